@@ -2,6 +2,7 @@ package app
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/mariusor/littr.go/internal/errors"
@@ -28,6 +29,16 @@ type OAuth struct {
 	State        string
 }
 
+// TwoFactor holds the second login factor of an account
+type TwoFactor struct {
+	// Secret is the base32 encoded TOTP secret, encrypted with the server key
+	Secret string `json:"secret,omitempty"`
+	// LastStep is the time step of the last accepted TOTP code, the codes up to it can't be used again
+	LastStep int64 `json:"step,omitempty"`
+	// RecoveryCodes are the bcrypt hashes of the one time codes which can replace a TOTP code
+	RecoveryCodes [][]byte `json:"recovery,omitempty"`
+}
+
 type AccountMetadata struct {
 	Password     []byte        `json:"pw,omitempty"`
 	Provider     string        `json:"provider,omitempty"`
@@ -43,21 +54,29 @@ type AccountMetadata struct {
 	LikedIRI     string        `json:"liked,omitempty"`
 	FollowersIRI string        `json:"followers,omitempty"`
 	FollowingIRI string        `json:"following,omitempty"`
+	CommentSort  string        `json:"commentSort,omitempty"`
+	Languages    []string      `json:"languages,omitempty"`
+	AlsoKnownAs  []string      `json:"alsoKnownAs,omitempty"`
+	MovedTo      string        `json:"movedTo,omitempty"`
+	TwoFactor    *TwoFactor    `json:"2fa,omitempty"`
 	OAuth        OAuth         `json:-`
 }
 
 type AccountCollection []Account
 
 type Account struct {
-	Email     string           `json:"email,omitempty"`
-	Hash      Hash             `json:"hash,omitempty"`
-	Score     int64            `json:"score,omitempty"`
-	Handle    string           `json:"handle,omitempty"`
-	CreatedAt time.Time        `json:"-"`
-	UpdatedAt time.Time        `json:"-"`
-	Flags     FlagBits         `json:"flags,omitempty"`
-	Metadata  *AccountMetadata `json:"-"`
-	Votes     VoteCollection   `json:"votes,omitempty"`
+	Email     string            `json:"email,omitempty"`
+	Hash      Hash              `json:"hash,omitempty"`
+	Score     int64             `json:"score,omitempty"`
+	Handle    string            `json:"handle,omitempty"`
+	CreatedAt time.Time         `json:"-"`
+	UpdatedAt time.Time         `json:"-"`
+	Flags     FlagBits          `json:"flags,omitempty"`
+	Role      Role              `json:"role,omitempty"`
+	Metadata  *AccountMetadata  `json:"-"`
+	Votes     VoteCollection    `json:"votes,omitempty"`
+	Saved     ItemCollection    `json:"-"`
+	Choices   map[Hash][]string `json:"-"`
 }
 
 // Hash is a local type for string, it should hold a [32]byte array actually
@@ -134,6 +153,24 @@ func (a Account) VotedOn(i Item) *Vote {
 	return nil
 }
 
+// HasSaved returns if the "i" item is in the account's saved items
+func (a Account) HasSaved(i Item) bool {
+	for _, s := range a.Saved {
+		if s.Hash == i.Hash {
+			return true
+		}
+	}
+	return false
+}
+
+// ChoicesFor returns the options the account chose in the "i" poll item
+func (a Account) ChoicesFor(i Item) []string {
+	if a.Choices == nil {
+		return nil
+	}
+	return a.Choices[i.Hash]
+}
+
 func (a Account) GetLink() string {
 	if a.IsLocal() {
 		return fmt.Sprintf("/~%s", a.Handle)
@@ -141,6 +178,81 @@ func (a Account) GetLink() string {
 	return a.Metadata.URL
 }
 
+// IsModerator returns if the account is a local one with the moderator or the admin role
+func (a Account) IsModerator() bool {
+	role := a.GetRole()
+	return role == RoleModerator || role == RoleAdmin
+}
+
+// Deleted returns if the account was deleted by its owner
+func (a Account) Deleted() bool {
+	return (a.Flags & FlagsDeleted) == FlagsDeleted
+}
+
+// Delete adds the deleted flag on an account
+func (a *Account) Delete() {
+	a.Flags |= FlagsDeleted
+}
+
+// Moved returns if the account has migrated to a different actor
+func (a Account) Moved() bool {
+	return a.Metadata != nil && len(a.Metadata.MovedTo) > 0
+}
+
+// IsAlias returns if the "iri" actor was declared by the account as one of its aliases
+func (a Account) IsAlias(iri string) bool {
+	if a.Metadata == nil {
+		return false
+	}
+	for _, aka := range a.Metadata.AlsoKnownAs {
+		if strings.TrimRight(aka, "/") == strings.TrimRight(iri, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTwoFactor returns if the account needs a TOTP code, besides the password, for logging in
+func (a Account) HasTwoFactor() bool {
+	return a.Metadata != nil && a.Metadata.TwoFactor != nil && len(a.Metadata.TwoFactor.Secret) > 0
+}
+
+// IsVerified returns if the account confirmed its email address
+func (a Account) IsVerified() bool {
+	return (a.Flags & FlagsUnverified) == FlagsNone
+}
+
+// Verify removes the unverified flag from an account
+func (a *Account) Verify() {
+	a.Flags &^= FlagsUnverified
+}
+
+// Blocked returns if the account was blocked by a moderator
+func (a Account) Blocked() bool {
+	return (a.Flags & FlagsBlocked) == FlagsBlocked
+}
+
+// Block adds the blocked flag on an account
+func (a *Account) Block() {
+	a.Flags |= FlagsBlocked
+}
+
+// Unblock removes the blocked flag from an account
+func (a *Account) Unblock() {
+	a.Flags &^= FlagsBlocked
+}
+
+// ValidateInteraction returns an error if the account is not allowed to submit content or to vote
+func (a Account) ValidateInteraction() error {
+	if a.Blocked() {
+		return errors.Forbiddenf("your account was blocked by a moderator")
+	}
+	if !a.IsVerified() {
+		return errors.Forbiddenf("you need to confirm your email address before submitting or voting")
+	}
+	return nil
+}
+
 // IsLogged should show if current user was loaded from a session
 func (a Account) IsLogged() bool {
 	return !a.CreatedAt.IsZero() || a.Hash != AnonymousHash
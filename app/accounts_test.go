@@ -0,0 +1,75 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccount_HasSaved(t *testing.T) {
+	a := Account{
+		Handle: "jane",
+		Saved:  ItemCollection{{Hash: Hash("162edb32c80d0e6dd3114fbb59d6273b")}},
+	}
+	if !a.HasSaved(Item{Hash: Hash("162edb32c80d0e6dd3114fbb59d6273b")}) {
+		t.Errorf("the item should be in the saved items of %s", a.Handle)
+	}
+	if a.HasSaved(Item{Hash: Hash("dc6f5f5bf55bc1073715c98c69fa7ca8")}) {
+		t.Errorf("the item should not be in the saved items of %s", a.Handle)
+	}
+}
+
+func TestLoadItemsFilter_SavedBy(t *testing.T) {
+	f := LoadItemsFilter{SavedBy: []string{"jane"}}
+	f.WithContentAlias("item")
+	wheres, values := f.GetWhereClauses()
+
+	clause := ""
+	for _, w := range wheres {
+		if strings.Contains(w, `"saved_items"`) {
+			clause = w
+		}
+	}
+	if !strings.Contains(clause, `"item"."id" IN (SELECT "saved_items"."item_id" FROM "saved_items"`) {
+		t.Errorf("missing the saved items clause in %v", wheres)
+	}
+	found := false
+	for _, v := range values {
+		if v == "jane" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("missing the saved by value in %v", values)
+	}
+}
+
+func TestAccount_IsModerator(t *testing.T) {
+	defer func(m []string) { Instance.Config.Moderators = m }(Instance.Config.Moderators)
+	Instance.Config.Moderators = []string{"jane"}
+
+	jane := Account{Hash: Hash("dc6f5f5bf55bc1073715c98c69fa7ca8"), Handle: "jane", CreatedAt: time.Now()}
+	if !jane.IsModerator() {
+		t.Errorf("%s should be a moderator", jane.Handle)
+	}
+	john := Account{Hash: Hash("162edb32c80d0e6dd3114fbb59d6273b"), Handle: "john", CreatedAt: time.Now()}
+	if john.IsModerator() {
+		t.Errorf("%s should not be a moderator", john.Handle)
+	}
+}
+
+func TestAccount_IsAlias(t *testing.T) {
+	a := Account{
+		Handle:   "jane",
+		Metadata: &AccountMetadata{AlsoKnownAs: []string{"https://remote.org/users/jane/"}},
+	}
+	if !a.IsAlias("https://remote.org/users/jane") {
+		t.Errorf("https://remote.org/users/jane should be an alias of %s", a.Handle)
+	}
+	if a.IsAlias("https://remote.org/users/eve") {
+		t.Errorf("https://remote.org/users/eve should not be an alias of %s", a.Handle)
+	}
+	if (Account{Handle: "eve"}).IsAlias("https://remote.org/users/jane") {
+		t.Errorf("an account without metadata should not have aliases")
+	}
+}
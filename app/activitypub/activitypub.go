@@ -6,8 +6,13 @@ import (
 	ap "github.com/go-ap/activitypub"
 	as "github.com/go-ap/activitystreams"
 	"github.com/go-ap/jsonld"
+	"time"
 )
 
+// HashtagType is the type used by Mastodon and other servers for tags on objects
+// It's not part of the ActivityStreams vocabulary, it comes from the "as:Hashtag" extension
+const HashtagType as.ActivityVocabularyType = "Hashtag"
+
 // PublicKey holds the ActivityPub compatible public key data
 type PublicKey struct {
 	ID           as.ObjectID     `jsonld:"id,omitempty"`
@@ -23,6 +28,13 @@ type Person struct {
 	PublicKey PublicKey `jsonld:"publicKey,omitempty"`
 	// Score is our own custom property for which we needed to extend the existing AP one
 	Score int64 `jsonld:"score"`
+	// Languages are the codes of the languages the content of the actor is written in.
+	// We're using it only for the Service actor that represents the instance.
+	Languages []string `jsonld:"languages,omitempty"`
+	// AlsoKnownAs are the IRIs of the other actors that belong to the same person, it's used to validate account moves
+	AlsoKnownAs []as.IRI `jsonld:"alsoKnownAs,omitempty"`
+	// MovedTo is the IRI of the actor the account has migrated to
+	MovedTo as.IRI `jsonld:"movedTo,omitempty"`
 }
 
 type Service = Person
@@ -33,6 +45,26 @@ type Service = Person
 type Article struct {
 	ap.Object
 	Score int64 `jsonld:"score"`
+	// Ups and Downs are the number of positive and negative votes the object received
+	Ups   int64 `jsonld:"ups,omitempty"`
+	Downs int64 `jsonld:"downs,omitempty"`
+	// Locked and Archived show that the object doesn't accept replies or votes anymore
+	Locked   bool `jsonld:"locked,omitempty"`
+	Archived bool `jsonld:"archived,omitempty"`
+	// Draft and Scheduled show that the object is not published yet, they're visible only to its author
+	Draft     bool `jsonld:"draft,omitempty"`
+	Scheduled bool `jsonld:"scheduled,omitempty"`
+}
+
+// Question it should be identical to:
+//    github.com/go-ap/activitystreams/activity.go#Question
+// We need it here in order to be able to add to it our Score property and to load the poll options
+type Question struct {
+	Article
+	// OneOf holds the options of a single choice poll
+	OneOf as.ItemCollection `jsonld:"oneOf,omitempty"`
+	// AnyOf holds the options of a multiple choice poll
+	AnyOf as.ItemCollection `jsonld:"anyOf,omitempty"`
 }
 
 // OrderedCollection should be identical to:
@@ -107,7 +139,130 @@ func (a *Article) UnmarshalJSON(data []byte) error {
 	if score, err := jsonparser.GetInt(data, "score"); err == nil {
 		a.Score = score
 	}
+	if ups, err := jsonparser.GetInt(data, "ups"); err == nil {
+		a.Ups = ups
+	}
+	if downs, err := jsonparser.GetInt(data, "downs"); err == nil {
+		a.Downs = downs
+	}
+	if locked, err := jsonparser.GetBoolean(data, "locked"); err == nil {
+		a.Locked = locked
+	}
+	if archived, err := jsonparser.GetBoolean(data, "archived"); err == nil {
+		a.Archived = archived
+	}
+	if draft, err := jsonparser.GetBoolean(data, "draft"); err == nil {
+		a.Draft = draft
+	}
+	if scheduled, err := jsonparser.GetBoolean(data, "scheduled"); err == nil {
+		a.Scheduled = scheduled
+	}
+	if attachments := loadAttachments(data); len(attachments) > 0 {
+		a.Attachment = attachments
+	}
+	if cnt, err := jsonparser.GetInt(data, "replies", "totalItems"); err == nil {
+		id, _ := jsonparser.GetString(data, "replies", "id")
+		replies := as.CollectionNew(as.ObjectID(id))
+		replies.TotalItems = uint(cnt)
+		a.Replies = replies
+	}
+
+	return nil
+}
+
+// loadAttachments reads the "attachment" property, which can hold a single object or an array of them.
+// The URL of an attachment can be a plain IRI or a Link object, we keep only the first href.
+func loadAttachments(data []byte) as.ItemCollection {
+	attachments := make(as.ItemCollection, 0)
+	load := func(val []byte) {
+		url, err := jsonparser.GetString(val, "url")
+		if err != nil {
+			if url, err = jsonparser.GetString(val, "url", "href"); err != nil {
+				url, _ = jsonparser.GetString(val, "url", "[0]", "href")
+			}
+		}
+		if len(url) == 0 {
+			return
+		}
+		o := as.Object{
+			Type: as.DocumentType,
+			URL:  as.IRI(url),
+		}
+		if typ, err := jsonparser.GetString(val, "type"); err == nil {
+			o.Type = as.ActivityVocabularyType(typ)
+		}
+		if mt, err := jsonparser.GetString(val, "mediaType"); err == nil {
+			o.MediaType = as.MimeType(mt)
+		}
+		if name, err := jsonparser.GetString(val, "name"); err == nil && len(name) > 0 {
+			o.Name = as.NaturalLanguageValues{{Ref: as.NilLangRef, Value: name}}
+		}
+		if icon, err := jsonparser.GetString(val, "icon", "url"); err == nil {
+			ic := as.Object{
+				Type: as.ImageType,
+				URL:  as.IRI(icon),
+			}
+			if mt, err := jsonparser.GetString(val, "icon", "mediaType"); err == nil {
+				ic.MediaType = as.MimeType(mt)
+			}
+			o.Icon = ic
+		}
+		attachments = append(attachments, o)
+	}
 
+	val, typ, _, err := jsonparser.Get(data, "attachment")
+	if err != nil {
+		return attachments
+	}
+	switch typ {
+	case jsonparser.Array:
+		jsonparser.ArrayEach(val, func(v []byte, t jsonparser.ValueType, _ int, _ error) {
+			if t == jsonparser.Object {
+				load(v)
+			}
+		})
+	case jsonparser.Object:
+		load(val)
+	}
+	return attachments
+}
+
+// UnmarshalJSON tries to load json data to Question object
+func (q *Question) UnmarshalJSON(data []byte) error {
+	if err := q.Article.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	if q.EndTime.IsZero() {
+		// some servers use "closed" for the end time of the poll
+		if closed, err := jsonparser.GetString(data, "closed"); err == nil {
+			q.EndTime, _ = time.Parse(time.RFC3339, closed)
+		}
+	}
+	loadOptions := func(key string) as.ItemCollection {
+		options := make(as.ItemCollection, 0)
+		jsonparser.ArrayEach(data, func(val []byte, typ jsonparser.ValueType, _ int, _ error) {
+			if typ != jsonparser.Object {
+				return
+			}
+			name, err := jsonparser.GetString(val, "name")
+			if err != nil || len(name) == 0 {
+				return
+			}
+			o := as.Object{
+				Type: as.NoteType,
+				Name: as.NaturalLanguageValues{{Ref: as.NilLangRef, Value: name}},
+			}
+			replies := as.CollectionNew("")
+			if cnt, err := jsonparser.GetInt(val, "replies", "totalItems"); err == nil {
+				replies.TotalItems = uint(cnt)
+			}
+			o.Replies = replies
+			options = append(options, o)
+		}, key)
+		return options
+	}
+	q.OneOf = loadOptions("oneOf")
+	q.AnyOf = loadOptions("anyOf")
 	return nil
 }
 
@@ -144,6 +299,25 @@ func (p *Person) UnmarshalJSON(data []byte) error {
 	if pubData, _, _, err := jsonparser.Get(data, "publicKey"); err == nil {
 		p.PublicKey.UnmarshalJSON(pubData)
 	}
+	jsonparser.ArrayEach(data, func(val []byte, typ jsonparser.ValueType, _ int, _ error) {
+		if typ == jsonparser.String {
+			p.Languages = append(p.Languages, string(val))
+		}
+	}, "languages")
+	if aka, typ, _, err := jsonparser.Get(data, "alsoKnownAs"); err == nil {
+		if typ == jsonparser.String {
+			p.AlsoKnownAs = append(p.AlsoKnownAs, as.IRI(aka))
+		} else {
+			jsonparser.ArrayEach(aka, func(val []byte, typ jsonparser.ValueType, _ int, _ error) {
+				if typ == jsonparser.String {
+					p.AlsoKnownAs = append(p.AlsoKnownAs, as.IRI(val))
+				}
+			})
+		}
+	}
+	if moved, err := jsonparser.GetString(data, "movedTo"); err == nil {
+		p.MovedTo = as.IRI(moved)
+	}
 
 	return nil
 }
@@ -406,13 +580,19 @@ func (a *Activity) UnmarshalJSON(data []byte) error {
 		return err
 	}
 	*a = Activity(it)
-	if _, err := jsonparser.GetString(data, "object", "type"); err == nil {
+	if typ, err := jsonparser.GetString(data, "object", "type"); err == nil {
 		// when we have a type try to
 		// convert objects to local articles
-		obj := Article{}
 		if data, _, _, err := jsonparser.Get(data, "object"); err == nil {
-			obj.UnmarshalJSON(data)
-			a.Object = obj
+			if as.ActivityVocabularyType(typ) == as.QuestionType {
+				obj := Question{}
+				obj.UnmarshalJSON(data)
+				a.Object = obj
+			} else {
+				obj := Article{}
+				obj.UnmarshalJSON(data)
+				a.Object = obj
+			}
 		}
 	}
 
@@ -471,6 +651,14 @@ func JSONGetItemByType(typ as.ActivityVocabularyType) (as.Item, error) {
 		ret = &Article{}
 		o := ret.(*Article)
 		o.Type = typ
+	case as.QuestionType:
+		ret = &Question{}
+		o := ret.(*Question)
+		o.Type = typ
+	case HashtagType:
+		ret = &as.Object{}
+		o := ret.(*as.Object)
+		o.Type = typ
 	case as.ActorType:
 		fallthrough
 	case as.PersonType:
@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/mariusor/littr.go/app"
 
@@ -94,6 +95,11 @@ func loadAPItem(item app.Item) as.Item {
 	if id, ok := BuildObjectIDFromItem(item); ok {
 		o.ID = id
 	}
+	// when we don't know the language we don't use a contentMap, so the receiver can detect it
+	lang := as.NilLangRef
+	if l := item.Language(); len(l) > 0 {
+		lang = as.LangRef(l)
+	}
 	if item.MimeType == app.MimeTypeURL {
 		o.Type = as.PageType
 		o.URL = as.IRI(item.Data)
@@ -117,14 +123,14 @@ func loadAPItem(item app.Item) as.Item {
 			o.Object.Source.MediaType = as.MimeType(item.MimeType)
 			o.MediaType = as.MimeType(app.MimeTypeHTML)
 			if item.Data != "" {
-				o.Source.Content.Set("en", string(item.Data))
-				o.Content.Set("en", string(app.Markdown(string(item.Data))))
+				o.Source.Content.Set(lang, string(item.Data))
+				o.Content.Set(lang, string(app.Markdown(string(item.Data))))
 			}
 		case app.MimeTypeText:
 			fallthrough
 		case app.MimeTypeHTML:
 			o.MediaType = as.MimeType(item.MimeType)
-			o.Content.Set("en", string(item.Data))
+			o.Content.Set(lang, string(item.Data))
 		}
 	}
 
@@ -156,8 +162,14 @@ func loadAPItem(item app.Item) as.Item {
 
 	//o.Generator = as.IRI(app.Instance.BaseURL)
 	o.Score = item.Score / app.ScoreMultiplier
+	o.Ups = item.Ups
+	o.Downs = item.Downs
+	o.Locked = item.Locked()
+	o.Archived = item.Archived()
+	o.Draft = item.Draft()
+	o.Scheduled = item.Scheduled()
 	if item.Title != "" {
-		o.Name.Set("en", string(item.Title))
+		o.Name.Set(lang, string(item.Title))
 	}
 	if item.SubmittedBy != nil {
 		id := BuildActorID(*item.SubmittedBy)
@@ -171,6 +183,11 @@ func loadAPItem(item app.Item) as.Item {
 		id, _ := BuildObjectIDFromItem(*item.OP)
 		o.Context = as.IRI(id)
 	}
+	if item.ReplyCount > 0 && len(o.ID) > 0 {
+		replies := as.CollectionNew(BuildRepliesCollectionID(&o))
+		replies.TotalItems = uint(item.ReplyCount)
+		o.Replies = replies
+	}
 	if item.Metadata != nil {
 		m := item.Metadata
 		if m.Mentions != nil || m.Tags != nil {
@@ -186,15 +203,73 @@ func loadAPItem(item app.Item) as.Item {
 			for _, tag := range m.Tags {
 				t := as.Object{
 					ID:   as.ObjectID(tag.URL),
+					Type: ap.HashtagType,
 					Name: as.NaturalLanguageValues{{Ref: as.NilLangRef, Value: tag.Name}},
 				}
 				o.Tag.Append(t)
 			}
 		}
+		if len(m.Attachments) > 0 {
+			o.Attachment = loadAPAttachments(m.Attachments)
+		}
+		if m.Poll != nil {
+			return loadAPQuestion(o, *m.Poll)
+		}
 	}
 
 	return &o
 }
+
+// loadAPAttachments converts the media files of an item to objects, using the thumbnails as their icons
+func loadAPAttachments(attachments app.AttachmentCollection) as.ItemCollection {
+	col := make(as.ItemCollection, 0)
+	for _, att := range attachments {
+		a := as.Object{
+			Type:      as.DocumentType,
+			URL:       as.IRI(att.URL),
+			MediaType: as.MimeType(att.MimeType),
+		}
+		if att.IsImage() {
+			a.Type = as.ImageType
+		}
+		if len(att.Name) > 0 {
+			a.Name = as.NaturalLanguageValues{{Ref: as.NilLangRef, Value: att.Name}}
+		}
+		if len(att.Thumbnail.URI) > 0 {
+			a.Icon = as.Object{
+				Type:      as.ImageType,
+				URL:       as.IRI(att.Thumbnail.URI),
+				MediaType: as.MimeType(att.Thumbnail.MimeType),
+			}
+		}
+		col = append(col, a)
+	}
+	return col
+}
+
+// loadAPQuestion converts the "o" object to a Question with the options of the "p" poll
+func loadAPQuestion(o ap.Article, p app.Poll) *ap.Question {
+	q := ap.Question{Article: o}
+	q.Type = as.QuestionType
+	q.EndTime = p.EndTime
+
+	options := make(as.ItemCollection, 0)
+	for _, opt := range p.Options {
+		replies := as.CollectionNew("")
+		replies.TotalItems = uint(opt.Count)
+		options = append(options, as.Object{
+			Type:    as.NoteType,
+			Name:    as.NaturalLanguageValues{{Ref: as.NilLangRef, Value: opt.Name}},
+			Replies: replies,
+		})
+	}
+	if p.Multiple {
+		q.AnyOf = options
+	} else {
+		q.OneOf = options
+	}
+	return &q
+}
 func accountURL(acc app.Account) as.IRI {
 	return as.IRI(fmt.Sprintf("%s%s", app.Instance.BaseURL, frontend.AccountPermaLink(acc)))
 }
@@ -216,6 +291,12 @@ func loadAPPerson(a app.Account) *ap.Person {
 			avatar.URL = as.IRI(a.Metadata.Icon.URI)
 			p.Icon = avatar
 		}
+		for _, aka := range a.Metadata.AlsoKnownAs {
+			p.AlsoKnownAs = append(p.AlsoKnownAs, as.IRI(aka))
+		}
+		if len(a.Metadata.MovedTo) > 0 {
+			p.MovedTo = as.IRI(a.Metadata.MovedTo)
+		}
 	}
 
 	p.PreferredUsername.Set("en", a.Handle)
@@ -242,7 +323,11 @@ func loadAPPerson(a app.Account) *ap.Person {
 			p.URL = as.IRI(a.Metadata.URL)
 		}
 	} else {
-		p.Name.Set("en", a.Handle)
+		if a.HasMetadata() && len(a.Metadata.Name) > 0 {
+			p.Name.Set("en", a.Metadata.Name)
+		} else {
+			p.Name.Set("en", a.Handle)
+		}
 
 		p.Outbox = as.IRI(BuildCollectionID(a, new(goap.Outbox)))
 		p.Inbox = as.IRI(BuildCollectionID(a, new(goap.Inbox)))
@@ -373,6 +458,10 @@ func (h handler) HandleCollectionActivity(w http.ResponseWriter, r *http.Request
 		fallthrough
 	case "inbox":
 		fallthrough
+	case "drafts":
+		fallthrough
+	case "saved":
+		fallthrough
 	case "outbox":
 		item, ok := val.(app.Item)
 		if !ok {
@@ -421,6 +510,10 @@ func (h handler) HandleCollectionActivityObject(w http.ResponseWriter, r *http.R
 		fallthrough
 	case "replies":
 		fallthrough
+	case "drafts":
+		fallthrough
+	case "saved":
+		fallthrough
 	case "outbox":
 		i, ok := val.(app.Item)
 		if !ok {
@@ -492,11 +585,18 @@ func loadCollection(items app.Collection, count uint, typ string, filters app.Pa
 	if len(f.LoadItemsFilter.AttributedTo) == 1 {
 		f.LoadItemsFilter.AttributedTo = nil
 	}
+	if len(f.LoadItemsFilter.SavedBy) == 1 {
+		f.LoadItemsFilter.SavedBy = nil
+	}
 	switch typ {
 	case "inbox":
 		fallthrough
 	case "replies":
 		fallthrough
+	case "drafts":
+		fallthrough
+	case "saved":
+		fallthrough
 	case "outbox":
 		if col, ok := items.(app.ItemCollection); ok {
 			if _, err := loadAPItemCollection(&oc, col); err != nil {
@@ -795,6 +895,18 @@ func getValidObjectTypes(typ as.ActivityVocabularyType) []as.ActivityVocabularyT
 			as.ArticleType,
 			as.DocumentType,
 			as.PageType,
+			as.QuestionType,
+		}
+	case as.AddType:
+		fallthrough
+	case as.RemoveType:
+		// these are used for managing the saved items of an actor
+		return []as.ActivityVocabularyType{
+			as.NoteType,
+			as.ArticleType,
+			as.DocumentType,
+			as.PageType,
+			as.QuestionType,
 		}
 	case as.DeleteType:
 		return []as.ActivityVocabularyType{
@@ -802,6 +914,7 @@ func getValidObjectTypes(typ as.ActivityVocabularyType) []as.ActivityVocabularyT
 			as.ArticleType,
 			as.DocumentType,
 			as.PageType,
+			as.QuestionType,
 			// not sure if we need the other types
 			as.TombstoneType,
 		}
@@ -847,10 +960,27 @@ func validateObject(a as.Item, repo app.CanLoadItems, typ as.ActivityVocabularyT
 		if len(cont.Hash) > 0 {
 			// dunno if this is an error
 		}
+		// replies to locked or archived threads are not accepted
+		if cont.Parent != nil {
+			if err = validateItemInteraction(*cont.Parent, repo); err != nil {
+				return a, err
+			}
+		}
+		if cont.OP != nil {
+			if err = validateItemInteraction(*cont.OP, repo); err != nil {
+				return a, err
+			}
+		}
 	case as.UpdateType:
 		if len(cont.Hash) == 0 {
 			return o, objectMissingError{err: err, object: a}
 		}
+	case as.LikeType:
+		fallthrough
+	case as.DislikeType:
+		if err = validateItemInteraction(cont, repo); err != nil {
+			return a, err
+		}
 	}
 
 	o = loadAPItem(cont)
@@ -982,6 +1112,7 @@ func validateInboxActivityType(typ as.ActivityVocabularyType) error {
 		//as.DeleteType, // @todo(marius): not implemented
 		//as.UndoType,   // @todo(marius): not implemented
 		as.FollowType, // @todo(marius): not implemented
+		as.MoveType,
 	}
 	if err := validateItemType(typ, validTypes); err != nil {
 		return errors.NewNotValid(err, "failed to validate activity type for inbox collection")
@@ -989,6 +1120,19 @@ func validateInboxActivityType(typ as.ActivityVocabularyType) error {
 	return nil
 }
 
+// validateItemInteraction checks if the local item "it" still accepts replies and votes.
+// Items we don't know about are not validated.
+func validateItemInteraction(it app.Item, repo app.CanLoadItems) error {
+	if len(it.Hash) == 0 || repo == nil {
+		return nil
+	}
+	cur, err := repo.LoadItem(app.Filters{LoadItemsFilter: app.LoadItemsFilter{Key: app.Hashes{it.Hash}}})
+	if err != nil {
+		return nil
+	}
+	return cur.ValidateInteraction()
+}
+
 func validateInboxActivity(a ap.Activity, repo app.CanLoad) (ap.Activity, error) {
 	// TODO(marius): need to add a step to verify the Activity Actor against the one loaded from the Authorization header.
 	if err := validateInboxActivityType(a.GetType()); err != nil {
@@ -1006,7 +1150,18 @@ func validateInboxActivity(a ap.Activity, repo app.CanLoad) (ap.Activity, error)
 	} else {
 		a.Actor = p
 	}
-	if o, err := validateObject(a.Object, repo, a.GetType()); err != nil {
+	if isActorMove(a) {
+		if err := validateActorMove(a); err != nil {
+			aErr.object = err
+		}
+	} else if a.GetType() == as.FollowType {
+		// the object of a Follow activity is the local actor being followed
+		if p, err := validateLocalActor(a.Object, repo); err != nil {
+			aErr.object = err
+		} else {
+			a.Object = p
+		}
+	} else if o, err := validateObject(a.Object, repo, a.GetType()); err != nil {
 		aErr.object = err
 	} else {
 		a.Object = o
@@ -1027,6 +1182,9 @@ func validateOutboxActivityType(typ as.ActivityVocabularyType) error {
 		as.DislikeType,
 		as.DeleteType,
 		as.UndoType, // @todo(marius): not implemented yet
+		as.AddType,
+		as.RemoveType,
+		as.MoveType,
 	}
 	if err := validateItemType(typ, validTypes); err != nil {
 		return errors.Annotate(err, "failed to validate activity type for outbox collection")
@@ -1043,23 +1201,190 @@ func validateOutboxActivity(a ap.Activity, repo app.CanLoadAccounts) (ap.Activit
 	} else {
 		a.Actor = p
 	}
+	if isActorUpdate(a) || isActorDelete(a) {
+		if a.Object.GetLink() != a.Actor.GetLink() {
+			return a, errors.Forbiddenf("actors can only update or delete their own profile")
+		}
+		return a, nil
+	}
+	if isActorMove(a) {
+		if err := validateActorMove(a); err != nil {
+			return a, errors.Annotate(err, "failed to validate move for outbox collection")
+		}
+		return a, nil
+	}
 	if o, err := validateObject(a.Object, repo.(app.CanLoadItems), a.GetType()); err != nil {
 		return a, errors.Annotate(err, "failed to validate object for outbox collection")
 	} else {
 		a.Object = o
 	}
+	if a.GetType() == as.AddType || a.GetType() == as.RemoveType {
+		if err := validateSavedTarget(a); err != nil {
+			return a, errors.Annotate(err, "failed to validate target for outbox collection")
+		}
+	}
 	return a, nil
 }
 
-func (h *handler) saveActivityContent(a ap.Activity, r *http.Request, w http.ResponseWriter) (int, string) {
+// isActorUpdate returns if "a" is an Update activity of an actor's profile, instead of an item's
+func isActorUpdate(a ap.Activity) bool {
+	if a.GetType() != as.UpdateType || a.Object == nil || a.Object.IsLink() {
+		return false
+	}
+	return a.Object.GetType() == as.PersonType
+}
+
+// isActorDelete returns if "a" is a Delete activity of an actor, instead of an item
+func isActorDelete(a ap.Activity) bool {
+	if a.GetType() != as.DeleteType || a.Object == nil || a.Object.IsLink() {
+		return false
+	}
+	return a.Object.GetType() == as.PersonType
+}
+
+// validateItemChange returns an error if the "acc" account can't update or delete the item which is the object of "a".
+// Only the authors can edit their items, and the accounts with the delete-item permission can delete any of them,
+// when "moderate" shows the request was granted the admin scope.
+func validateItemChange(a ap.Activity, acc app.Account, repo app.CanLoadItems, moderate bool) error {
+	it := app.Item{}
+	if err := it.FromActivityPub(a.Object); err != nil || len(it.Hash) == 0 {
+		return errors.NotValidf("invalid object of %s activity", a.GetType())
+	}
+	if repo == nil {
+		return errors.Errorf("could not load item repository from Context")
+	}
+	old, err := repo.LoadItem(app.Filters{
+		LoadItemsFilter: app.LoadItemsFilter{Key: app.Hashes{it.Hash}, Draft: []bool{true, false}},
+	})
+	if err != nil {
+		return errors.NewNotFound(err, "item %s", it.Hash)
+	}
+	if old.SubmittedBy != nil && old.SubmittedBy.Hash == acc.Hash {
+		return nil
+	}
+	if a.GetType() == as.DeleteType && moderate && acc.Can(app.PermDeleteItem) {
+		return nil
+	}
+	return errors.Forbiddenf("%s is not allowed to %s the item %s", acc.Handle, strings.ToLower(string(a.GetType())), it.Hash)
+}
+
+// isActorMove returns if "a" is a Move activity of an actor to a different one
+func isActorMove(a ap.Activity) bool {
+	return a.GetType() == as.MoveType
+}
+
+// validateActorMove checks that a Move activity moves its own actor and that it has a target actor
+func validateActorMove(a ap.Activity) error {
+	if a.Object == nil || a.Actor == nil || a.Object.GetLink() != a.Actor.GetLink() {
+		return errors.Forbiddenf("actors can only move their own account")
+	}
+	if a.Target == nil || len(a.Target.GetLink()) == 0 {
+		return errors.NotValidf("missing target")
+	}
+	if a.Target.GetLink() == a.Actor.GetLink() {
+		return errors.NotValidf("an account can't be moved to itself")
+	}
+	return nil
+}
+
+// validateSavedTarget checks that the target of an Add or Remove activity is the saved collection of its actor,
+// which is the only collection we currently allow to be managed this way
+func validateSavedTarget(a ap.Activity) error {
+	if a.Target == nil {
+		return errors.NotValidf("missing target")
+	}
+	tgt := strings.TrimRight(a.Target.GetLink().String(), "/")
+	if tgt != fmt.Sprintf("%s/saved", strings.TrimRight(a.Actor.GetLink().String(), "/")) {
+		return errors.NotValidf("target %s is not the saved collection of the actor", tgt)
+	}
+	return nil
+}
+
+// publishingFlags sets the flags of an item received in an activity:
+// only the author can keep an item as a draft or schedule it through their outbox,
+// the items delivered to the inbox are always published.
+func publishingFlags(it *app.Item, outbox bool) {
+	if !outbox {
+		it.Flags &^= app.FlagsUnpublished
+		return
+	}
+	if !it.Draft() && it.SubmittedAt.After(time.Now().UTC()) {
+		// a publishing date in the future schedules the item
+		it.Flags |= app.FlagsScheduled
+	}
+}
+
+func (h *handler) saveActivityContent(a ap.Activity, outbox bool, r *http.Request, w http.ResponseWriter) (int, string) {
 	status := http.StatusInternalServerError
 	var location string
 	switch a.GetType() {
 	case as.FollowType:
 		status = http.StatusAccepted
+		if repo, ok := app.ContextNotificationSaver(r.Context()); ok {
+			followed := app.Account{}
+			followed.FromActivityPub(a.Object)
+			follower := app.Account{}
+			follower.FromActivityPub(a.Actor)
+			n := app.Notification{
+				Type:  app.NotificationFollow,
+				For:   &followed,
+				Actor: &follower,
+			}
+			if _, err := repo.SaveNotification(n); err != nil {
+				h.logger.WithContext(log.Ctx{
+					"err":      err,
+					"trace":    errors.Details(err),
+					"follower": a.Actor.GetLink(),
+					"followed": a.Object.GetLink(),
+				}).Error(err.Error())
+			}
+		}
+	case as.AddType:
+		fallthrough
+	case as.RemoveType:
+		acc := app.Account{}
+		acc.FromActivityPub(a.Actor)
+		it := app.Item{}
+		if err := it.FromActivityPub(a.Object); err != nil {
+			h.logger.WithContext(log.Ctx{
+				"err":   err,
+				"trace": errors.Details(err),
+			}).Error("unable to load item from ActivityPub object")
+			h.HandleError(w, r, errors.NewNotValid(err, "not found"))
+			return http.StatusNotFound, ""
+		}
+		if repo, ok := app.ContextBookmarkSaver(r.Context()); ok {
+			var err error
+			if a.GetType() == as.AddType {
+				err = repo.SaveBookmark(acc, it)
+				status = http.StatusCreated
+				location = fmt.Sprintf("%s/self/following/%s/saved/%s", h.repo.BaseURL, acc.Hash, it.Hash)
+			} else {
+				err = repo.RemoveBookmark(acc, it)
+				status = http.StatusOK
+			}
+			if err != nil {
+				h.logger.WithContext(log.Ctx{
+					"err":     err,
+					"trace":   errors.Details(err),
+					"item":    it.Hash,
+					"account": acc.Hash,
+				}).Error(err.Error())
+				h.HandleError(w, r, errors.NewNotValid(err, "not found"))
+				return http.StatusNotFound, ""
+			}
+		}
+	case as.MoveType:
+		return h.saveActorMove(a, r, w)
 	case as.DeleteType:
+		if isActorDelete(a) {
+			return h.saveActorDelete(a, r, w)
+		}
 		fallthrough
 	case as.UpdateType:
+		if isActorUpdate(a) {
+			return h.saveActorUpdate(a, r, w)
+		}
 		fallthrough
 	case as.CreateType:
 		it := app.Item{}
@@ -1071,6 +1396,12 @@ func (h *handler) saveActivityContent(a ap.Activity, r *http.Request, w http.Res
 			h.HandleError(w, r, errors.NewNotValid(err, "not found"))
 			return http.StatusNotFound, ""
 		}
+		if poll, ok := loadAnsweredPoll(r, a, it); ok {
+			return h.savePollAnswer(poll, it, r, w)
+		}
+		if a.GetType() != as.DeleteType {
+			publishingFlags(&it, outbox)
+		}
 		if repo, ok := app.ContextItemSaver(r.Context()); ok {
 			newIt, err := repo.SaveItem(it)
 			if err != nil {
@@ -1087,7 +1418,11 @@ func (h *handler) saveActivityContent(a ap.Activity, r *http.Request, w http.Res
 				// we need to make a difference between created vote and updated vote
 				// created - http.StatusCreated
 				status = http.StatusCreated
-				location = fmt.Sprintf("%s/self/following/%s/outbox/%s", h.repo.BaseURL, newIt.SubmittedBy.Hash, newIt.Hash)
+				col := "outbox"
+				if !newIt.IsPublished() {
+					col = "drafts"
+				}
+				location = fmt.Sprintf("%s/self/following/%s/%s/%s", h.repo.BaseURL, newIt.SubmittedBy.Hash, col, newIt.Hash)
 			} else {
 				// updated - http.StatusOK
 				status = http.StatusOK
@@ -1132,6 +1467,252 @@ func (h *handler) saveActivityContent(a ap.Activity, r *http.Request, w http.Res
 	return status, location
 }
 
+// loadAnsweredPoll returns the poll that "it" is an answer to.
+// Answers are Notes which have only a name, the chosen option, and are in reply to the Question
+func loadAnsweredPoll(r *http.Request, a ap.Activity, it app.Item) (app.Item, bool) {
+	if a.GetType() != as.CreateType || it.Parent == nil || len(it.Parent.Hash) == 0 {
+		return app.Item{}, false
+	}
+	if len(it.Title) == 0 || len(strings.TrimSpace(it.Data)) > 0 {
+		return app.Item{}, false
+	}
+	loader, ok := app.ContextItemLoader(r.Context())
+	if !ok {
+		return app.Item{}, false
+	}
+	poll, err := loader.LoadItem(app.Filters{LoadItemsFilter: app.LoadItemsFilter{Key: app.Hashes{it.Parent.Hash}}})
+	if err != nil || !poll.IsPoll() {
+		return app.Item{}, false
+	}
+	return poll, true
+}
+
+func (h *handler) savePollAnswer(poll app.Item, answer app.Item, r *http.Request, w http.ResponseWriter) (int, string) {
+	if !poll.IsLocal() {
+		// the answers to remote polls need to be delivered to the inbox of their author, which we can't do yet
+		h.HandleError(w, r, errors.NotImplementedf("voting in remote polls is not supported"))
+		return http.StatusNotImplemented, ""
+	}
+	repo, ok := app.ContextPollVoteSaver(r.Context())
+	if !ok {
+		h.HandleError(w, r, errors.Errorf("could not load poll repository from Context"))
+		return http.StatusInternalServerError, ""
+	}
+	v := app.PollVote{
+		Item:        &poll,
+		SubmittedBy: answer.SubmittedBy,
+		Choices:     []string{answer.Title},
+	}
+	if err := repo.SavePollVote(v); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"err":     err,
+			"trace":   errors.Details(err),
+			"item":    poll.Hash,
+			"account": answer.SubmittedBy.Hash,
+		}).Error(err.Error())
+		h.HandleError(w, r, err)
+		return http.StatusNotFound, ""
+	}
+	return http.StatusOK, ""
+}
+
+// saveActorUpdate applies the profile from an Update activity to the local account of its actor.
+// Only the public properties are taken into account, the email and the credentials stay the same.
+func (h *handler) saveActorUpdate(a ap.Activity, r *http.Request, w http.ResponseWriter) (int, string) {
+	upd := app.Account{}
+	if err := upd.FromActivityPub(a.Object); err != nil || len(upd.Hash) == 0 {
+		h.HandleError(w, r, errors.NotValidf("unable to load the actor from the activity"))
+		return http.StatusNotFound, ""
+	}
+	if acc := authAccount(r); acc == nil || acc.Hash != upd.Hash {
+		h.HandleError(w, r, errors.Forbiddenf("the profile can only be updated by its owner"))
+		return http.StatusForbidden, ""
+	}
+	loader, ok := app.ContextAccountLoader(r.Context())
+	if !ok {
+		h.HandleError(w, r, errors.Errorf("could not load account repository from Context"))
+		return http.StatusInternalServerError, ""
+	}
+	acc, err := loader.LoadAccount(app.Filters{LoadAccountsFilter: app.LoadAccountsFilter{Key: app.Hashes{upd.Hash}}})
+	if err != nil {
+		h.HandleError(w, r, errors.NewNotFound(err, "not found"))
+		return http.StatusNotFound, ""
+	}
+	if acc.Metadata == nil {
+		acc.Metadata = &app.AccountMetadata{}
+	}
+	if upd.Metadata != nil {
+		acc.Metadata.Name = upd.Metadata.Name
+		acc.Metadata.Blurb = upd.Metadata.Blurb
+		acc.Metadata.Icon = upd.Metadata.Icon
+		acc.Metadata.AlsoKnownAs = upd.Metadata.AlsoKnownAs
+	}
+	acc.UpdatedAt = time.Now().UTC()
+	saver, ok := app.ContextAccountSaver(r.Context())
+	if !ok {
+		h.HandleError(w, r, errors.Errorf("could not load account repository from Context"))
+		return http.StatusInternalServerError, ""
+	}
+	if _, err := saver.SaveAccount(acc); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"err":     err,
+			"trace":   errors.Details(err),
+			"account": acc.Hash,
+		}).Error(err.Error())
+		h.HandleError(w, r, errors.NewNotValid(err, "unable to save the account"))
+		return http.StatusInternalServerError, ""
+	}
+	return http.StatusOK, ""
+}
+
+// saveActorDelete removes the personal data of the local account deleted by its actor
+func (h *handler) saveActorDelete(a ap.Activity, r *http.Request, w http.ResponseWriter) (int, string) {
+	acc := app.Account{}
+	if err := acc.FromActivityPub(a.Object); err != nil || len(acc.Hash) == 0 {
+		h.HandleError(w, r, errors.NotValidf("unable to load the actor from the activity"))
+		return http.StatusNotFound, ""
+	}
+	signer := authAccount(r)
+	if signer == nil || signer.Hash != acc.Hash {
+		h.HandleError(w, r, errors.Forbiddenf("the account can only be deleted by its owner"))
+		return http.StatusForbidden, ""
+	}
+	deleter, ok := app.ContextAccountDeleter(r.Context())
+	if !ok {
+		h.HandleError(w, r, errors.Errorf("could not load account repository from Context"))
+		return http.StatusInternalServerError, ""
+	}
+	if err := deleter.DeleteAccount(*signer); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"err":     err,
+			"trace":   errors.Details(err),
+			"account": acc.Hash,
+		}).Error(err.Error())
+		h.HandleError(w, r, errors.NewNotValid(err, "unable to delete the account"))
+		return http.StatusInternalServerError, ""
+	}
+	return http.StatusOK, ""
+}
+
+// loadMoveAccount loads the account of the "iri" actor, the remote ones are loaded by their IRI
+func loadMoveAccount(repo app.CanLoadAccounts, iri as.IRI) (app.Account, error) {
+	f := app.Filters{}
+	if err := validateLocalIRI(iri); err == nil {
+		f.LoadAccountsFilter.Key = app.Hashes{app.GetHashFromAP(iri)}
+	} else {
+		f.LoadAccountsFilter.IRI = iri.String()
+	}
+	return repo.LoadAccount(f)
+}
+
+// loadMoveTarget returns the actor an account is moving to, the remote actors are fetched from their instance
+// so that we can check their current aliases
+func (h *handler) loadMoveTarget(repo app.CanLoadAccounts, iri as.IRI) (ap.Person, error) {
+	if err := validateLocalIRI(iri); err == nil {
+		acc, err := loadMoveAccount(repo, iri)
+		if err != nil {
+			return ap.Person{}, err
+		}
+		return *loadAPPerson(acc), nil
+	}
+	if err := validateIRIBelongsToBlackListedInstance(iri); err != nil {
+		return ap.Person{}, errors.NewMethodNotAllowed(err, "target belongs to blocked instance")
+	}
+	body, err := loadURL(h.repo, iri.String())
+	if err != nil {
+		return ap.Person{}, errors.NewNotFound(err, "unable to load the target actor %s", iri)
+	}
+	p := ap.Person{}
+	if err := p.UnmarshalJSON(body); err != nil {
+		return ap.Person{}, errors.NewNotValid(err, "unable to load the target actor %s", iri)
+	}
+	return p, nil
+}
+
+// validateMoveSigner returns an error if the "signer" account which signed the request is not the "actor" of a Move
+func validateMoveSigner(signer *app.Account, actor as.IRI) error {
+	if NotAnonymous(signer) != nil {
+		return errors.Forbiddenf("the move of %s is not signed", actor)
+	}
+	signerIRI := loadAPPerson(*signer).GetLink()
+	if signer.IsFederated() {
+		// the remote actors are identified by their own IRI, not by the local one we build from their hash
+		signerIRI = as.IRI(signer.Metadata.ID)
+	}
+	if signerIRI != actor {
+		return errors.Forbiddenf("the account can only be moved by its owner")
+	}
+	return nil
+}
+
+// saveActorMove re-points the followers and the karma of the actor of a Move activity to its target.
+// The move is accepted only if it is signed by the actor, and if the target actor lists the old one
+// in its alsoKnownAs aliases.
+func (h *handler) saveActorMove(a ap.Activity, r *http.Request, w http.ResponseWriter) (int, string) {
+	fromIRI := a.Actor.GetLink()
+	toIRI := a.Target.GetLink()
+	if err := validateMoveSigner(authAccount(r), fromIRI); err != nil {
+		h.HandleError(w, r, err)
+		return http.StatusForbidden, ""
+	}
+	loader, ok := app.ContextAccountLoader(r.Context())
+	if !ok {
+		h.HandleError(w, r, errors.Errorf("could not load account repository from Context"))
+		return http.StatusInternalServerError, ""
+	}
+	mover, ok := app.ContextAccountMover(r.Context())
+	if !ok {
+		h.HandleError(w, r, errors.Errorf("could not load account repository from Context"))
+		return http.StatusInternalServerError, ""
+	}
+	from, err := loadMoveAccount(loader, fromIRI)
+	if err != nil {
+		h.HandleError(w, r, errors.NewNotFound(err, "not found"))
+		return http.StatusNotFound, ""
+	}
+	target, err := h.loadMoveTarget(loader, toIRI)
+	if err != nil {
+		h.HandleError(w, r, err)
+		return http.StatusNotFound, ""
+	}
+	to := app.Account{}
+	to.FromActivityPub(target)
+	if !to.IsAlias(fromIRI.String()) {
+		h.HandleError(w, r, errors.Forbiddenf("the target actor %s doesn't list %s as an alias", toIRI, fromIRI))
+		return http.StatusForbidden, ""
+	}
+	if existing, err := loadMoveAccount(loader, toIRI); err == nil {
+		to = existing
+	} else if saver, ok := app.ContextSaver(r.Context()); ok {
+		// we don't know about the remote target yet
+		to.Hash = ""
+		if to, err = saver.SaveAccount(to); err != nil {
+			h.logger.WithContext(log.Ctx{
+				"err":   err,
+				"trace": errors.Details(err),
+				"actor": toIRI,
+			}).Error(err.Error())
+			h.HandleError(w, r, errors.NewNotValid(err, "unable to save the target account"))
+			return http.StatusInternalServerError, ""
+		}
+	}
+	if to.Metadata == nil {
+		to.Metadata = &app.AccountMetadata{}
+	}
+	to.Metadata.ID = toIRI.String()
+	if err := mover.MoveAccount(from, to); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"err":   err,
+			"trace": errors.Details(err),
+			"from":  fromIRI,
+			"to":    toIRI,
+		}).Error(err.Error())
+		h.HandleError(w, r, errors.NewNotValid(err, "unable to move the account"))
+		return http.StatusInternalServerError, ""
+	}
+	return http.StatusOK, ""
+}
+
 func (h *handler) ClientRequest(w http.ResponseWriter, r *http.Request) {
 	a, _ := app.ContextActivity(r.Context())
 
@@ -1145,13 +1726,28 @@ func (h *handler) ClientRequest(w http.ResponseWriter, r *http.Request) {
 		h.HandleError(w, r, err)
 		return
 	}
-	if h.acc != nil {
+	if acc := authAccount(r); acc != nil {
 		// validate if http-signature matches the current Activity.Actor
-		account := *h.acc
+		account := *acc
 		if a.Actor.GetLink() != loadAPPerson(account).GetLink() {
 			h.HandleError(w, r, errors.Forbiddenf("The activity actor is not authorized to add"))
 			return
 		}
+		// accounts which didn't confirm their email can't submit content or vote
+		switch a.GetType() {
+		case as.CreateType, as.LikeType, as.DislikeType:
+			if err := account.ValidateInteraction(); err != nil {
+				h.HandleError(w, r, err)
+				return
+			}
+		case as.UpdateType, as.DeleteType:
+			if !isActorUpdate(a) && !isActorDelete(a) {
+				if err := validateItemChange(a, account, repo, hasScope(r, app.ScopeAdmin)); err != nil {
+					h.HandleError(w, r, err)
+					return
+				}
+			}
+		}
 	}
 
 	if repo, ok := app.ContextActivitySaver(r.Context()); ok == true {
@@ -1162,7 +1758,7 @@ func (h *handler) ClientRequest(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	status, location = h.saveActivityContent(a, r, w)
+	status, location = h.saveActivityContent(a, true, r, w)
 	if err != nil {
 		h.logger.WithContext(log.Ctx{
 			"actor":   a.Actor.GetLink(),
@@ -1215,6 +1811,10 @@ func (h *handler) ServerRequest(w http.ResponseWriter, r *http.Request) {
 				actorNeedsSaving = true
 				actor = eact.actor
 			}
+			if errors.IsForbidden(e.object) {
+				errFn(e.object, "")
+				return
+			}
 			//if eobj, ok := e.object.(objectMissingError); ok {
 			//	a.Object = eobj.object
 			//}
@@ -1253,7 +1853,12 @@ func (h *handler) ServerRequest(w http.ResponseWriter, r *http.Request) {
 			//	}
 			//}
 
-			if acc, err = repo.SaveAccount(*h.acc); err != nil {
+			signer := authAccount(r)
+			if signer == nil {
+				errFn(missingActor, "")
+				return
+			}
+			if acc, err = repo.SaveAccount(*signer); err != nil {
 				errFn(errors.NewNotFound(err, fmt.Sprintf("failed to save local account for remote actor")), "")
 				return
 			}
@@ -1261,7 +1866,7 @@ func (h *handler) ServerRequest(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	status, location = h.saveActivityContent(a, r, w)
+	status, location = h.saveActivityContent(a, false, r, w)
 
 	if err != nil {
 		h.logger.WithContext(log.Ctx{
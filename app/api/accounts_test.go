@@ -0,0 +1,70 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	as "github.com/go-ap/activitystreams"
+	"github.com/mariusor/littr.go/app"
+)
+
+func TestValidateMoveSigner(t *testing.T) {
+	app.Instance.HostName = "example.com"
+	app.Instance.APIURL = "https://example.com/api"
+	BaseURL = "https://example.com/api"
+	ActorsURL = BaseURL + "/self/following"
+
+	jane := app.Account{Hash: app.Hash("dc6f5f5bf55bc1073715c98c69fa7ca8"), Handle: "jane"}
+	janeIRI := as.IRI(ActorsURL + "/dc6f5f5bf55bc1073715c98c69fa7ca8")
+	eve := app.Account{Hash: app.Hash("162edb32c80d0e6dd3114fbb59d6273b"), Handle: "eve"}
+	remote := app.Account{
+		Hash:     app.Hash("64e55785250f59637e7f578167e2c112"),
+		Handle:   "jane",
+		Metadata: &app.AccountMetadata{ID: "https://remote.org/users/jane"},
+	}
+	anonymous := app.AnonymousAccount
+
+	tests := []struct {
+		name   string
+		signer *app.Account
+		actor  as.IRI
+		valid  bool
+	}{
+		{"unsigned", nil, janeIRI, false},
+		{"anonymous", &anonymous, janeIRI, false},
+		{"owner", &jane, janeIRI, true},
+		{"forged", &eve, janeIRI, false},
+		{"remote owner", &remote, as.IRI("https://remote.org/users/jane"), true},
+		{"remote forged", &remote, janeIRI, false},
+	}
+	for _, tt := range tests {
+		if err := validateMoveSigner(tt.signer, tt.actor); (err == nil) != tt.valid {
+			t.Errorf("%s: invalid move signer validation %v, expected valid %t", tt.name, err, tt.valid)
+		}
+	}
+}
+
+func TestPublishingFlags(t *testing.T) {
+	future := time.Now().UTC().Add(time.Hour)
+	past := time.Now().UTC().Add(-time.Hour)
+	tests := []struct {
+		name   string
+		item   app.Item
+		outbox bool
+		flags  app.FlagBits
+	}{
+		{"outbox published", app.Item{SubmittedAt: past}, true, app.FlagsNone},
+		{"outbox scheduled", app.Item{SubmittedAt: future}, true, app.FlagsScheduled},
+		{"outbox draft", app.Item{SubmittedAt: future, Flags: app.FlagsDraft}, true, app.FlagsDraft},
+		{"inbox future", app.Item{SubmittedAt: future}, false, app.FlagsNone},
+		{"inbox draft", app.Item{SubmittedAt: past, Flags: app.FlagsDraft | app.FlagsLocked}, false, app.FlagsLocked},
+		{"inbox scheduled", app.Item{SubmittedAt: future, Flags: app.FlagsScheduled}, false, app.FlagsNone},
+	}
+	for _, tt := range tests {
+		it := tt.item
+		publishingFlags(&it, tt.outbox)
+		if it.Flags != tt.flags {
+			t.Errorf("%s: invalid flags %b, expected %b", tt.name, it.Flags, tt.flags)
+		}
+	}
+}
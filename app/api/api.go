@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"crypto"
 	"crypto/x509"
 	"encoding/json"
@@ -10,11 +11,15 @@ import (
 	"github.com/openshift/osin"
 	"net/http"
 	"net/url"
+	"os"
 	"path"
+	"strconv"
 	"strings"
 
 	"github.com/mariusor/littr.go/app"
 	ap "github.com/mariusor/littr.go/app/activitypub"
+	"github.com/mariusor/littr.go/app/oauth"
+	"github.com/mariusor/littr.go/app/ratelimit"
 	"github.com/mariusor/littr.go/internal/errors"
 	"github.com/mariusor/littr.go/internal/log"
 	"github.com/spacemonkeygo/httpsig"
@@ -26,6 +31,8 @@ import (
 
 const (
 	MaxContentItems = 50
+	// MaxRepliesDepth is the number of levels of a thread that can be loaded in one replies collection page
+	MaxRepliesDepth = 10
 )
 
 type InternalError struct {
@@ -41,16 +48,37 @@ func (u UserError) Error() string {
 }
 
 type handler struct {
-	acc    *app.Account
 	repo   *repository
 	logger log.Logger
 	os     *osin.Server
+	rl     ratelimit.Limiter
+}
+
+const (
+	// authAccountCtxtKey is the key of the account which authorized the request
+	authAccountCtxtKey app.CtxtKey = "__auth_acct"
+	// authScopesCtxtKey is the key of the scopes granted to the access token of the request,
+	// it is missing when the request was not authorized with a token
+	authScopesCtxtKey app.CtxtKey = "__auth_scopes"
+)
+
+// authAccount returns the account which authorized the request, or nil if the authorization failed
+func authAccount(r *http.Request) *app.Account {
+	a, _ := r.Context().Value(authAccountCtxtKey).(*app.Account)
+	return a
+}
+
+// authScopes returns the scopes granted to the access token of the request
+func authScopes(r *http.Request) []app.Scope {
+	s, _ := r.Context().Value(authScopesCtxtKey).([]app.Scope)
+	return s
 }
 
 type Config struct {
 	Logger      log.Logger
 	BaseURL     string
 	OAuthServer *osin.Server
+	RateLimiter ratelimit.Limiter
 }
 
 func Init(c Config) handler {
@@ -63,6 +91,7 @@ func Init(c Config) handler {
 	}
 	h.repo = New(c)
 	h.os = c.OAuthServer
+	h.rl = c.RateLimiter
 	return h
 }
 
@@ -211,6 +240,12 @@ func (h handler) HandleError(w http.ResponseWriter, r *http.Request, errs ...err
 		res.Status = http.StatusInternalServerError
 	}
 
+	for _, err := range errs {
+		if retry, ok := errors.RetryAfter(err); ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retry.Seconds())))
+		}
+	}
+
 	j, _ := json.Marshal(res)
 	w.WriteHeader(res.Status)
 	w.Write(j)
@@ -225,9 +260,11 @@ type keyLoader struct {
 }
 
 type oauthLoader struct {
-	logFn func(string, ...interface{})
-	acc   app.Account
-	s     *osin.Server
+	logFn  func(string, ...interface{})
+	acc    app.Account
+	scopes []app.Scope
+	s      *osin.Server
+	l      app.CanLoadAccounts
 }
 
 func (k keyLoader) log(s string, p ...interface{}) {
@@ -248,13 +285,36 @@ func (k *oauthLoader) Verify(r *http.Request) (error, string) {
 	if err != nil {
 		return err, ""
 	}
+	// the personal access tokens are saved with no expiration when they don't expire
+	if dat.ExpiresIn > 0 && dat.IsExpired() {
+		return errors.Unauthorizedf("token expired"), ""
+	}
+	// the token only identifies the account, its role and flags are loaded for every request,
+	// so the changes made by the moderators apply to the tokens which were already issued
+	who := app.Account{}
 	if b, ok := dat.UserData.(json.RawMessage); ok {
-		if err := json.Unmarshal([]byte(b), &k.acc); err != nil {
+		if err := json.Unmarshal([]byte(b), &who); err != nil {
 			return err, ""
 		}
-	} else {
+	}
+	if len(who.Hash) == 0 || k.l == nil {
 		return errors.Unauthorizedf("unable to load from bearer"), ""
 	}
+	acc, err := k.l.LoadAccount(app.Filters{LoadAccountsFilter: app.LoadAccountsFilter{Key: app.Hashes{who.Hash}}})
+	if err != nil {
+		return errors.NewUnauthorized(err, "unable to load the account of the token"), ""
+	}
+	if acc.Deleted() || acc.Blocked() {
+		return errors.Unauthorizedf("the account of the token is not active"), ""
+	}
+	k.acc = acc
+	if dat.Client != nil {
+		k.scopes = tokenScopes(dat.Client.GetId(), dat.Scope, os.Getenv("OAUTH2_KEY"))
+	}
+	// the last use of the token is shown on the sessions page of the account
+	if st, ok := k.s.Storage.(*oauth.Storage); ok {
+		st.TouchAccess(dat.AccessToken, ratelimit.ClientIP(r, app.Instance.Config.TrustProxy), r.UserAgent())
+	}
 	return nil, ""
 }
 
@@ -344,8 +404,9 @@ func httpSignatureVerifier(getter *keyLoader) (*httpsig.Verifier, string) {
 	return v, challenge
 }
 
-func (h *handler) loadAccountFromAuthHeader(w http.ResponseWriter, r *http.Request) (app.Account, error) {
+func (h *handler) loadAccountFromAuthHeader(w http.ResponseWriter, r *http.Request) (app.Account, []app.Scope, error) {
 	var acct = app.AnonymousAccount
+	var scopes []app.Scope
 
 	if auth := r.Header.Get("Authorization"); auth != "" {
 		var err error
@@ -357,9 +418,13 @@ func (h *handler) loadAccountFromAuthHeader(w http.ResponseWriter, r *http.Reque
 			// TODO(marius): move this to a better place but outside the handler
 			s := h.os
 			v := oauthLoader{acc: acct, s: s}
+			v.l, _ = app.ContextAccountLoader(r.Context())
 			v.logFn = h.logger.WithContext(log.Ctx{"from": method}).Debugf
 			err, challenge = v.Verify(r)
 			acct = v.acc
+			if err == nil {
+				scopes = v.scopes
+			}
 		}
 		if strings.Contains(auth, "Signature") {
 			if loader, ok := app.ContextAccountLoader(r.Context()); ok {
@@ -388,7 +453,7 @@ func (h *handler) loadAccountFromAuthHeader(w http.ResponseWriter, r *http.Reque
 			// TODO(marius): here we need to implement some outside logic, as to we want to allow non-signed
 			//   requests on some urls, but not on others - probably another handler to check for Anonymous
 			//   would suffice.
-			return acct, err
+			return acct, nil, err
 		} else {
 			// TODO(marius): Add actor's host to the logging
 			h.logger.WithContext(log.Ctx{
@@ -398,8 +463,7 @@ func (h *handler) loadAccountFromAuthHeader(w http.ResponseWriter, r *http.Reque
 			}).Debug("loaded account from Authorization header")
 		}
 	}
-	h.acc = &acct
-	return acct, nil
+	return acct, scopes, nil
 }
 
 type acctVerifierFn func(a *app.Account) error
@@ -444,8 +508,9 @@ func (h handler) VerifyMultiple(fns ...verifFn) app.Handler {
 func (h *handler) VerifyAuthHeader(fns ...acctVerifierFn) app.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			acc := authAccount(r)
 			for _, f := range fns {
-				if err := f(h.acc); err != nil {
+				if err := f(acc); err != nil {
 					h.HandleError(w, r, err)
 					return
 				}
@@ -456,14 +521,99 @@ func (h *handler) VerifyAuthHeader(fns ...acctVerifierFn) app.Handler {
 	}
 }
 
+// ValidatePermissions lets through only the requests of the authenticated accounts which have all the "perms" permissions
+func (h *handler) ValidatePermissions(perms ...app.Permission) app.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if acc := authAccount(r); acc == nil || !acc.Can(perms...) {
+				h.HandleError(w, r, errors.Forbiddenf("you are not allowed to perform this action"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+		return http.HandlerFunc(fn)
+	}
+}
+
+// isModeration returns if "a" is an activity of the "acc" account which deletes the item of another account
+func isModeration(a ap.Activity, acc app.Account, repo app.CanLoadItems) bool {
+	if a.GetType() != as.DeleteType || isActorDelete(a) || repo == nil {
+		return false
+	}
+	it := app.Item{}
+	if err := it.FromActivityPub(a.Object); err != nil || len(it.Hash) == 0 {
+		return false
+	}
+	old, err := repo.LoadItem(app.Filters{
+		LoadItemsFilter: app.LoadItemsFilter{Key: app.Hashes{it.Hash}, Draft: []bool{true, false}},
+	})
+	if err != nil {
+		return false
+	}
+	return old.SubmittedBy == nil || old.SubmittedBy.Hash != acc.Hash
+}
+
+// ValidateModeration passes the outbox activities which moderate the content of other accounts through the "checks"
+// middlewares, the other activities go straight to the next handler. It needs to run after LoadActivity.
+func (h *handler) ValidateModeration(checks ...app.Handler) app.Handler {
+	return func(next http.Handler) http.Handler {
+		moderation := next
+		for i := len(checks) - 1; i >= 0; i-- {
+			moderation = checks[i](moderation)
+		}
+		fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			a, ok := r.Context().Value(app.ItemCtxtKey).(ap.Activity)
+			acc := authAccount(r)
+			repo, _ := app.ContextItemLoader(r.Context())
+			if ok && acc != nil && isModeration(a, *acc, repo) {
+				moderation.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+		return http.HandlerFunc(fn)
+	}
+}
+
+// RateLimit refuses the requests of the authenticated account which used up its budget for "action".
+// The requests are not counted by address, as the frontend makes them on behalf of all its users.
+func (h *handler) RateLimit(action string) app.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if acc := authAccount(r); acc != nil {
+				if err := h.rl.Allow(action, *acc, ""); err != nil {
+					if errors.IsTooManyRequests(err) {
+						h.logger.WithContext(log.Ctx{
+							"action": action,
+							"handle": acc.Handle,
+						}).Warn(err.Error())
+						h.HandleError(w, r, err)
+						return
+					}
+					h.logger.Error(err.Error())
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+		return http.HandlerFunc(fn)
+	}
+}
+
+// LoadAccountFromAuthHeader adds the account which authorized the request, and the scopes of its token, to the request context.
+// When the authorization fails the request continues without them.
 func (h *handler) LoadAccountFromAuthHeader(next http.Handler) http.Handler {
 	fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if acct, err := h.loadAccountFromAuthHeader(w, r); err == nil {
-			h.acc = &acct
-		} else {
+		acct, scopes, err := h.loadAccountFromAuthHeader(w, r)
+		if err != nil {
 			h.logger.Warnf("%s", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := context.WithValue(r.Context(), authAccountCtxtKey, &acct)
+		if scopes != nil {
+			ctx = context.WithValue(ctx, authScopesCtxtKey, scopes)
 		}
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 	return http.HandlerFunc(fn)
 }
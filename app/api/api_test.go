@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/openshift/osin"
+)
+
+type accessStorage struct {
+	osin.Storage
+	tokens map[string]*osin.AccessData
+}
+
+func (s accessStorage) LoadAccess(token string) (*osin.AccessData, error) {
+	if d, ok := s.tokens[token]; ok {
+		return d, nil
+	}
+	return nil, errors.NotFoundf("token not found")
+}
+
+type accountsLoader map[app.Hash]app.Account
+
+func (l accountsLoader) LoadAccount(f app.Filters) (app.Account, error) {
+	for _, h := range f.LoadAccountsFilter.Key {
+		if a, ok := l[h]; ok {
+			return a, nil
+		}
+	}
+	return app.Account{}, errors.NotFoundf("account not found")
+}
+
+func (l accountsLoader) LoadAccounts(f app.Filters) (app.AccountCollection, uint, error) {
+	a, err := l.LoadAccount(f)
+	if err != nil {
+		return nil, 0, err
+	}
+	return app.AccountCollection{a}, 1, nil
+}
+
+func TestOauthLoader_Verify(t *testing.T) {
+	jane := app.Account{Hash: app.Hash("dc6f5f5bf55bc1073715c98c69fa7ca8"), Handle: "jane", CreatedAt: time.Now()}
+	eve := app.Account{Hash: app.Hash("162edb32c80d0e6dd3114fbb59d6273b"), Handle: "eve", CreatedAt: time.Now()}
+	eve.Block()
+	loader := accountsLoader{jane.Hash: jane, eve.Hash: eve}
+
+	token := func(a app.Account, role app.Role) *osin.AccessData {
+		// the stale snapshot of the account which was saved when the token was issued
+		data, _ := json.Marshal(app.Account{Hash: a.Hash, Handle: a.Handle, Role: role})
+		return &osin.AccessData{
+			Client:    &osin.DefaultClient{Id: "personal-access-tokens"},
+			Scope:     "read",
+			CreatedAt: time.Now(),
+			UserData:  json.RawMessage(data),
+		}
+	}
+	s := osin.NewServer(osin.NewServerConfig(), accessStorage{tokens: map[string]*osin.AccessData{
+		"jane":    token(jane, app.RoleAdmin),
+		"eve":     token(eve, app.RoleUser),
+		"deleted": token(app.Account{Hash: app.Hash("64e55785250f59637e7f578167e2c112"), Handle: "john"}, app.RoleUser),
+	}})
+
+	tests := []struct {
+		token string
+		valid bool
+	}{
+		{"jane", true},
+		{"eve", false},
+		{"deleted", false},
+		{"unknown", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/self", nil)
+		r.Header.Set("Authorization", "Bearer "+tt.token)
+		v := oauthLoader{s: s, l: loader}
+		err, _ := v.Verify(r)
+		if (err == nil) != tt.valid {
+			t.Errorf("invalid verification of the %s token: %v, expected valid %t", tt.token, err, tt.valid)
+			continue
+		}
+		if !tt.valid {
+			continue
+		}
+		if v.acc.Hash != jane.Hash || v.acc.Role != jane.Role {
+			t.Errorf("the account %v should be loaded from the database, not from the token", v.acc)
+		}
+		if len(v.scopes) != 1 || v.scopes[0] != app.ScopeRead {
+			t.Errorf("invalid scopes %v, expected %v", v.scopes, []app.Scope{app.ScopeRead})
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	goap "github.com/go-ap/activitypub"
+	as "github.com/go-ap/activitystreams"
+	json "github.com/go-ap/jsonld"
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/app/db"
+	"github.com/mariusor/littr.go/internal/errors"
+)
+
+// exportCollection returns an ordered collection with the "id" of the "a" account
+func exportCollection(a app.Account, o as.Item) as.OrderedCollection {
+	oc := as.OrderedCollection{}
+	oc.ID = BuildCollectionID(a, o)
+	oc.Type = as.OrderedCollectionType
+	return oc
+}
+
+// writeExportFile adds the JSON-LD representation of "it" as the "name" file of the archive
+func writeExportFile(z *zip.Writer, name string, it interface{}) error {
+	data, err := json.WithContext(GetContext()).Marshal(it)
+	if err != nil {
+		return errors.Annotatef(err, "unable to marshal %s", name)
+	}
+	f, err := z.Create(name)
+	if err != nil {
+		return errors.Annotatef(err, "unable to create %s", name)
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+// ExportAccount writes a zip archive with the actor, outbox, liked, followers and following collections of
+// the "a" account as ActivityStreams JSON-LD documents.
+// We don't store who an account follows yet, so the following collection is always empty,
+// and the followers are the accounts we sent follow notifications for.
+func (r *repository) ExportAccount(a app.Account, w io.Writer) error {
+	if !a.IsLocal() || len(a.Hash) == 0 {
+		return errors.Forbiddenf("only local accounts can be exported")
+	}
+	items, _, err := db.Config.LoadItems(app.Filters{
+		LoadItemsFilter: app.LoadItemsFilter{
+			AttributedTo: app.Hashes{a.Hash},
+			Draft:        []bool{true, false},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	votes, _, err := db.Config.LoadVotes(app.Filters{
+		LoadVotesFilter: app.LoadVotesFilter{
+			AttributedTo: app.Hashes{a.Hash},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	notifications, _, err := db.Config.LoadNotifications(a, app.Filters{})
+	if err != nil {
+		return err
+	}
+	followers := make(app.AccountCollection, 0)
+	for _, n := range notifications {
+		if n.Type == app.NotificationFollow && n.Actor != nil {
+			followers = append(followers, *n.Actor)
+		}
+	}
+
+	outbox := exportCollection(a, new(goap.Outbox))
+	if _, err := loadAPItemCollection(&outbox, items); err != nil {
+		return err
+	}
+	outbox.TotalItems = uint(len(items))
+	liked := exportCollection(a, new(goap.Liked))
+	if _, err := loadAPVoteCollection(&liked, votes); err != nil {
+		return err
+	}
+	liked.TotalItems = uint(len(votes))
+	followersCol := exportCollection(a, new(goap.Followers))
+	if _, err := loadAPAccountCollection(&followersCol, followers); err != nil {
+		return err
+	}
+	followersCol.TotalItems = uint(len(followers))
+	following := exportCollection(a, new(goap.Following))
+
+	z := zip.NewWriter(w)
+	files := []struct {
+		name string
+		it   interface{}
+	}{
+		{"actor.json", loadAPPerson(a)},
+		{"outbox.json", outbox},
+		{"liked.json", liked},
+		{"followers.json", followersCol},
+		{"following.json", following},
+	}
+	for _, f := range files {
+		if err := writeExportFile(z, f.name, f.it); err != nil {
+			z.Close()
+			return err
+		}
+	}
+	return z.Close()
+}
+
+// DeleteAccount publishes a Delete activity of the current local account's actor,
+// the other accounts are deleted directly in the database.
+func (r *repository) DeleteAccount(a app.Account) error {
+	if !a.IsLocal() || len(a.Hash) == 0 || r.Account == nil || r.Account.Hash != a.Hash {
+		return db.Config.DeleteAccount(a)
+	}
+	p := loadAPPerson(a)
+	del := as.DeleteNew(as.ObjectID(""), p)
+	del.Actor = p.GetLink()
+
+	var err error
+	var body []byte
+	if body, err = json.Marshal(del); err != nil {
+		r.logger.Error(err.Error())
+		return err
+	}
+
+	var resp *http.Response
+	outbox := fmt.Sprintf("%s/self/following/%s/outbox", r.BaseURL, a.Hash)
+	if resp, err = r.client.Post(outbox, "application/activity+json", bytes.NewReader(body)); err != nil {
+		r.logger.Error(err.Error())
+		return err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusForbidden:
+		return errors.Forbiddenf("%s", resp.Status)
+	case http.StatusNotFound:
+		return errors.NotFoundf("account not found")
+	case http.StatusInternalServerError:
+		return errors.Errorf("unable to delete account %s", resp.Status)
+	default:
+		return errors.Errorf("unknown error, received status %d", resp.StatusCode)
+	}
+}
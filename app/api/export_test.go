@@ -0,0 +1,65 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	goap "github.com/go-ap/activitypub"
+	"github.com/mariusor/littr.go/app"
+)
+
+func TestExportCollection(t *testing.T) {
+	BaseURL = "https://example.com/api"
+	ActorsURL = BaseURL + "/self/following"
+	a := app.Account{Hash: app.Hash("dc6f5f5bf55bc1073715c98c69fa7ca8"), Handle: "jane"}
+
+	buf := bytes.Buffer{}
+	z := zip.NewWriter(&buf)
+	if err := writeExportFile(z, "outbox.json", exportCollection(a, new(goap.Outbox))); err != nil {
+		t.Fatalf("unable to write the export file: %s", err)
+	}
+	if err := z.Close(); err != nil {
+		t.Fatalf("unable to close the archive: %s", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("unable to read the archive: %s", err)
+	}
+	if len(zr.File) != 1 || zr.File[0].Name != "outbox.json" {
+		t.Fatalf("invalid archive files %v", zr.File)
+	}
+	f, err := zr.File[0].Open()
+	if err != nil {
+		t.Fatalf("unable to open outbox.json: %s", err)
+	}
+	defer f.Close()
+	data, _ := ioutil.ReadAll(f)
+	doc := struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	}{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid JSON document %s: %s", data, err)
+	}
+	if exp := ActorsURL + "/dc6f5f5bf55bc1073715c98c69fa7ca8/outbox"; doc.ID != exp {
+		t.Errorf("invalid collection id %s, expected %s", doc.ID, exp)
+	}
+	if doc.Type != "OrderedCollection" {
+		t.Errorf("invalid collection type %s", doc.Type)
+	}
+}
+
+func TestExportAccount_NotLocal(t *testing.T) {
+	app.Instance.HostName = "example.com"
+	app.Instance.APIURL = "https://example.com/api"
+
+	r := repository{}
+	remote := app.Account{Hash: app.Hash("dc6f5f5bf55bc1073715c98c69fa7ca8"), Handle: "jane", Metadata: &app.AccountMetadata{ID: "https://remote.org/users/jane"}}
+	if err := r.ExportAccount(remote, ioutil.Discard); err == nil {
+		t.Errorf("a remote account should not be exported")
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"path"
 	"strings"
 
+	"github.com/buger/jsonparser"
 	"github.com/spacemonkeygo/httpsig"
 
 	cl "github.com/go-ap/activitypub/client"
@@ -192,6 +193,10 @@ func (h handler) ItemCtxt(next http.Handler) http.Handler {
 			fallthrough
 		case "replies":
 			fallthrough
+		case "drafts":
+			fallthrough
+		case "saved":
+			fallthrough
 		case "outbox":
 			loader, ok := val.(app.CanLoadItems)
 			if !ok {
@@ -248,7 +253,15 @@ func loadRepliesFilterFromReq(r *http.Request) *app.LoadItemsFilter {
 	}
 	hash := chi.URLParam(r, "hash")
 
-	filters.InReplyTo = []string{hash}
+	if filters.Depth > 0 {
+		// when a depth is requested, the collection contains the sub-thread of the item, breadth first
+		if filters.Depth > MaxRepliesDepth {
+			filters.Depth = MaxRepliesDepth
+		}
+		filters.Context = []string{hash}
+	} else {
+		filters.InReplyTo = []string{hash}
+	}
 
 	return &filters
 }
@@ -355,9 +368,48 @@ func loadLikedFilterFromReq(r *http.Request) *app.LoadVotesFilter {
 	return &filters
 }
 
+func loadSavedFilterFromReq(r *http.Request) *app.LoadItemsFilter {
+	filters := app.LoadItemsFilter{}
+	if err := qstring.Unmarshal(r.URL.Query(), &filters); err != nil {
+		return &filters
+	}
+	// the saved items can only be loaded for the account the collection belongs to
+	filters.SavedBy = []string{chi.URLParam(r, "handle")}
+	hash := chi.URLParam(r, "hash")
+	if hash != "" {
+		old := filters.Key
+		filters.Key = nil
+		filters.Key = append(filters.Key, app.Hash(hash))
+		filters.Key = append(filters.Key, old...)
+		filters.Key = hashesUnique(filters.Key)
+	}
+	return &filters
+}
+
+func loadDraftsFilterFromReq(r *http.Request) *app.LoadItemsFilter {
+	filters := app.LoadItemsFilter{}
+	if err := qstring.Unmarshal(r.URL.Query(), &filters); err != nil {
+		return &filters
+	}
+	// the drafts can only be loaded for the account the collection belongs to
+	filters.AttributedTo = app.Hashes{app.Hash(chi.URLParam(r, "handle"))}
+	filters.Draft = []bool{true}
+	hash := chi.URLParam(r, "hash")
+	if hash != "" {
+		old := filters.Key
+		filters.Key = nil
+		filters.Key = append(filters.Key, app.Hash(hash))
+		filters.Key = append(filters.Key, old...)
+		filters.Key = hashesUnique(filters.Key)
+	}
+	return &filters
+}
+
 var validCollectionNames = []string{
 	"actors",
 	"liked",
+	"saved",
+	"drafts",
 	"outbox",
 	"inbox",
 	"replies",
@@ -374,6 +426,48 @@ func isValidCollectionName(s string) bool {
 	return false
 }
 
+// privateCollections are the collections that can be accessed only by the account they belong to
+var privateCollections = []string{
+	"saved",
+	"drafts",
+}
+
+func isPrivateCollectionName(s string) bool {
+	for _, private := range privateCollections {
+		if private == strings.ToLower(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidatePrivateCollection allows access to the private collections only to the account they belong to,
+// and only with the read scope when the request was authorized with an access token
+func (h *handler) ValidatePrivateCollection(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		col := getCollectionFromReq(r)
+		if !isPrivateCollectionName(col) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		a, ok := app.ContextAccount(r.Context())
+		if !ok {
+			h.HandleError(w, r, errors.NotFoundf("collection %s", col))
+			return
+		}
+		if acc := authAccount(r); acc == nil || acc.Hash != a.Hash {
+			h.HandleError(w, r, errors.Forbiddenf("collection %s is available only to its owner", col))
+			return
+		}
+		if !hasScope(r, app.ScopeRead) {
+			h.insufficientScope(w, r, app.ScopeRead)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
 func LoadFiltersCtxt(eh app.ErrorHandler) app.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
@@ -392,15 +486,27 @@ func LoadFiltersCtxt(eh app.ErrorHandler) app.Handler {
 				f.LoadItemsFilter = *loadInboxFilterFromReq(r)
 			case "replies":
 				f.LoadItemsFilter = *loadRepliesFilterFromReq(r)
+			case "saved":
+				f.LoadItemsFilter = *loadSavedFilterFromReq(r)
+			case "drafts":
+				f.LoadItemsFilter = *loadDraftsFilterFromReq(r)
 			case "":
 				// skip
 			default:
 				eh(w, r, errors.NotValidf("collection %s", col))
 				return
 			}
+			if !isPrivateCollectionName(col) {
+				// the saved items and the drafts of an account are not to be exposed through other collections
+				f.SavedBy = nil
+				f.Draft = nil
+			}
 			if f.MaxItems == 0 {
 				f.MaxItems = MaxContentItems
 			}
+			if col == "replies" && f.MaxItems > MaxContentItems {
+				f.MaxItems = MaxContentItems
+			}
 
 			ctx := context.WithValue(r.Context(), app.FilterCtxtKey, &f)
 			next.ServeHTTP(w, r.WithContext(ctx))
@@ -443,6 +549,10 @@ func (h handler) ItemCollectionCtxt(next http.Handler) http.Handler {
 			fallthrough
 		case "outbox":
 			fallthrough
+		case "drafts":
+			fallthrough
+		case "saved":
+			fallthrough
 		case "replies":
 			loader, ok := val.(app.CanLoadItems)
 			if !ok {
@@ -486,11 +596,18 @@ func (r *repository) LoadItem(f app.Filters) (app.Item, error) {
 	hashes := f.LoadItemsFilter.Key
 	f.LoadItemsFilter.Key = nil
 
+	target := "self/outbox"
+	if len(f.Draft) > 0 && len(f.AttributedTo) > 0 {
+		// the drafts are loaded from the private collection of their author
+		target = fmt.Sprintf("self/following/%s/drafts", f.AttributedTo[0])
+		f.AttributedTo = nil
+		f.Draft = nil
+	}
 	var qs string
 	if q, err := qstring.MarshalString(&f); err == nil {
 		qs = fmt.Sprintf("?%s", q)
 	}
-	url := fmt.Sprintf("%s/self/outbox/%s/object%s", r.BaseURL, hashes[0], qs)
+	url := fmt.Sprintf("%s/%s/%s/object%s", r.BaseURL, target, hashes[0], qs)
 
 	var err error
 	var resp *http.Response
@@ -515,11 +632,21 @@ func (r *repository) LoadItem(f app.Filters) (app.Item, error) {
 		r.logger.Error(err.Error())
 		return it, err
 	}
-	if err := j.Unmarshal(body, &art); err != nil {
-		r.logger.Error(err.Error())
-		return it, err
+	if typ, _ := jsonparser.GetString(body, "type"); as.ActivityVocabularyType(typ) == as.QuestionType {
+		// polls need their options loaded too
+		q := ap.Question{}
+		if err := j.Unmarshal(body, &q); err != nil {
+			r.logger.Error(err.Error())
+			return it, err
+		}
+		err = it.FromActivityPub(q)
+	} else {
+		if err := j.Unmarshal(body, &art); err != nil {
+			r.logger.Error(err.Error())
+			return it, err
+		}
+		err = it.FromActivityPub(art)
 	}
-	err = it.FromActivityPub(art)
 	if err == nil {
 		var items app.ItemCollection
 		items, err = r.loadItemsAuthors(it)
@@ -587,6 +714,20 @@ func (r *repository) LoadItems(f app.Filters) (app.ItemCollection, uint, error)
 			}
 		}
 	}
+	if len(f.SavedBy) > 0 {
+		for _, acc := range f.SavedBy {
+			target = fmt.Sprintf("self/following/%s", acc)
+			c = "saved"
+			break
+		}
+	}
+	if len(f.Draft) > 0 {
+		for _, acc := range f.AttributedTo {
+			target = fmt.Sprintf("self/following/%s", acc)
+			c = "drafts"
+			break
+		}
+	}
 	url := fmt.Sprintf("%s/%s/%s%s", r.BaseURL, target, c, qs)
 
 	var err error
@@ -693,6 +834,95 @@ func (r *repository) SaveVote(v app.Vote) (app.Vote, error) {
 	return v, errors.Errorf("unknown error, received status %d", resp.StatusCode)
 }
 
+func (r *repository) saveBookmarkActivity(typ as.ActivityVocabularyType, a app.Account, it app.Item) error {
+	p := loadAPPerson(a)
+	o := loadAPItem(it)
+
+	var act ap.Activity
+	act.Type = typ
+	act.Actor = p.GetLink()
+	act.Object = o.GetLink()
+	act.Target = as.IRI(fmt.Sprintf("%s/saved", p.GetLink()))
+
+	var err error
+	var body []byte
+	if body, err = j.Marshal(act); err != nil {
+		r.logger.Error(err.Error())
+		return err
+	}
+
+	var resp *http.Response
+	outbox := fmt.Sprintf("%s/self/following/%s/outbox", r.BaseURL, a.Hash)
+	if resp, err = r.client.Post(outbox, "application/json+activity", bytes.NewReader(body)); err != nil {
+		r.logger.Error(err.Error())
+		return err
+	}
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated {
+		return nil
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return errors.Errorf("item not found")
+	}
+	if resp.StatusCode == http.StatusInternalServerError {
+		return errors.Errorf("unable to save bookmark")
+	}
+	return errors.Errorf("unknown error, received status %d", resp.StatusCode)
+}
+
+func (r *repository) SaveBookmark(a app.Account, it app.Item) error {
+	return r.saveBookmarkActivity(as.AddType, a, it)
+}
+
+func (r *repository) RemoveBookmark(a app.Account, it app.Item) error {
+	return r.saveBookmarkActivity(as.RemoveType, a, it)
+}
+
+func (r *repository) SavePollVote(v app.PollVote) error {
+	if v.Item == nil || v.SubmittedBy == nil {
+		return errors.Errorf("invalid poll vote, missing item or account")
+	}
+	p := loadAPPerson(*v.SubmittedBy)
+	q := loadAPItem(*v.Item)
+	outbox := fmt.Sprintf("%s/self/following/%s/outbox", r.BaseURL, v.SubmittedBy.Hash)
+
+	// each choice is sent as a separate Note in reply to the Question, having the name of the option
+	for _, choice := range v.Choices {
+		answer := ap.Article{}
+		answer.Type = as.NoteType
+		answer.Name = as.NaturalLanguageValues{{Ref: as.NilLangRef, Value: choice}}
+		answer.AttributedTo = p.GetLink()
+		answer.InReplyTo = q.GetLink()
+
+		var act ap.Activity
+		act.Type = as.CreateType
+		act.Actor = p.GetLink()
+		act.Object = answer
+
+		var err error
+		var body []byte
+		if body, err = j.Marshal(act); err != nil {
+			r.logger.Error(err.Error())
+			return err
+		}
+		var resp *http.Response
+		if resp, err = r.client.Post(outbox, "application/json+activity", bytes.NewReader(body)); err != nil {
+			r.logger.Error(err.Error())
+			return err
+		}
+		if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated {
+			continue
+		}
+		if resp.StatusCode == http.StatusForbidden {
+			return errors.Forbiddenf("unable to vote in poll")
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return errors.Errorf("poll not found")
+		}
+		return errors.Errorf("unknown error, received status %d", resp.StatusCode)
+	}
+	return nil
+}
+
 func (r *repository) LoadVotes(f app.Filters) (app.VoteCollection, uint, error) {
 	var qs string
 	if q, err := qstring.MarshalString(&f); err == nil {
@@ -790,6 +1020,9 @@ func (r *repository) SaveItem(it app.Item) (app.Item, error) {
 	if r.Account != nil && r.Account.Hash == it.SubmittedBy.Hash {
 		// need to test if it.SubmittedBy matches r.Account and that the signature is valid
 		actor = loadAPPerson(*it.SubmittedBy)
+	} else if r.Account != nil && it.Deleted() && r.Account.Can(app.PermDeleteItem) {
+		// the moderators delete the items of other accounts in their own name
+		actor = loadAPPerson(*r.Account)
 	}
 
 	var body []byte
@@ -844,6 +1077,10 @@ func (r *repository) SaveItem(it app.Item) (app.Item, error) {
 		f := app.Filters{LoadItemsFilter: app.LoadItemsFilter{
 			Key: app.Hashes{app.Hash(hash)},
 		}}
+		if !it.IsPublished() {
+			f.Draft = []bool{true}
+			f.AttributedTo = app.Hashes{it.SubmittedBy.Hash}
+		}
 		return r.LoadItem(f)
 	case http.StatusGone:
 		newLoc := resp.Header.Get("Location")
@@ -936,8 +1173,85 @@ func (r *repository) LoadAccount(f app.Filters) (app.Account, error) {
 	return *ac, nil
 }
 
+// SaveAccount publishes the profile of the current local account as an Update activity,
+// the other accounts are saved directly in the database.
 func (r *repository) SaveAccount(a app.Account) (app.Account, error) {
-	return db.Config.SaveAccount(a)
+	if !a.IsLocal() || len(a.Hash) == 0 || r.Account == nil || r.Account.Hash != a.Hash {
+		return db.Config.SaveAccount(a)
+	}
+	p := loadAPPerson(a)
+	update := as.UpdateNew(as.ObjectID(""), p)
+	update.Actor = p.GetLink()
+
+	var err error
+	var body []byte
+	if body, err = j.Marshal(update); err != nil {
+		r.logger.Error(err.Error())
+		return a, err
+	}
+
+	var resp *http.Response
+	outbox := fmt.Sprintf("%s/self/following/%s/outbox", r.BaseURL, a.Hash)
+	if resp, err = r.client.Post(outbox, "application/activity+json", bytes.NewReader(body)); err != nil {
+		r.logger.Error(err.Error())
+		return a, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return r.LoadAccount(app.Filters{LoadAccountsFilter: app.LoadAccountsFilter{Key: app.Hashes{a.Hash}}})
+	case http.StatusForbidden:
+		return a, errors.Forbiddenf("%s", resp.Status)
+	case http.StatusNotFound:
+		return a, errors.NotFoundf("account not found")
+	case http.StatusInternalServerError:
+		return a, errors.Errorf("unable to save account %s", resp.Status)
+	default:
+		return a, errors.Errorf("unknown error, received status %d", resp.StatusCode)
+	}
+}
+
+// MoveAccount publishes a Move activity of the current local account's actor to the "to" actor,
+// the other accounts are moved directly in the database.
+func (r *repository) MoveAccount(from, to app.Account) error {
+	if to.Metadata == nil || len(to.Metadata.ID) == 0 {
+		return errors.NotValidf("missing target actor")
+	}
+	if !from.IsLocal() || len(from.Hash) == 0 || r.Account == nil || r.Account.Hash != from.Hash {
+		return db.Config.MoveAccount(from, to)
+	}
+	p := loadAPPerson(from)
+
+	var act ap.Activity
+	act.Type = as.MoveType
+	act.Actor = p.GetLink()
+	act.Object = p.GetLink()
+	act.Target = as.IRI(to.Metadata.ID)
+
+	var err error
+	var body []byte
+	if body, err = j.Marshal(act); err != nil {
+		r.logger.Error(err.Error())
+		return err
+	}
+
+	var resp *http.Response
+	outbox := fmt.Sprintf("%s/self/following/%s/outbox", r.BaseURL, from.Hash)
+	if resp, err = r.client.Post(outbox, "application/activity+json", bytes.NewReader(body)); err != nil {
+		r.logger.Error(err.Error())
+		return err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusForbidden:
+		return errors.Forbiddenf("%s", resp.Status)
+	case http.StatusNotFound:
+		return errors.NotFoundf("account not found")
+	case http.StatusInternalServerError:
+		return errors.Errorf("unable to move account %s", resp.Status)
+	default:
+		return errors.Errorf("unknown error, received status %d", resp.StatusCode)
+	}
 }
 
 type SignFunc func(r *http.Request) error
@@ -983,7 +1297,7 @@ func (r *repository) LoadInfo() (app.Info, error) {
 		r.logger.Error(err.Error())
 		return inf, err
 	}
-	s := as.Service{}
+	s := ap.Service{}
 	if err = j.Unmarshal(body, &s); err != nil {
 		r.logger.Error(err.Error())
 		return inf, err
@@ -1003,7 +1317,7 @@ func (r *repository) LoadInfo() (app.Info, error) {
 	inf.Title = ni.Software.Name
 	inf.Summary = s.Summary.First()
 	inf.Description = ni.Metadata.NodeDescription
-	inf.Languages = []string{"en"}
+	inf.Languages = s.Languages
 	inf.Version = ni.Software.Version
 	inf.Email = fmt.Sprintf("%s@%s", "system", app.Instance.HostName)
 
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+)
+
+func TestLoadRepliesFilterFromReq(t *testing.T) {
+	tests := []struct {
+		query  string
+		depth  int
+		thread bool
+	}{
+		{"", 0, false},
+		{"?depth=2", 2, true},
+		{fmt.Sprintf("?depth=%d", MaxRepliesDepth+5), MaxRepliesDepth, true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/self/outbox/abc/replies"+tt.query, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("hash", "abc")
+		f := loadRepliesFilterFromReq(r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx)))
+		if f.Depth != tt.depth {
+			t.Errorf("invalid depth %d for %q, expected %d", f.Depth, tt.query, tt.depth)
+		}
+		if tt.thread && (len(f.Context) != 1 || f.Context[0] != "abc" || len(f.InReplyTo) > 0) {
+			t.Errorf("expected the thread of the item for %q, got %v %v", tt.query, f.Context, f.InReplyTo)
+		}
+		if !tt.thread && (len(f.InReplyTo) != 1 || f.InReplyTo[0] != "abc" || len(f.Context) > 0) {
+			t.Errorf("expected the direct replies of the item for %q, got %v %v", tt.query, f.Context, f.InReplyTo)
+		}
+	}
+}
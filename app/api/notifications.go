@@ -0,0 +1,85 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	as "github.com/go-ap/activitystreams"
+	json "github.com/go-ap/jsonld"
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/internal/errors"
+)
+
+// loadAPNotification converts a notification to the activity that generated it
+func loadAPNotification(n app.Notification) as.Item {
+	var actor as.Item
+	if n.Actor != nil {
+		if n.Actor.IsLocal() && len(n.Actor.Hash) > 0 {
+			actor = as.IRI(BuildActorID(*n.Actor))
+		} else if n.Actor.HasMetadata() {
+			actor = as.IRI(n.Actor.Metadata.ID)
+		}
+	}
+	switch n.Type {
+	case app.NotificationFollow:
+		act := as.FollowNew("", as.IRI(BuildActorID(*n.For)))
+		act.Actor = actor
+		act.Published = n.CreatedAt
+		return *act
+	default:
+		if n.Item == nil {
+			return nil
+		}
+		act := loadAPActivity(*n.Item)
+		act.Actor = actor
+		return act
+	}
+}
+
+// HandleNotifications serves GET /api/self/following/{handle}/notifications request
+// It lists the notifications of the {handle} account, only to the account itself.
+func (h *handler) HandleNotifications(w http.ResponseWriter, r *http.Request) {
+	a, ok := app.ContextAccount(r.Context())
+	if !ok {
+		h.HandleError(w, r, errors.NotFoundf("account"))
+		return
+	}
+	if acc := authAccount(r); acc == nil || acc.Hash != a.Hash {
+		h.HandleError(w, r, errors.Forbiddenf("notifications are available only to their owner"))
+		return
+	}
+	repo, ok := app.ContextNotificationLoader(r.Context())
+	if !ok {
+		h.HandleError(w, r, errors.Errorf("could not load notifications repository from Context"))
+		return
+	}
+	f, ok := r.Context().Value(app.FilterCtxtKey).(*app.Filters)
+	if !ok {
+		f = &app.Filters{MaxItems: MaxContentItems}
+	}
+	notifications, count, err := repo.LoadNotifications(a, *f)
+	if err != nil {
+		h.HandleError(w, r, errors.NewNotValid(err, "unable to load notifications"))
+		return
+	}
+
+	oc := as.OrderedCollection{}
+	oc.ID = as.ObjectID(fmt.Sprintf("%s%s", h.repo.BaseURL, strings.Replace(r.URL.Path, "/api", "", 1)))
+	oc.Type = as.OrderedCollectionType
+	for _, n := range notifications {
+		if it := loadAPNotification(n); it != nil {
+			oc.Append(it)
+		}
+	}
+	oc.TotalItems = count
+
+	data, err := json.WithContext(GetContext()).Marshal(oc)
+	if err != nil {
+		h.HandleError(w, r, errors.NewNotValid(err, "unable to marshal collection"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/activity+json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
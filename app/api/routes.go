@@ -10,11 +10,13 @@ import (
 
 func (h handler) Routes() func(chi.Router) {
 	apGroup := func(r chi.Router) {
-		r.With(h.VerifyAuthHeader(LocalAccount), h.LoadActivity).Post("/outbox", h.ClientRequest)
+		r.With(h.VerifyAuthHeader(LocalAccount), h.RateLimit(app.ActionOutbox), h.LoadActivity, h.RequireActivityScope,
+			h.ValidateModeration(h.ValidatePermissions(app.PermDeleteItem), h.RequireScope(app.ScopeAdmin))).Post("/outbox", h.ClientRequest)
 		r.With(h.VerifyAuthHeader(NotAnonymous), h.LoadActivity).Post("/inbox", h.ServerRequest)
 	}
 	collectionRouter := func(r chi.Router) {
 		r.Use(LoadFiltersCtxt(h.HandleError))
+		r.Use(h.ValidatePrivateCollection)
 		r.With(h.ItemCollectionCtxt).Get("/", h.HandleCollection)
 		r.Route("/{hash}", func(r chi.Router) {
 			r.With(LoadFiltersCtxt(h.HandleError), h.ItemCtxt).Get("/", h.HandleCollectionActivity)
@@ -27,6 +29,7 @@ func (h handler) Routes() func(chi.Router) {
 		r.Route("/{handle}", func(r chi.Router) {
 			r.Use(h.AccountCtxt)
 			r.Get("/", h.HandleActor)
+			r.With(h.VerifyAuthHeader(LocalAccount), h.RequireScope(app.ScopeRead), LoadFiltersCtxt(h.HandleError)).Get("/notifications", h.HandleNotifications)
 			r.Route("/{collection}", collectionRouter)
 			r.With(LoadFiltersCtxt(h.HandleError)).Group(apGroup)
 		})
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/mariusor/littr.go/app"
+	ap "github.com/mariusor/littr.go/app/activitypub"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+
+	as "github.com/go-ap/activitystreams"
+)
+
+// tokenScopes returns the scopes granted to an access token of the "client".
+// The frontend gets all the scopes, as it acts for the accounts logged into it, and the third party applications
+// which didn't request any scope can only read.
+func tokenScopes(client string, scope string, frontendClient string) []app.Scope {
+	if len(client) > 0 && client == frontendClient {
+		return app.Scopes
+	}
+	scopes := app.ParseScopes(scope)
+	if len(scopes) == 0 {
+		scopes = []app.Scope{app.ScopeRead}
+	}
+	return scopes
+}
+
+// hasScope returns if the current request is allowed "s".
+// The requests which weren't authorized with an access token, like the HTTP signed ones, are not restricted.
+func hasScope(r *http.Request, s app.Scope) bool {
+	scopes := authScopes(r)
+	if scopes == nil {
+		return true
+	}
+	for _, sc := range scopes {
+		if sc == s {
+			return true
+		}
+	}
+	return false
+}
+
+// insufficientScope outputs the 403 error for a request which misses the "s" scope
+func (h handler) insufficientScope(w http.ResponseWriter, r *http.Request, s app.Scope) {
+	h.logger.WithContext(log.Ctx{
+		"scope":   s,
+		"granted": app.JoinScopes(authScopes(r)),
+		"req":     fmt.Sprintf("%s:%s", r.Method, r.URL.RequestURI()),
+	}).Warn("insufficient scope")
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, s))
+	h.HandleError(w, r, errors.Forbiddenf("insufficient scope, the %s scope is required", s))
+}
+
+// RequireScope lets through only the requests which were granted all the "scopes"
+func (h *handler) RequireScope(scopes ...app.Scope) app.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, s := range scopes {
+				if !hasScope(r, s) {
+					h.insufficientScope(w, r, s)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+		return http.HandlerFunc(fn)
+	}
+}
+
+// activityScope returns the scope needed for posting the "a" activity to an outbox.
+// Deleting and moving an account are administrative actions, as they can't be undone.
+func activityScope(a ap.Activity) app.Scope {
+	switch a.GetType() {
+	case as.LikeType, as.DislikeType:
+		return app.ScopeWriteVotes
+	case as.FollowType, as.BlockType, as.AcceptType, as.RejectType:
+		return app.ScopeFollow
+	case as.UndoType:
+		if a.Object != nil {
+			switch a.Object.GetType() {
+			case as.FollowType, as.BlockType:
+				return app.ScopeFollow
+			}
+		}
+		return app.ScopeWriteVotes
+	case as.MoveType:
+		return app.ScopeAdmin
+	case as.DeleteType:
+		if isActorDelete(a) {
+			return app.ScopeAdmin
+		}
+	}
+	return app.ScopeWriteItems
+}
+
+// RequireActivityScope lets through only the outbox requests which were granted the scope of their activity.
+// It needs to run after LoadActivity.
+func (h *handler) RequireActivityScope(next http.Handler) http.Handler {
+	fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a, ok := r.Context().Value(app.ItemCtxtKey).(ap.Activity)
+		if !ok {
+			h.HandleError(w, r, errors.NotValidf("missing activity"))
+			return
+		}
+		if s := activityScope(a); !hasScope(r, s) {
+			h.insufficientScope(w, r, s)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+	return http.HandlerFunc(fn)
+}
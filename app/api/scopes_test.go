@@ -0,0 +1,187 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	as "github.com/go-ap/activitystreams"
+	"github.com/go-chi/chi"
+	"github.com/mariusor/littr.go/app"
+	ap "github.com/mariusor/littr.go/app/activitypub"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+)
+
+func TestTokenScopes(t *testing.T) {
+	tests := []struct {
+		client string
+		scope  string
+		scopes []app.Scope
+	}{
+		{"frontend", "", app.Scopes},
+		{"frontend", "read", app.Scopes},
+		{"client", "", []app.Scope{app.ScopeRead}},
+		{"client", "unknown", []app.Scope{app.ScopeRead}},
+		{"client", "read write:votes", []app.Scope{app.ScopeRead, app.ScopeWriteVotes}},
+		{"", "admin", []app.Scope{app.ScopeAdmin}},
+	}
+	for _, tt := range tests {
+		if s := tokenScopes(tt.client, tt.scope, "frontend"); !reflect.DeepEqual(s, tt.scopes) {
+			t.Errorf("invalid scopes for client %q with %q: %v, expected %v", tt.client, tt.scope, s, tt.scopes)
+		}
+	}
+}
+
+func TestActivityScope(t *testing.T) {
+	activity := func(typ as.ActivityVocabularyType, ob as.Item) ap.Activity {
+		a := ap.Activity{}
+		a.Type = typ
+		a.Object = ob
+		return a
+	}
+	tests := []struct {
+		a     ap.Activity
+		scope app.Scope
+	}{
+		{activity(as.CreateType, &as.Object{Type: as.NoteType}), app.ScopeWriteItems},
+		{activity(as.LikeType, as.IRI("https://example.com/api/objects/1")), app.ScopeWriteVotes},
+		{activity(as.FollowType, as.IRI("https://example.com/api/actors/1")), app.ScopeFollow},
+		{activity(as.UndoType, &as.Object{Type: as.FollowType}), app.ScopeFollow},
+		{activity(as.UndoType, &as.Object{Type: as.LikeType}), app.ScopeWriteVotes},
+		{activity(as.DeleteType, &as.Object{Type: as.NoteType}), app.ScopeWriteItems},
+		{activity(as.DeleteType, &as.Object{Type: as.PersonType}), app.ScopeAdmin},
+		{activity(as.MoveType, as.IRI("https://example.com/api/actors/1")), app.ScopeAdmin},
+	}
+	for _, tt := range tests {
+		if s := activityScope(tt.a); s != tt.scope {
+			t.Errorf("invalid scope for %s activity: %s, expected %s", tt.a.GetType(), s, tt.scope)
+		}
+	}
+}
+
+func authRequest(acc *app.Account, scopes []app.Scope) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/api/self/following/dc6f5f5bf55bc1073715c98c69fa7ca8/saved", nil)
+	ctx := r.Context()
+	if acc != nil {
+		ctx = context.WithValue(ctx, authAccountCtxtKey, acc)
+	}
+	if scopes != nil {
+		ctx = context.WithValue(ctx, authScopesCtxtKey, scopes)
+	}
+	return r.WithContext(ctx)
+}
+
+func TestHasScope(t *testing.T) {
+	if !hasScope(authRequest(nil, nil), app.ScopeAdmin) {
+		t.Errorf("the requests which were not authorized with a token should not be restricted")
+	}
+	r := authRequest(&app.Account{Handle: "jane"}, []app.Scope{app.ScopeRead})
+	if !hasScope(r, app.ScopeRead) {
+		t.Errorf("the %s scope should be granted", app.ScopeRead)
+	}
+	if hasScope(r, app.ScopeAdmin) {
+		t.Errorf("the %s scope should not be granted", app.ScopeAdmin)
+	}
+	if hasScope(authRequest(&app.Account{Handle: "jane"}, []app.Scope{}), app.ScopeRead) {
+		t.Errorf("the token without scopes should not be granted any")
+	}
+}
+
+func TestValidatePrivateCollection(t *testing.T) {
+	jane := app.Account{Hash: app.Hash("dc6f5f5bf55bc1073715c98c69fa7ca8"), Handle: "jane"}
+	eve := app.Account{Hash: app.Hash("162edb32c80d0e6dd3114fbb59d6273b"), Handle: "eve"}
+	h := handler{logger: log.Dev(log.PanicLevel)}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name   string
+		acc    *app.Account
+		scopes []app.Scope
+		status int
+	}{
+		{"owner", &jane, nil, http.StatusOK},
+		{"owner token with read", &jane, []app.Scope{app.ScopeRead}, http.StatusOK},
+		{"owner token without read", &jane, []app.Scope{app.ScopeWriteVotes}, http.StatusForbidden},
+		{"other account", &eve, nil, http.StatusForbidden},
+		{"failed authorization", nil, nil, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		r := authRequest(tt.acc, tt.scopes)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("collection", "saved")
+		ctx := context.WithValue(r.Context(), chi.RouteCtxKey, rctx)
+		ctx = context.WithValue(ctx, app.AccountCtxtKey, jane)
+		w := httptest.NewRecorder()
+		h.ValidatePrivateCollection(next).ServeHTTP(w, r.WithContext(ctx))
+		if w.Code != tt.status {
+			t.Errorf("%s: invalid status %d for the saved collection, expected %d", tt.name, w.Code, tt.status)
+		}
+	}
+}
+
+type itemsLoader map[app.Hash]app.Item
+
+func (l itemsLoader) LoadItem(f app.Filters) (app.Item, error) {
+	for _, h := range f.LoadItemsFilter.Key {
+		if it, ok := l[h]; ok {
+			return it, nil
+		}
+	}
+	return app.Item{}, errors.NotFoundf("item not found")
+}
+
+func (l itemsLoader) LoadItems(f app.Filters) (app.ItemCollection, uint, error) {
+	return nil, 0, errors.NotImplementedf("not implemented")
+}
+
+func TestValidateModeration(t *testing.T) {
+	now := time.Now()
+	jane := app.Account{Hash: app.Hash("dc6f5f5bf55bc1073715c98c69fa7ca8"), Handle: "jane", CreatedAt: now}
+	mod := app.Account{Hash: app.Hash("162edb32c80d0e6dd3114fbb59d6273b"), Handle: "mod", CreatedAt: now, Role: app.RoleModerator}
+	items := itemsLoader{
+		"janes": {Hash: "janes", SubmittedBy: &jane},
+	}
+	h := handler{logger: log.Dev(log.PanicLevel)}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mw := h.ValidateModeration(h.ValidatePermissions(app.PermDeleteItem), h.RequireScope(app.ScopeAdmin))
+
+	activity := func(typ as.ActivityVocabularyType, hash string) ap.Activity {
+		a := ap.Activity{}
+		a.Type = typ
+		a.Object = as.IRI("https://example.com/api/self/following/dc6f5f5bf55bc1073715c98c69fa7ca8/outbox/" + hash + "/object")
+		return a
+	}
+	tests := []struct {
+		name   string
+		acc    *app.Account
+		scopes []app.Scope
+		a      ap.Activity
+		status int
+	}{
+		{"own item", &jane, []app.Scope{app.ScopeWriteItems}, activity(as.DeleteType, "janes"), http.StatusOK},
+		{"other account", &mod, []app.Scope{app.ScopeWriteItems}, activity(as.UpdateType, "janes"), http.StatusOK},
+		{"user deleting another item", &jane, nil, activity(as.DeleteType, "eves"), http.StatusForbidden},
+		{"moderator without admin scope", &mod, []app.Scope{app.ScopeWriteItems}, activity(as.DeleteType, "janes"), http.StatusForbidden},
+		{"moderator with admin scope", &mod, []app.Scope{app.ScopeWriteItems, app.ScopeAdmin}, activity(as.DeleteType, "janes"), http.StatusOK},
+		{"moderator session", &mod, nil, activity(as.DeleteType, "janes"), http.StatusOK},
+	}
+	items["eves"] = app.Item{Hash: "eves", SubmittedBy: &app.Account{Hash: app.Hash("0ae3b3f41b7c3c09b2b5b2a1a5f9f0c4")}}
+	for _, tt := range tests {
+		r := authRequest(tt.acc, tt.scopes)
+		ctx := context.WithValue(r.Context(), app.ItemCtxtKey, tt.a)
+		ctx = context.WithValue(ctx, app.RepositoryCtxtKey, items)
+		w := httptest.NewRecorder()
+		mw(next).ServeHTTP(w, r.WithContext(ctx))
+		if w.Code != tt.status {
+			t.Errorf("%s: invalid status %d, expected %d", tt.name, w.Code, tt.status)
+		}
+	}
+}
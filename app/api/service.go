@@ -38,6 +38,7 @@ func (h handler) HandleService(w http.ResponseWriter, r *http.Request) {
 	//us.Summary.Set(as.NilLangRef, "This is a link aggregator similar to hacker news and reddit")
 	us.Summary.Set(as.NilLangRef, inf.Summary)
 	us.Content.Set(as.NilLangRef, string(app.Markdown(inf.Description)))
+	us.Languages = inf.Languages
 
 	us.AttributedTo = as.IRI("https://github.com/mariusor")
 	data, _ := json.WithContext(GetContext()).Marshal(us)
@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
@@ -78,6 +79,23 @@ type Config struct {
 	VotingEnabled       bool
 	DownvotingEnabled   bool
 	UserCreatingEnabled bool
+	InviteOnly          bool
+	InviteMinScore      int64
+	InviteQuota         int
+	InviteMaxUses       int
+	StoragePath         string
+	MaxUploadSize       int64
+	ArchiveAfter        time.Duration
+	Moderators          []string
+	Admins              []string
+	SMTP                backendConfig
+	MailFrom            string
+	MailPath            string
+	RateLimitStore      string
+	RateLimits          map[string]RateLimit
+	RateLimitNewAge     time.Duration
+	RateLimitMinScore   int64
+	TrustProxy          bool
 }
 
 // Stats holds data for keeping compatibility with Mastodon instances
@@ -270,6 +288,49 @@ func loadEnv(l *Application) (bool, error) {
 	l.Config.SessionsEnabled = !sessionsDisabled
 	userCreationDisabled, _ := strconv.ParseBool(os.Getenv("DISABLE_USER_CREATION"))
 	l.Config.UserCreatingEnabled = !userCreationDisabled
+	l.Config.InviteOnly, _ = strconv.ParseBool(os.Getenv("INVITE_ONLY"))
+	l.Config.InviteMinScore, _ = strconv.ParseInt(os.Getenv("INVITE_MIN_SCORE"), 10, 64)
+	if l.Config.InviteQuota, err = strconv.Atoi(os.Getenv("INVITE_QUOTA")); err != nil {
+		l.Config.InviteQuota = 5
+	}
+	if l.Config.InviteMaxUses, err = strconv.Atoi(os.Getenv("INVITE_MAX_USES")); err != nil || l.Config.InviteMaxUses < 1 {
+		l.Config.InviteMaxUses = 1
+	}
+
+	if l.Config.StoragePath = os.Getenv("STORAGE_PATH"); l.Config.StoragePath == "" {
+		workDir, _ := os.Getwd()
+		l.Config.StoragePath = filepath.Join(workDir, "media")
+	}
+	l.Config.MaxUploadSize, _ = strconv.ParseInt(os.Getenv("MAX_UPLOAD_SIZE"), 10, 64)
+	l.Config.ArchiveAfter, _ = time.ParseDuration(os.Getenv("ARCHIVE_AFTER"))
+	l.Config.Moderators = make([]string, 0)
+	for _, handle := range strings.Split(os.Getenv("MODERATORS"), ",") {
+		if handle = strings.TrimSpace(handle); len(handle) > 0 {
+			l.Config.Moderators = append(l.Config.Moderators, handle)
+		}
+	}
+	l.Config.Admins = make([]string, 0)
+	for _, handle := range strings.Split(os.Getenv("ADMINS"), ",") {
+		if handle = strings.TrimSpace(handle); len(handle) > 0 {
+			l.Config.Admins = append(l.Config.Admins, handle)
+		}
+	}
+	l.Config.SMTP.Host = os.Getenv("SMTP_HOST")
+	l.Config.SMTP.Port = os.Getenv("SMTP_PORT")
+	l.Config.SMTP.User = os.Getenv("SMTP_USER")
+	l.Config.SMTP.Pw = os.Getenv("SMTP_PASSWORD")
+	if l.Config.MailFrom = os.Getenv("MAIL_FROM"); l.Config.MailFrom == "" {
+		l.Config.MailFrom = fmt.Sprintf("system@%s", l.HostName)
+	}
+	l.Config.MailPath = os.Getenv("MAIL_PATH")
+
+	l.Config.RateLimitStore = strings.ToLower(os.Getenv("RATE_LIMIT_STORE"))
+	l.Config.RateLimits = loadRateLimits(l)
+	if l.Config.RateLimitNewAge, err = time.ParseDuration(os.Getenv("RATE_LIMIT_NEW_ACCOUNT_AGE")); err != nil {
+		l.Config.RateLimitNewAge = 72 * time.Hour
+	}
+	l.Config.RateLimitMinScore, _ = strconv.ParseInt(os.Getenv("RATE_LIMIT_MIN_SCORE"), 10, 64)
+	l.Config.TrustProxy, _ = strconv.ParseBool(os.Getenv("TRUST_PROXY"))
 
 	l.APIURL = os.Getenv("API_URL")
 	if l.APIURL == "" {
@@ -0,0 +1,24 @@
+package app
+
+// MaxAttachments is the maximum number of files that can be uploaded together with an item
+const MaxAttachments = 4
+
+// Attachment is a media file linked to an item, either uploaded locally or loaded from a remote object
+type Attachment struct {
+	URL       string        `json:"url"`
+	MimeType  string        `json:"mimeType,omitempty"`
+	Name      string        `json:"name,omitempty"`
+	Thumbnail ImageMetadata `json:"thumbnail,omitempty"`
+}
+
+type AttachmentCollection []Attachment
+
+// IsImage returns if the attachment has an image mime-type
+func (a Attachment) IsImage() bool {
+	return len(a.MimeType) > 6 && a.MimeType[:6] == "image/"
+}
+
+// HasAttachments returns if the item has any media files attached
+func (i Item) HasAttachments() bool {
+	return i.Metadata != nil && len(i.Metadata.Attachments) > 0
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/mariusor/littr.go/app/db"
+	"github.com/mariusor/littr.go/internal/errors"
+)
+
+// ArchiveItems marks as archived the threads which are older than "age"
+func ArchiveItems(age time.Duration) error {
+	if age <= 0 {
+		return errors.NotValidf("invalid archive age %s", age)
+	}
+	cnt, err := db.Config.ArchiveItems(time.Now().UTC().Add(-age))
+	if err != nil {
+		return err
+	}
+	Logger.Infof("Archived %d items older than %s", cnt, age)
+	return nil
+}
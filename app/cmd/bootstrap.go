@@ -132,6 +132,49 @@ func CleanDB(o *pg.Options) error {
 	return nil
 }
 
+// migrations are the named queries of db/init.sql which bring an existing database up to date.
+// They need to be safe to run more than once, and they run in order, so the tables are created before they are
+// populated or referenced.
+var migrations = []string{
+	"alter-accounts-role",
+	"create-tags",
+	"populate-tags",
+	"create-notifications",
+	"alter-notifications-unique",
+	"create-saved-items",
+	"create-invites",
+	"create-rate-limits",
+	"create-identities",
+	"create-poll-votes",
+	"create-sessions",
+	"alter-oauth-storage",
+}
+
+// MigrateDB runs the migrations on the existing database
+func MigrateDB(o *pg.Options) error {
+	db, err := dbConnection(o)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	dot, err := dotsql.LoadFromFile("./db/init.sql")
+	if err != nil {
+		return errors.Annotatef(err, "unable to load file")
+	}
+	for _, name := range migrations {
+		q, err := dot.Raw(name)
+		if err != nil {
+			return errors.Annotatef(err, "unable to load query: %s", name)
+		}
+		if _, err = db.Exec(q); err != nil {
+			return errors.Annotatef(err, "query: %s", q)
+		}
+		Logger.Infof("Ran migration %s", name)
+	}
+	return nil
+}
+
 func SeedTestData(o *pg.Options, seed map[string][][]interface{}) []error {
 	dot, err := dotsql.LoadFromFile("./db/seed-parametrized.sql")
 	if err != nil {
@@ -378,6 +421,46 @@ func BootstrapDB(o *pg.Options) error {
 		return errors.Annotatef(err, "query: %s", votes)
 	}
 
+	tags, _ := dot.Raw("create-tags")
+	if _, err = db.Exec(tags); err != nil {
+		return errors.Annotatef(err, "query: %s", tags)
+	}
+
+	notifications, _ := dot.Raw("create-notifications")
+	if _, err = db.Exec(notifications); err != nil {
+		return errors.Annotatef(err, "query: %s", notifications)
+	}
+
+	saved, _ := dot.Raw("create-saved-items")
+	if _, err = db.Exec(saved); err != nil {
+		return errors.Annotatef(err, "query: %s", saved)
+	}
+
+	invites, _ := dot.Raw("create-invites")
+	if _, err = db.Exec(invites); err != nil {
+		return errors.Annotatef(err, "query: %s", invites)
+	}
+
+	rateLimits, _ := dot.Raw("create-rate-limits")
+	if _, err = db.Exec(rateLimits); err != nil {
+		return errors.Annotatef(err, "query: %s", rateLimits)
+	}
+
+	identities, _ := dot.Raw("create-identities")
+	if _, err = db.Exec(identities); err != nil {
+		return errors.Annotatef(err, "query: %s", identities)
+	}
+
+	sessions, _ := dot.Raw("create-sessions")
+	if _, err = db.Exec(sessions); err != nil {
+		return errors.Annotatef(err, "query: %s", sessions)
+	}
+
+	pollVotes, _ := dot.Raw("create-poll-votes")
+	if _, err = db.Exec(pollVotes); err != nil {
+		return errors.Annotatef(err, "query: %s", pollVotes)
+	}
+
 	instances, _ := dot.Raw("create-instances")
 	if _, err = db.Exec(instances); err != nil {
 		return errors.Annotatef(err, "query: %s", instances)
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gchaincl/dotsql"
+)
+
+// notIdempotent matches the statements which fail when the table or the index already exists
+var notIdempotent = regexp.MustCompile(`(?im)^\s*create\s+(table|(unique\s+)?index)\s+(\w+)`)
+
+// notIdempotentColumn matches the added columns which fail when the column already exists
+var notIdempotentColumn = regexp.MustCompile(`(?i)add\s+column\s+(\w+)`)
+
+func TestMigrations(t *testing.T) {
+	dot, err := dotsql.LoadFromFile("../../db/init.sql")
+	if err != nil {
+		t.Fatalf("unable to load the queries: %s", err)
+	}
+	for _, name := range migrations {
+		q, err := dot.Raw(name)
+		if err != nil {
+			t.Errorf("unable to load the %s migration: %s", name, err)
+			continue
+		}
+		for _, m := range notIdempotent.FindAllStringSubmatch(q, -1) {
+			if !strings.EqualFold(m[3], "if") {
+				t.Errorf("the %s migration can't run more than once: %q", name, m[0])
+			}
+		}
+		for _, m := range notIdempotentColumn.FindAllStringSubmatch(q, -1) {
+			if !strings.EqualFold(m[1], "if") {
+				t.Errorf("the %s migration can't run more than once: %q", name, m[0])
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"time"
+
+	"github.com/mariusor/littr.go/app"
+	ap "github.com/mariusor/littr.go/app/activitypub"
+	"github.com/mariusor/littr.go/app/db"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+)
+
+// readArchiveFile returns the contents of the "name" file of the "z" account archive
+func readArchiveFile(z *zip.ReadCloser, name string) ([]byte, error) {
+	for _, f := range z.File {
+		if f.Name != name {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return nil, errors.Annotatef(err, "unable to open %s", name)
+		}
+		defer r.Close()
+		return ioutil.ReadAll(r)
+	}
+	return nil, errors.NotFoundf("%s is missing from the archive", name)
+}
+
+// ImportAccount attaches the account archive at "path", as exported from the settings page, to the local
+// "handle" account.
+// The old actor is added to the aliases of the account, so the old instance can move the followers here,
+// and the top level submissions of the outbox are recreated keeping their original publishing dates.
+// Replies are skipped, as the threads they belong to don't exist on this instance.
+func ImportAccount(handle string, path string) error {
+	acc, err := db.Config.LoadAccount(app.Filters{LoadAccountsFilter: app.LoadAccountsFilter{Handle: []string{handle}}})
+	if err != nil {
+		return errors.NewNotFound(err, "account %s", handle)
+	}
+	if !acc.IsLocal() {
+		return errors.NotValidf("only local accounts can import archives")
+	}
+	z, err := zip.OpenReader(path)
+	if err != nil {
+		return errors.Annotatef(err, "unable to open archive %s", path)
+	}
+	defer z.Close()
+
+	actorData, err := readArchiveFile(z, "actor.json")
+	if err != nil {
+		return err
+	}
+	old := ap.Person{}
+	if err := old.UnmarshalJSON(actorData); err != nil {
+		return errors.Annotatef(err, "unable to load the actor from the archive")
+	}
+	oldIRI := old.GetLink().String()
+	if len(oldIRI) == 0 {
+		return errors.NotValidf("the actor from the archive doesn't have an id")
+	}
+
+	if acc.Metadata == nil {
+		acc.Metadata = &app.AccountMetadata{}
+	}
+	if !acc.IsAlias(oldIRI) {
+		acc.Metadata.AlsoKnownAs = append(acc.Metadata.AlsoKnownAs, oldIRI)
+		acc.UpdatedAt = time.Now().UTC()
+		if acc, err = db.Config.SaveAccount(acc); err != nil {
+			return errors.Annotatef(err, "unable to save the alias of account %s", handle)
+		}
+		Logger.Infof("Added %s as an alias of %s", oldIRI, handle)
+	}
+
+	outboxData, err := readArchiveFile(z, "outbox.json")
+	if err != nil {
+		return err
+	}
+	outbox := ap.OrderedCollection{}
+	if err := outbox.UnmarshalJSON(outboxData); err != nil {
+		return errors.Annotatef(err, "unable to load the outbox from the archive")
+	}
+	cnt := 0
+	for _, act := range outbox.OrderedItems {
+		it := app.Item{}
+		if err := it.FromActivityPub(act); err != nil {
+			Logger.WithContext(log.Ctx{
+				"id":  act.GetLink(),
+				"err": err.Error(),
+			}).Warn("unable to load item from the archive")
+			continue
+		}
+		if it.Deleted() || it.Parent != nil {
+			continue
+		}
+		// the items get new identifiers on this instance
+		it.Hash = ""
+		it.Score = 0
+		it.SubmittedBy = &acc
+		if it.Metadata == nil {
+			it.Metadata = &app.ItemMetadata{}
+		}
+		it.Metadata.ID = ""
+		it.Metadata.URL = ""
+		it.Metadata.AuthorURI = ""
+		if _, err := db.Config.SaveItem(it); err != nil {
+			// the items imported before generate the same key, so they fail here
+			Logger.WithContext(log.Ctx{
+				"title": it.Title,
+				"err":   err.Error(),
+			}).Warn("unable to import item")
+			continue
+		}
+		cnt++
+	}
+	Logger.Infof("Imported %d items from %s for %s", cnt, path, handle)
+	return nil
+}
@@ -15,6 +15,21 @@ type FlagBits uint8
 
 const (
 	FlagsDeleted = FlagBits(1 << iota)
+	// FlagsLocked marks the items of a thread a moderator closed for replies and votes
+	FlagsLocked
+	// FlagsArchived marks the items which became too old to receive replies and votes
+	FlagsArchived
+	// FlagsDraft marks the items which were saved by their author, but not published yet
+	FlagsDraft
+	// FlagsScheduled marks the items which will be published at their submission time
+	FlagsScheduled
+	// FlagsUnverified marks the local accounts which didn't confirm their email address yet
+	FlagsUnverified
+	// FlagsBlocked marks the accounts a moderator blocked, they can't log in, submit or vote
+	FlagsBlocked
+
+	// FlagsUnpublished are the flags of the items visible only to their author
+	FlagsUnpublished = FlagsDraft | FlagsScheduled
 
 	FlagsNone = FlagBits(0)
 )
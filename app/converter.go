@@ -78,6 +78,9 @@ func (a *Account) FromActivityPub(it as.Item) error {
 				a.Metadata.Name = name
 			}
 		}
+		if summary := jsonUnescape(p.Summary.First()); len(summary) > 0 {
+			a.Metadata.Blurb = []byte(summary)
+		}
 		if p.Icon != nil {
 			if p.Icon.IsObject() {
 				if ic, ok := p.Icon.(*as.Object); ok {
@@ -113,6 +116,9 @@ func (a *Account) FromActivityPub(it as.Item) error {
 			pName = jsonUnescape(p.Name.First())
 		}
 		a.Handle = pName
+		if name := jsonUnescape(p.Name.First()); len(name) > 0 && name != pName {
+			a.Metadata.Name = name
+		}
 		if a.IsFederated() {
 			if len(a.Metadata.URL) > 0 {
 				host := host(a.Metadata.URL)
@@ -151,6 +157,12 @@ func (a *Account) FromActivityPub(it as.Item) error {
 				Public: pub,
 			}
 		}
+		for _, aka := range p.AlsoKnownAs {
+			a.Metadata.AlsoKnownAs = append(a.Metadata.AlsoKnownAs, aka.String())
+		}
+		if len(p.MovedTo) > 0 {
+			a.Metadata.MovedTo = p.MovedTo.String()
+		}
 
 		return nil
 	}
@@ -181,6 +193,18 @@ func (a *Account) FromActivityPub(it as.Item) error {
 	return nil
 }
 
+// languageFromValues returns the first language key of the contentMap or nameMap values
+func languageFromValues(values ...as.NaturalLanguageValues) string {
+	for _, nlv := range values {
+		for _, v := range nlv {
+			if len(v.Ref) > 0 && v.Ref != as.NilLangRef {
+				return string(v.Ref)
+			}
+		}
+	}
+	return ""
+}
+
 func (i *Item) FromActivityPub(it as.Item) error {
 	if it == nil {
 		return errors.New("nil item received")
@@ -234,6 +258,9 @@ func (i *Item) FromActivityPub(it as.Item) error {
 		if i.Metadata == nil {
 			i.Metadata = &ItemMetadata{}
 		}
+		if lang := languageFromValues(a.Content, a.Name); len(lang) > 0 {
+			i.Metadata.Language = lang
+		}
 
 		if a.AttributedTo != nil {
 			auth := Account{}
@@ -258,6 +285,12 @@ func (i *Item) FromActivityPub(it as.Item) error {
 				}
 			}
 		}
+		if a.Attachment != nil {
+			i.Metadata.Attachments = loadAttachments(a.Attachment)
+		}
+		if replies, ok := a.Replies.(*as.Collection); ok {
+			i.ReplyCount = int64(replies.TotalItems)
+		}
 		if a.InReplyTo != nil {
 			par := Item{}
 			par.FromActivityPub(a.InReplyTo)
@@ -275,6 +308,9 @@ func (i *Item) FromActivityPub(it as.Item) error {
 			tags := TagCollection{}
 			tags.FromActivityPub(a.Tag)
 			for _, t := range tags {
+				if len(t.Name) == 0 {
+					continue
+				}
 				if t.Name[0] == '#' {
 					i.Metadata.Tags = append(i.Metadata.Tags, t)
 				} else {
@@ -287,6 +323,20 @@ func (i *Item) FromActivityPub(it as.Item) error {
 	loadFromArticle := func(i *Item, a ap.Article) error {
 		err := loadFromObject(i, a.Object.Parent)
 		i.Score = a.Score
+		i.Ups = a.Ups
+		i.Downs = a.Downs
+		if a.Locked {
+			i.Lock()
+		}
+		if a.Archived {
+			i.Flags |= FlagsArchived
+		}
+		if a.Draft {
+			i.Flags |= FlagsDraft
+		}
+		if a.Scheduled {
+			i.Flags |= FlagsScheduled
+		}
 		// TODO(marius): here we seem to have a bug, when Source.Content is nil when it shouldn't
 		//    to repro, I used some copy/pasted comments from console javascript
 		if len(a.Source.Content) > 0 && len(a.Source.MediaType) > 0 {
@@ -332,6 +382,19 @@ func (i *Item) FromActivityPub(it as.Item) error {
 		fallthrough
 	case as.PageType:
 		return articleFn(i, loadFromObject, loadFromArticle)
+	case as.QuestionType:
+		loadFromQuestion := func(i *Item, q ap.Question) error {
+			err := loadFromArticle(i, q.Article)
+			i.Metadata.Poll = loadPoll(q)
+			return err
+		}
+		if q, ok := it.(ap.Question); ok {
+			return loadFromQuestion(i, q)
+		}
+		if q, ok := it.(*ap.Question); ok {
+			return loadFromQuestion(i, *q)
+		}
+		return articleFn(i, loadFromObject, loadFromArticle)
 	case as.TombstoneType:
 		id := it.GetLink()
 		i.Hash.FromActivityPub(id)
@@ -378,6 +441,78 @@ func (i *Item) FromActivityPub(it as.Item) error {
 	return nil
 }
 
+// loadAttachments loads the media files from the attachment property of an object
+func loadAttachments(it as.Item) AttachmentCollection {
+	items := make(as.ItemCollection, 0)
+	switch col := it.(type) {
+	case as.ItemCollection:
+		items = col
+	case *as.ItemCollection:
+		items = *col
+	default:
+		items = append(items, it)
+	}
+	toObject := func(it as.Item) (as.Object, bool) {
+		if o, ok := it.(as.Object); ok {
+			return o, true
+		}
+		if o, ok := it.(*as.Object); ok {
+			return *o, true
+		}
+		return as.Object{}, false
+	}
+
+	attachments := make(AttachmentCollection, 0)
+	for _, it := range items {
+		o, ok := toObject(it)
+		if !ok || o.URL == nil {
+			continue
+		}
+		att := Attachment{
+			URL:      o.URL.GetLink().String(),
+			MimeType: string(o.MediaType),
+			Name:     jsonUnescape(o.Name.First()),
+		}
+		if ic, ok := toObject(o.Icon); ok && ic.URL != nil {
+			att.Thumbnail.URI = ic.URL.GetLink().String()
+			att.Thumbnail.MimeType = string(ic.MediaType)
+		}
+		attachments = append(attachments, att)
+	}
+	return attachments
+}
+
+// loadPoll loads the poll options and their results from the oneOf or anyOf properties of a Question
+func loadPoll(q ap.Question) *Poll {
+	p := Poll{
+		EndTime: q.EndTime,
+	}
+	options := q.OneOf
+	if len(q.AnyOf) > 0 {
+		options = q.AnyOf
+		p.Multiple = true
+	}
+	p.Options = make(PollOptions, 0)
+	for _, it := range options {
+		o, ok := it.(as.Object)
+		if !ok {
+			if op, ok := it.(*as.Object); ok {
+				o = *op
+			} else {
+				continue
+			}
+		}
+		opt := PollOption{
+			Name: jsonUnescape(o.Name.First()),
+		}
+		if col, ok := o.Replies.(*as.Collection); ok {
+			opt.Count = int64(col.TotalItems)
+		}
+		p.Options = append(p.Options, opt)
+	}
+	return &p
+}
+
 func (v *Vote) FromActivityPub(it as.Item) error {
 	if it == nil {
 		return errors.New("nil item received")
@@ -491,6 +626,10 @@ func (i *TagCollection) FromActivityPub(it as.ItemCollection) error {
 				URL:  u,
 				Name: ob.Name.First(),
 			}
+			if ob.Type == ap.HashtagType && len(lt.Name) > 0 && lt.Name[0] != '#' {
+				// some servers don't prefix the hashtag names
+				lt.Name = "#" + lt.Name
+			}
 			*i = append(*i, lt)
 		}
 	}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"testing"
+
+	as "github.com/go-ap/activitystreams"
+	ap "github.com/mariusor/littr.go/app/activitypub"
+)
+
+func TestTagCollection_FromActivityPub(t *testing.T) {
+	name := func(s string) as.NaturalLanguageValues {
+		return as.NaturalLanguageValues{{Ref: as.NilLangRef, Value: s}}
+	}
+	col := as.ItemCollection{
+		&as.Object{ID: as.ObjectID("https://example.com/tags/golang"), Type: ap.HashtagType, Name: name("golang")},
+		&as.Object{ID: as.ObjectID("https://example.com/tags/news"), Type: ap.HashtagType, Name: name("#news")},
+		&as.Object{ID: as.ObjectID("https://example.com/~jane"), Type: as.MentionType, Name: name("@jane")},
+	}
+	exp := TagCollection{
+		{Name: "#golang", URL: "https://example.com/tags/golang"},
+		{Name: "#news", URL: "https://example.com/tags/news"},
+		{Name: "@jane", URL: "https://example.com/~jane"},
+	}
+
+	tags := TagCollection{}
+	if err := tags.FromActivityPub(col); err != nil {
+		t.Fatalf("unable to load the tags: %s", err)
+	}
+	if len(tags) != len(exp) {
+		t.Fatalf("invalid tags %v, expected %v", tags, exp)
+	}
+	for i := range exp {
+		if tags[i] != exp[i] {
+			t.Errorf("invalid tag %v, expected %v", tags[i], exp[i])
+		}
+	}
+
+	if err := tags.FromActivityPub(nil); err == nil {
+		t.Errorf("an empty collection should not be loaded")
+	}
+}
@@ -26,6 +26,7 @@ type Account struct {
 	CreatedAt time.Time           `sql:"created_at"`
 	UpdatedAt time.Time           `sql:"updated_at"`
 	Flags     FlagBits            `sql:"flags"`
+	Role      string              `sql:"role"`
 	Metadata  app.AccountMetadata `sql:"metadata"`
 }
 
@@ -80,7 +81,7 @@ func loadAccounts(db *pg.DB, f app.Filters) (app.AccountCollection, error) {
 	}
 
 	sel := fmt.Sprintf(`select 
-		"id", "key", "handle", "email", "score", "created_at", "updated_at", "metadata", "flags"
+		"id", "key", "handle", "email", "score", "created_at", "updated_at", "metadata", "flags", "role"
 	from "accounts" where %s%s;`, fullWhere, f.GetLimit())
 
 	type AccountCollection []Account
@@ -136,7 +137,7 @@ func saveAccount(db *pg.DB, a app.Account) (app.Account, error) {
 
 	ins := `insert into "accounts" ("key", "handle", "email", "score", "created_at", "updated_at", "flags", "metadata")
 	VALUES (?0, ?1, ?2, ?3, ?4, ?5, ?6::bit(8), ?7)
-	ON CONFLICT("key") DO UPDATE SET "score" = ?3, "updated_at" = ?5, "flags" = ?6::bit(8), "metadata" = ?7;`
+	ON CONFLICT("key") DO UPDATE SET "email" = COALESCE(?2, "accounts"."email"), "score" = ?3, "updated_at" = ?5, "flags" = ?6::bit(8), "metadata" = ?7;`
 
 	if res, err := db.Exec(ins, acct.Key, acct.Handle, em, acct.Score, acct.CreatedAt, acct.UpdatedAt, a.Flags, acct.Metadata); err == nil {
 		if rows := res.RowsAffected(); rows == 0 {
@@ -151,3 +152,104 @@ func saveAccount(db *pg.DB, a app.Account) (app.Account, error) {
 	a.Hash = acct.Key.Hash()
 	return a, nil
 }
+
+// deleteAccount tombstones the items of the "a" account and removes its personal data.
+// The account itself is kept with the deleted flag, so its handle can't be registered again
+// and the replies of the other accounts keep their place in the threads.
+func deleteAccount(db *pg.DB, a app.Account) error {
+	deleted := FlagBits{}
+	deleted.Scan(app.FlagsDeleted)
+	now := time.Now().UTC()
+	meta := app.AccountMetadata{}
+	if a.Metadata != nil {
+		meta.ID = a.Metadata.ID
+	}
+
+	return db.RunInTransaction(func(tx *pg.Tx) error {
+		account := `(SELECT "id" FROM "accounts" WHERE "key" ~* ?0)`
+		queries := []string{
+			fmt.Sprintf(`DELETE FROM "item_tags" WHERE "item_id" IN (SELECT "id" FROM "items" WHERE "submitted_by" = %s);`, account),
+			fmt.Sprintf(`UPDATE "items" SET "title" = NULL, "data" = NULL, "metadata" = '{}', "flags" = "flags" | ?1::bit(8), "updated_at" = ?2
+				WHERE "submitted_by" = %s;`, account),
+			fmt.Sprintf(`DELETE FROM "saved_items" WHERE "account_id" = %s;`, account),
+			fmt.Sprintf(`DELETE FROM "notifications" WHERE "account_id" = %s OR "actor_id" = %s;`, account, account),
+			`UPDATE "accounts" SET "email" = NULL, "metadata" = ?3, "flags" = "flags" | ?1::bit(8), "updated_at" = ?2 WHERE "key" ~* ?0;`,
+		}
+		for _, q := range queries {
+			if _, err := tx.Exec(q, a.Hash, deleted, now, meta); err != nil {
+				return errors.Annotatef(err, "DB query error")
+			}
+		}
+		return nil
+	})
+}
+
+// moveAccount re-points the follow relationships and the karma of the "from" account to the "to" account
+// and records the new actor in the metadata of the old one.
+// We store follows only as notifications, so the rows already existing for the "to" account are kept
+// and the duplicates of the "from" account are dropped.
+func moveAccount(db *pg.DB, from, to app.Account) error {
+	now := time.Now().UTC()
+	meta := app.AccountMetadata{}
+	if from.Metadata != nil {
+		meta = *from.Metadata
+	}
+	meta.MovedTo = to.Metadata.ID
+
+	return db.RunInTransaction(func(tx *pg.Tx) error {
+		prev := `(SELECT "id" FROM "accounts" WHERE "key" ~* ?0)`
+		next := `(SELECT "id" FROM "accounts" WHERE "key" ~* ?1)`
+		queries := []string{
+			fmt.Sprintf(`UPDATE "notifications" AS "n" SET "account_id" = %s WHERE "n"."account_id" = %s AND "n"."type" = ?2
+				AND NOT EXISTS (SELECT 1 FROM "notifications" AS "o" WHERE "o"."account_id" = %s AND "o"."type" = ?2
+					AND "o"."actor_id" IS NOT DISTINCT FROM "n"."actor_id" AND "o"."item_id" IS NOT DISTINCT FROM "n"."item_id");`, next, prev, next),
+			fmt.Sprintf(`UPDATE "notifications" AS "n" SET "actor_id" = %s WHERE "n"."actor_id" = %s AND "n"."type" = ?2
+				AND NOT EXISTS (SELECT 1 FROM "notifications" AS "o" WHERE "o"."actor_id" = %s AND "o"."type" = ?2
+					AND "o"."account_id" IS NOT DISTINCT FROM "n"."account_id" AND "o"."item_id" IS NOT DISTINCT FROM "n"."item_id");`, next, prev, next),
+			fmt.Sprintf(`DELETE FROM "notifications" WHERE "type" = ?2 AND ("account_id" = %s OR "actor_id" = %s);`, prev, prev),
+			fmt.Sprintf(`UPDATE "accounts" SET "score" = "score" + (SELECT COALESCE("score", 0) FROM "accounts" WHERE "key" ~* ?0), "updated_at" = ?3
+				WHERE "id" = %s;`, next),
+			`UPDATE "accounts" SET "score" = 0, "metadata" = ?4, "updated_at" = ?3 WHERE "key" ~* ?0;`,
+		}
+		for _, q := range queries {
+			if _, err := tx.Exec(q, from.Hash, to.Hash, string(app.NotificationFollow), now, meta); err != nil {
+				return errors.Annotatef(err, "DB query error")
+			}
+		}
+		return nil
+	})
+}
+
+// updateAccountRole changes the role of the "a" account, the role is not part of the regular saves
+// so the accounts can't change it for themselves
+func updateAccountRole(db *pg.DB, a app.Account, role app.Role) error {
+	upd := `UPDATE "accounts" SET "role" = ?0, "updated_at" = ?1 WHERE "key" ~* ?2;`
+	res, err := db.Exec(upd, string(role), time.Now().UTC(), a.Hash)
+	if err != nil {
+		return errors.Annotatef(err, "DB query error")
+	}
+	if res.RowsAffected() == 0 {
+		return errors.NotFoundf("account %s", a.Handle)
+	}
+	return nil
+}
+
+// loadAccountsByRole returns the local accounts which have "roles" stored
+func loadAccountsByRole(db *pg.DB, roles ...app.Role) (app.AccountCollection, error) {
+	sel := `select "id", "key", "handle", "email", "score", "created_at", "updated_at", "metadata", "flags", "role"
+	from "accounts" where "role" IN (?) ORDER BY "handle" ASC;`
+
+	r := make([]string, len(roles))
+	for k, role := range roles {
+		r[k] = string(role)
+	}
+	agg := make([]Account, 0)
+	if _, err := db.Query(&agg, sel, pg.In(r)); err != nil {
+		return nil, errors.Annotatef(err, "DB query error")
+	}
+	accounts := make(app.AccountCollection, len(agg))
+	for k, acc := range agg {
+		accounts[k] = acc.Model()
+	}
+	return accounts, nil
+}
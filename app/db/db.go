@@ -87,6 +87,7 @@ func (a Account) Model() app.Account {
 		UpdatedAt: a.UpdatedAt,
 		Score:     a.Score,
 		Flags:     f,
+		Role:      app.Role(a.Role),
 		Metadata:  &m,
 	}
 }
@@ -120,7 +121,7 @@ func (f *FlagBits) Scan(src interface{}) error {
 	case app.FlagBits:
 		for j := range f {
 			bv := v >> uint(len(f)-j-1)
-			f[j] = uint8(bv)
+			f[j] = uint8(bv & 1)
 		}
 	default:
 		return errors.Errorf("bad %T type assertion when loading %T", v, f)
@@ -180,7 +181,70 @@ func (c config) SaveVote(v app.Vote) (app.Vote, error) {
 }
 
 func (c config) SaveItem(it app.Item) (app.Item, error) {
-	return saveItem(c.DB, it)
+	saved, err := saveItem(c.DB, it)
+	if err != nil {
+		return saved, err
+	}
+	if !saved.IsPublished() {
+		// the notifications are sent when the item gets published
+		return saved, nil
+	}
+	if err := saveItemNotifications(c.DB, saved); err != nil {
+		Logger.WithContext(log.Ctx{
+			"key": saved.Hash,
+		}).Error(err.Error())
+	}
+	return saved, nil
+}
+
+// PublishScheduledItems publishes the scheduled items which have their submission time before "t",
+// sending the notifications and adding the vote of their author, like for a regular submission.
+func (c config) PublishScheduledItems(t time.Time) (app.ItemCollection, error) {
+	items, err := publishScheduledItems(c.DB, t)
+	if err != nil {
+		return items, err
+	}
+	for _, it := range items {
+		it := it
+		if err := saveItemNotifications(c.DB, it); err != nil {
+			Logger.WithContext(log.Ctx{
+				"key": it.Hash,
+			}).Error(err.Error())
+		}
+		if it.SubmittedBy == nil {
+			continue
+		}
+		v := app.Vote{
+			SubmittedBy: it.SubmittedBy,
+			Item:        &it,
+			Weight:      1 * app.ScoreMultiplier,
+		}
+		if _, err := saveVote(c.DB, v); err != nil {
+			Logger.WithContext(log.Ctx{
+				"key":    it.Hash,
+				"author": it.SubmittedBy.Handle,
+			}).Error(err.Error())
+		}
+	}
+	return items, nil
+}
+
+// LockItem closes, or reopens, the thread starting at "it" for replies and votes
+func (c config) LockItem(it app.Item, lock bool) error {
+	if len(it.Hash) == 0 {
+		return errors.Errorf("invalid item")
+	}
+	err := lockItem(c.DB, it, lock)
+	if err != nil {
+		Logger.WithContext(log.Ctx{"item": it.Hash, "lock": lock}).Error(err.Error())
+	}
+	return err
+}
+
+// ArchiveItems marks as archived the threads started before "olderThan"
+// It returns the number of archived items.
+func (c config) ArchiveItems(olderThan time.Time) (int, error) {
+	return archiveItems(c.DB, olderThan)
 }
 
 func (c config) LoadItem(f app.Filters) (app.Item, error) {
@@ -413,5 +477,143 @@ GROUP BY "accounts"."id", "accounts"."key" ORDER BY "accounts"."id";`,
 // LoadInfo this method is here to keep compatibility with the repository interfaces
 // but in the long term we might want to store some of this information in the DB
 func (c config) LoadInfo() (app.Info, error) {
-	return app.Instance.NodeInfo(), nil
+	inf := app.Instance.NodeInfo()
+	languages, err := cachedLanguages(time.Now(), func() ([]string, error) { return loadLanguages(c.DB) })
+	if err != nil {
+		return inf, err
+	}
+	inf.Languages = append(make([]string, 0, len(languages)), languages...)
+	return inf, nil
+}
+
+func (c config) DeleteAccount(a app.Account) error {
+	if len(a.Hash) == 0 {
+		return errors.Errorf("invalid account to delete")
+	}
+	err := deleteAccount(c.DB, a)
+	if err != nil {
+		Logger.WithContext(log.Ctx{"account": a.Hash}).Error(err.Error())
+	}
+	return err
+}
+
+func (c config) MoveAccount(from, to app.Account) error {
+	if len(from.Hash) == 0 || len(to.Hash) == 0 || to.Metadata == nil || len(to.Metadata.ID) == 0 {
+		return errors.Errorf("invalid accounts to move")
+	}
+	if from.Hash == to.Hash {
+		return errors.Errorf("an account can't be moved to itself")
+	}
+	err := moveAccount(c.DB, from, to)
+	if err != nil {
+		Logger.WithContext(log.Ctx{"from": from.Hash, "to": to.Hash}).Error(err.Error())
+	}
+	return err
+}
+
+func (c config) SaveInvite(i app.Invite) (app.Invite, error) {
+	return saveInvite(c.DB, i)
+}
+
+func (c config) LoadInvites(a app.Account) (app.InviteCollection, error) {
+	return loadInvites(c.DB, a)
+}
+
+// CountInvites returns the number of invites "a" created after "since"
+func (c config) CountInvites(a app.Account, since time.Time) (int, error) {
+	return countInvites(c.DB, a, since)
+}
+
+func (c config) ClaimInvite(code string) (app.Invite, error) {
+	if len(code) == 0 {
+		return app.Invite{}, errors.NotValidf("missing invite code")
+	}
+	return claimInvite(c.DB, code, time.Now().UTC())
+}
+
+func (c config) ReleaseInvite(code string) error {
+	return releaseInvite(c.DB, code)
+}
+
+// SaveInvitation records that the "a" account registered with the invite with "code"
+func (c config) SaveInvitation(code string, a app.Account) error {
+	if len(a.Hash) == 0 {
+		return errors.Errorf("invalid invitation, missing account")
+	}
+	return saveInvitation(c.DB, code, a)
+}
+
+func (c config) LoadInviteTree(a app.Account) (app.InvitationCollection, error) {
+	return loadInviteTree(c.DB, a)
+}
+
+func (c config) LoadInviter(a app.Account) (*app.Account, error) {
+	return loadInviter(c.DB, a)
+}
+
+func (c config) PruneInviteTree(a app.Account) (int, error) {
+	if len(a.Hash) == 0 {
+		return 0, errors.Errorf("invalid account to prune")
+	}
+	count, err := pruneInviteTree(c.DB, a)
+	if err != nil {
+		Logger.WithContext(log.Ctx{"account": a.Hash}).Error(err.Error())
+	}
+	return count, err
+}
+
+// SetAccountRole changes the role of the "a" local account
+func (c config) SetAccountRole(a app.Account, role app.Role) error {
+	if len(a.Hash) == 0 || !a.IsLocal() {
+		return errors.Errorf("invalid account to change the role of")
+	}
+	if !app.ValidRole(role) {
+		return errors.NotValidf("invalid role %q", role)
+	}
+	return updateAccountRole(c.DB, a, role)
+}
+
+// LoadAccountsByRole returns the accounts which have one of the "roles" stored
+func (c config) LoadAccountsByRole(roles ...app.Role) (app.AccountCollection, error) {
+	return loadAccountsByRole(c.DB, roles...)
+}
+
+// SaveIdentity links the "i" provider identity to its account
+func (c config) SaveIdentity(i app.Identity) (app.Identity, error) {
+	return saveIdentity(c.DB, i)
+}
+
+// LoadIdentityAccount returns the account which logs in with the "subject" account of "provider"
+func (c config) LoadIdentityAccount(provider string, subject string) (app.Account, error) {
+	return loadIdentityAccount(c.DB, provider, subject)
+}
+
+// LoadIdentities returns the provider identities linked to the "a" account
+func (c config) LoadIdentities(a app.Account) (app.IdentityCollection, error) {
+	return loadIdentities(c.DB, a)
+}
+
+// DeleteIdentity unlinks the "subject" account of "provider" from the "a" account
+func (c config) DeleteIdentity(a app.Account, provider string, subject string) error {
+	return deleteIdentity(c.DB, a, provider, subject)
+}
+
+// SaveSession saves the "s" browser session of its account
+func (c config) SaveSession(s app.Session) (app.Session, error) {
+	return saveSession(c.DB, s)
+}
+
+// TouchSession checks the "id" session of the "a" account is still valid, and saves the address it was used from
+func (c config) TouchSession(a app.Account, id string, ip string) error {
+	return touchSession(c.DB, a, id, ip)
+}
+
+// LoadSessions returns the browser sessions the "a" account is logged in with
+func (c config) LoadSessions(a app.Account) (app.SessionCollection, error) {
+	return loadSessions(c.DB, a)
+}
+
+// DeleteSessions logs the "a" account out of the "ids" browser sessions, or out of all of them when no identifier is passed
+func (c config) DeleteSessions(a app.Account, ids ...string) error {
+	return deleteSessions(c.DB, a, ids...)
 }
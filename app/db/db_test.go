@@ -0,0 +1,75 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gchaincl/dotsql"
+	"github.com/go-pg/pg"
+)
+
+// testDB connects to the database configured in the DB_* environment variables, the same the integration tests use,
+// and runs the "queries" from db/init.sql in a new schema, which is dropped by the returned function.
+// The test is skipped when there's no database to connect to.
+func testDB(t *testing.T, queries ...string) (*pg.DB, func()) {
+	t.Helper()
+	host := os.Getenv("DB_HOST")
+	if len(host) == 0 {
+		t.Skip("no test database configured")
+	}
+	port := os.Getenv("DB_PORT")
+	if len(port) == 0 {
+		port = "5432"
+	}
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	db := pg.Connect(&pg.Options{
+		Addr:     fmt.Sprintf("%s:%s", host, port),
+		User:     os.Getenv("DB_USER"),
+		Password: os.Getenv("DB_PASSWORD"),
+		Database: os.Getenv("DB_NAME"),
+		OnConnect: func(c *pg.Conn) error {
+			_, err := c.Exec(fmt.Sprintf(`SET search_path TO "%s", public`, schema))
+			return err
+		},
+	})
+	if _, err := db.Exec(fmt.Sprintf(`CREATE SCHEMA "%s"`, schema)); err != nil {
+		db.Close()
+		t.Skipf("unable to use the test database: %s", err)
+	}
+	cleanup := func() {
+		db.Exec(fmt.Sprintf(`DROP SCHEMA "%s" CASCADE`, schema))
+		db.Close()
+	}
+	dot, err := dotsql.LoadFromFile("../../db/init.sql")
+	if err != nil {
+		cleanup()
+		t.Fatalf("unable to load the queries: %s", err)
+	}
+	for _, name := range queries {
+		q, err := dot.Raw(name)
+		if err == nil {
+			_, err = db.Exec(q)
+		}
+		if err != nil {
+			cleanup()
+			t.Fatalf("unable to run %s: %s", name, err)
+		}
+	}
+	return db, cleanup
+}
+
+// testAccounts inserts accounts with the "handles", their keys being the handles padded to 32 characters
+func testAccounts(t *testing.T, db *pg.DB, handles ...string) {
+	t.Helper()
+	for _, handle := range handles {
+		if _, err := db.Exec(`INSERT INTO "accounts" ("key", "handle") VALUES (?0, ?1)`, testKey(handle), handle); err != nil {
+			t.Fatalf("unable to create account %s: %s", handle, err)
+		}
+	}
+}
+
+func testKey(handle string) string {
+	return fmt.Sprintf("%032x", handle)
+}
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/internal/errors"
+)
+
+type identityView struct {
+	Provider  string      `sql:"provider"`
+	Subject   string      `sql:"subject"`
+	Handle    string      `sql:"handle"`
+	Email     string      `sql:"email"`
+	CreatedAt time.Time   `sql:"created_at"`
+	LastLogin pg.NullTime `sql:"last_login"`
+}
+
+func (i identityView) Model() app.Identity {
+	return app.Identity{
+		Provider:  i.Provider,
+		Subject:   i.Subject,
+		Handle:    i.Handle,
+		Email:     i.Email,
+		CreatedAt: i.CreatedAt,
+		LastLogin: i.LastLogin.Time,
+	}
+}
+
+// saveIdentity links the provider identity to its account, or updates the profile details of an already linked one
+func saveIdentity(db *pg.DB, i app.Identity) (app.Identity, error) {
+	if i.Account == nil || len(i.Account.Hash) == 0 {
+		return i, errors.Errorf("invalid identity, missing account")
+	}
+	if len(i.Provider) == 0 || len(i.Subject) == 0 {
+		return i, errors.Errorf("invalid identity, missing provider account")
+	}
+	if i.CreatedAt.IsZero() {
+		i.CreatedAt = time.Now().UTC()
+	}
+	var lastLogin interface{}
+	if !i.LastLogin.IsZero() {
+		lastLogin = i.LastLogin
+	}
+	ins := `INSERT INTO "identities" ("account_id", "provider", "subject", "handle", "email", "created_at", "last_login")
+	VALUES ((SELECT "id" FROM "accounts" WHERE "key" ~* ?0), ?1, ?2, ?3, ?4, ?5, ?6)
+	ON CONFLICT ("provider", "subject") DO UPDATE SET "handle" = ?3, "email" = ?4, "last_login" = ?6
+	WHERE "identities"."account_id" = (SELECT "id" FROM "accounts" WHERE "key" ~* ?0);`
+	res, err := db.Exec(ins, i.Account.Hash, i.Provider, i.Subject, i.Handle, i.Email, i.CreatedAt, lastLogin)
+	if err != nil {
+		return i, errors.Annotatef(err, "DB query error")
+	}
+	if res.RowsAffected() == 0 {
+		return i, errors.Forbiddenf("the %s account is linked to a different account", i.Provider)
+	}
+	return i, nil
+}
+
+// loadIdentityAccount returns the account the provider identity is linked to
+func loadIdentityAccount(db *pg.DB, provider string, subject string) (app.Account, error) {
+	sel := `SELECT "a"."id", "a"."key", "a"."handle", "a"."email", "a"."score", "a"."created_at", "a"."updated_at",
+		"a"."metadata", "a"."flags", "a"."role"
+	FROM "identities" AS "i" INNER JOIN "accounts" AS "a" ON "a"."id" = "i"."account_id"
+	WHERE "i"."provider" = ?0 AND "i"."subject" = ?1;`
+
+	agg := make([]Account, 0)
+	if _, err := db.Query(&agg, sel, provider, subject); err != nil {
+		return app.Account{}, errors.Annotatef(err, "DB query error")
+	}
+	if len(agg) == 0 {
+		return app.Account{}, errors.NotFoundf("%s identity not linked", provider)
+	}
+	return agg[0].Model(), nil
+}
+
+func loadIdentities(db *pg.DB, a app.Account) (app.IdentityCollection, error) {
+	sel := `SELECT "provider", "subject", "handle", "email", "created_at", "last_login" FROM "identities"
+	WHERE "account_id" = (SELECT "id" FROM "accounts" WHERE "key" ~* ?0) ORDER BY "created_at" ASC;`
+
+	agg := make([]identityView, 0)
+	if _, err := db.Query(&agg, sel, a.Hash); err != nil {
+		return nil, errors.Annotatef(err, "DB query error")
+	}
+	identities := make(app.IdentityCollection, len(agg))
+	for k, i := range agg {
+		identities[k] = i.Model()
+		identities[k].Account = &a
+	}
+	return identities, nil
+}
+
+func deleteIdentity(db *pg.DB, a app.Account, provider string, subject string) error {
+	del := `DELETE FROM "identities" WHERE "account_id" = (SELECT "id" FROM "accounts" WHERE "key" ~* ?0)
+	AND "provider" = ?1 AND "subject" = ?2;`
+	res, err := db.Exec(del, a.Hash, provider, subject)
+	if err != nil {
+		return errors.Annotatef(err, "DB query error")
+	}
+	if res.RowsAffected() == 0 {
+		return errors.NotFoundf("%s identity not linked", provider)
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/internal/errors"
+)
+
+type inviteView struct {
+	ID        int64       `sql:"id,auto"`
+	Code      string      `sql:"code"`
+	MaxUses   int         `sql:"max_uses"`
+	Uses      int         `sql:"uses"`
+	CreatedAt time.Time   `sql:"created_at"`
+	ExpiresAt pg.NullTime `sql:"expires_at"`
+	AuthorKey app.Key     `sql:"author_key,size(32)"`
+}
+
+func (i inviteView) Model() app.Invite {
+	inv := app.Invite{
+		Code:      i.Code,
+		MaxUses:   i.MaxUses,
+		Uses:      i.Uses,
+		CreatedAt: i.CreatedAt,
+		ExpiresAt: i.ExpiresAt.Time,
+	}
+	if !i.AuthorKey.IsEmpty() {
+		inv.CreatedBy = &app.Account{Hash: i.AuthorKey.Hash()}
+	}
+	return inv
+}
+
+type invitationsView struct {
+	Account
+	InviterKey    app.Key        `sql:"inviter_key,size(32)"`
+	InviterHandle sql.NullString `sql:"inviter_handle"`
+	Code          sql.NullString `sql:"code"`
+	Depth         int            `sql:"depth"`
+	InvitedAt     time.Time      `sql:"invited_at"`
+}
+
+func (i invitationsView) Model() app.Invitation {
+	inv := app.Invitation{
+		Account:   i.Account.Model(),
+		Code:      i.Code.String,
+		Depth:     i.Depth,
+		CreatedAt: i.InvitedAt,
+	}
+	if i.InviterHandle.Valid {
+		inv.InvitedBy = &app.Account{
+			Hash:   i.InviterKey.Hash(),
+			Handle: i.InviterHandle.String,
+		}
+	}
+	return inv
+}
+
+func saveInvite(db *pg.DB, i app.Invite) (app.Invite, error) {
+	if i.CreatedBy == nil || len(i.CreatedBy.Hash) == 0 {
+		return i, errors.Errorf("invalid invite, missing account")
+	}
+	if len(i.Code) == 0 {
+		return i, errors.Errorf("invalid invite, missing code")
+	}
+	if i.CreatedAt.IsZero() {
+		i.CreatedAt = time.Now().UTC()
+	}
+	var expires interface{}
+	if !i.ExpiresAt.IsZero() {
+		expires = i.ExpiresAt
+	}
+	ins := `INSERT INTO "invites" ("code", "created_by", "max_uses", "created_at", "expires_at")
+	VALUES (?0, (SELECT "id" FROM "accounts" WHERE "key" ~* ?1), ?2, ?3, ?4);`
+	if _, err := db.Exec(ins, i.Code, i.CreatedBy.Hash, i.MaxUses, i.CreatedAt, expires); err != nil {
+		return i, errors.Annotatef(err, "DB query error")
+	}
+	return i, nil
+}
+
+func loadInvites(db *pg.DB, a app.Account) (app.InviteCollection, error) {
+	sel := `SELECT "i"."id", "i"."code", "i"."max_uses", "i"."uses", "i"."created_at", "i"."expires_at", "a"."key" AS "author_key"
+	FROM "invites" AS "i" INNER JOIN "accounts" AS "a" ON "a"."id" = "i"."created_by"
+	WHERE "a"."key" ~* ?0 ORDER BY "i"."created_at" DESC;`
+
+	agg := make([]inviteView, 0)
+	if _, err := db.Query(&agg, sel, a.Hash); err != nil {
+		return nil, errors.Annotatef(err, "DB query error")
+	}
+	invites := make(app.InviteCollection, len(agg))
+	for k, i := range agg {
+		invites[k] = i.Model()
+	}
+	return invites, nil
+}
+
+func countInvites(db *pg.DB, a app.Account, since time.Time) (int, error) {
+	sel := `SELECT COUNT(*) FROM "invites" WHERE "created_by" = (SELECT "id" FROM "accounts" WHERE "key" ~* ?0) AND "created_at" >= ?1`
+	var count int
+	if _, err := db.Query(&count, sel, a.Hash, since); err != nil {
+		return 0, errors.Annotatef(err, "DB query error")
+	}
+	return count, nil
+}
+
+// claimInvite uses one of the registrations allowed by the invite with "code"
+// The check and the update are done in the same query, so concurrent registrations can't go over the limit.
+func claimInvite(db *pg.DB, code string, t time.Time) (app.Invite, error) {
+	upd := `UPDATE "invites" SET "uses" = "uses" + 1
+	WHERE "code" = ?0 AND "uses" < "max_uses" AND ("expires_at" IS NULL OR "expires_at" > ?1)
+	RETURNING "id", "code", "max_uses", "uses", "created_at", "expires_at";`
+
+	claimed := make([]inviteView, 0)
+	if _, err := db.Query(&claimed, upd, code, t); err != nil {
+		return app.Invite{}, errors.Annotatef(err, "DB query error")
+	}
+	if len(claimed) == 0 {
+		return app.Invite{}, errors.NotFoundf("invite %q is not valid", code)
+	}
+	return claimed[0].Model(), nil
+}
+
+// releaseInvite gives back a use of the invite with "code", when the registration that claimed it failed
+func releaseInvite(db *pg.DB, code string) error {
+	upd := `UPDATE "invites" SET "uses" = "uses" - 1 WHERE "code" = ?0 AND "uses" > 0;`
+	if _, err := db.Exec(upd, code); err != nil {
+		return errors.Annotatef(err, "DB query error")
+	}
+	return nil
+}
+
+func saveInvitation(db *pg.DB, code string, a app.Account) error {
+	ins := `INSERT INTO "invitations" ("account_id", "invited_by", "invite_id", "created_at")
+	SELECT (SELECT "id" FROM "accounts" WHERE "key" ~* ?0), "created_by", "id", ?2 FROM "invites" WHERE "code" = ?1
+	ON CONFLICT DO NOTHING;`
+	if _, err := db.Exec(ins, a.Hash, code, time.Now().UTC()); err != nil {
+		return errors.Annotatef(err, "DB query error")
+	}
+	return nil
+}
+
+// inviteTree is the recursive query for the accounts invited, directly or not, by the ?0 account
+const inviteTree = `WITH RECURSIVE "tree" AS (
+		SELECT "account_id", "invited_by", "invite_id", "created_at", 1 AS "depth" FROM "invitations"
+		WHERE "invited_by" = (SELECT "id" FROM "accounts" WHERE "key" ~* ?0)
+	UNION ALL
+		SELECT "i"."account_id", "i"."invited_by", "i"."invite_id", "i"."created_at", "tree"."depth" + 1 FROM "invitations" AS "i"
+		INNER JOIN "tree" ON "i"."invited_by" = "tree"."account_id"
+	)`
+
+// loadInviteTree returns the accounts invited by "a" and, recursively, the ones they invited
+func loadInviteTree(db *pg.DB, a app.Account) (app.InvitationCollection, error) {
+	sel := inviteTree + `
+	SELECT "acc"."id", "acc"."key", "acc"."handle", "acc"."score", "acc"."created_at", "acc"."updated_at", "acc"."flags",
+		"acc"."metadata", "inviter"."key" AS "inviter_key", "inviter"."handle" AS "inviter_handle", "invite"."code",
+		"tree"."depth", "tree"."created_at" AS "invited_at"
+	FROM "tree"
+		INNER JOIN "accounts" AS "acc" ON "acc"."id" = "tree"."account_id"
+		LEFT JOIN "accounts" AS "inviter" ON "inviter"."id" = "tree"."invited_by"
+		LEFT JOIN "invites" AS "invite" ON "invite"."id" = "tree"."invite_id"
+	ORDER BY "tree"."depth" ASC, "tree"."created_at" ASC;`
+
+	agg := make([]invitationsView, 0)
+	if _, err := db.Query(&agg, sel, a.Hash); err != nil {
+		return nil, errors.Annotatef(err, "DB query error")
+	}
+	invitations := make(app.InvitationCollection, len(agg))
+	for k, i := range agg {
+		invitations[k] = i.Model()
+	}
+	return invitations, nil
+}
+
+// loadInviter returns the account which invited "a", if it registered with an invite
+func loadInviter(db *pg.DB, a app.Account) (*app.Account, error) {
+	sel := `SELECT "inviter"."id", "inviter"."key", "inviter"."handle", "inviter"."score", "inviter"."created_at",
+		"inviter"."updated_at", "inviter"."flags", "inviter"."metadata"
+	FROM "invitations" AS "i" INNER JOIN "accounts" AS "inviter" ON "inviter"."id" = "i"."invited_by"
+	WHERE "i"."account_id" = (SELECT "id" FROM "accounts" WHERE "key" ~* ?0);`
+
+	agg := make([]Account, 0)
+	if _, err := db.Query(&agg, sel, a.Hash); err != nil {
+		return nil, errors.Annotatef(err, "DB query error")
+	}
+	if len(agg) == 0 {
+		return nil, nil
+	}
+	inviter := agg[0].Model()
+	return &inviter, nil
+}
+
+// pruneInviteTree blocks the "a" account together with all the accounts in its invite tree,
+// and expires the invites they created which were not used yet.
+// It returns the number of blocked accounts.
+func pruneInviteTree(db *pg.DB, a app.Account) (int, error) {
+	blocked := FlagBits{}
+	blocked.Scan(app.FlagsBlocked)
+	now := time.Now().UTC()
+
+	count := 0
+	err := db.RunInTransaction(func(tx *pg.Tx) error {
+		branch := inviteTree + `, "branch" AS (
+			SELECT "id" FROM "accounts" WHERE "key" ~* ?0
+		UNION
+			SELECT "account_id" FROM "tree"
+		)`
+		upd := branch + `
+		UPDATE "accounts" SET "flags" = "flags" | ?1::bit(8), "updated_at" = ?2 WHERE "id" IN (SELECT "id" FROM "branch");`
+		res, err := tx.Exec(upd, a.Hash, blocked, now)
+		if err != nil {
+			return errors.Annotatef(err, "DB query error")
+		}
+		count = res.RowsAffected()
+		exp := branch + `
+		UPDATE "invites" SET "expires_at" = ?2 WHERE "created_by" IN (SELECT "id" FROM "branch")
+			AND "uses" < "max_uses" AND ("expires_at" IS NULL OR "expires_at" > ?2);`
+		if _, err := tx.Exec(exp, a.Hash, blocked, now); err != nil {
+			return errors.Annotatef(err, "DB query error")
+		}
+		return nil
+	})
+	return count, err
+}
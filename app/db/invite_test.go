@@ -0,0 +1,145 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/internal/errors"
+)
+
+func testAccount(handle string) app.Account {
+	return app.Account{Handle: handle, Hash: app.Hash(testKey(handle))}
+}
+
+func TestClaimInvite(t *testing.T) {
+	db, cleanup := testDB(t, "create-accounts", "create-invites")
+	defer cleanup()
+	testAccounts(t, db, "jane")
+
+	now := time.Now().UTC()
+	jane := testAccount("jane")
+	for _, i := range []app.Invite{
+		{Code: "twice", MaxUses: 2, CreatedBy: &jane},
+		{Code: "expired", MaxUses: 2, CreatedBy: &jane, ExpiresAt: now.Add(-time.Hour)},
+		{Code: "later", MaxUses: 1, CreatedBy: &jane, ExpiresAt: now.Add(time.Hour)},
+	} {
+		if _, err := saveInvite(db, i); err != nil {
+			t.Fatalf("unable to save invite %s: %s", i.Code, err)
+		}
+	}
+
+	for uses := 1; uses <= 2; uses++ {
+		inv, err := claimInvite(db, "twice", now)
+		if err != nil {
+			t.Fatalf("use %d of the invite was refused: %s", uses, err)
+		}
+		if inv.Uses != uses {
+			t.Errorf("invalid uses %d, expected %d", inv.Uses, uses)
+		}
+	}
+	if _, err := claimInvite(db, "twice", now); !errors.IsNotFound(err) {
+		t.Errorf("the exhausted invite was claimed, got %v", err)
+	}
+	if _, err := claimInvite(db, "expired", now); !errors.IsNotFound(err) {
+		t.Errorf("the expired invite was claimed, got %v", err)
+	}
+	if _, err := claimInvite(db, "later", now.Add(2*time.Hour)); !errors.IsNotFound(err) {
+		t.Errorf("the invite was claimed after it expired, got %v", err)
+	}
+	if _, err := claimInvite(db, "later", now); err != nil {
+		t.Errorf("the invite was refused before it expired: %s", err)
+	}
+	if _, err := claimInvite(db, "missing", now); !errors.IsNotFound(err) {
+		t.Errorf("a missing invite was claimed, got %v", err)
+	}
+
+	if err := releaseInvite(db, "twice"); err != nil {
+		t.Fatalf("unable to release the invite: %s", err)
+	}
+	if inv, err := claimInvite(db, "twice", now); err != nil || inv.Uses != 2 {
+		t.Errorf("the released use of the invite was not available: %v %v", inv, err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := releaseInvite(db, "twice"); err != nil {
+			t.Fatalf("unable to release the invite: %s", err)
+		}
+	}
+	invites, err := loadInvites(db, jane)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, inv := range invites {
+		if inv.Code == "twice" && inv.Uses != 0 {
+			t.Errorf("the uses of a released invite should stop at 0, got %d", inv.Uses)
+		}
+	}
+}
+
+func TestLoadInviteTree(t *testing.T) {
+	db, cleanup := testDB(t, "create-accounts", "create-invites")
+	defer cleanup()
+	testAccounts(t, db, "jane", "john", "eve", "bob", "alice")
+
+	invite := func(by, code string, invited ...string) {
+		inviter := testAccount(by)
+		if _, err := saveInvite(db, app.Invite{Code: code, MaxUses: len(invited), CreatedBy: &inviter}); err != nil {
+			t.Fatalf("unable to save invite %s: %s", code, err)
+		}
+		for _, handle := range invited {
+			if _, err := claimInvite(db, code, time.Now().UTC()); err != nil {
+				t.Fatalf("unable to claim invite %s: %s", code, err)
+			}
+			if err := saveInvitation(db, code, testAccount(handle)); err != nil {
+				t.Fatalf("unable to save the invitation of %s: %s", handle, err)
+			}
+		}
+	}
+	invite("jane", "jane-1", "john")
+	invite("john", "john-1", "bob")
+	invite("jane", "jane-2", "eve")
+	invite("bob", "bob-1", "alice")
+
+	tree, err := loadInviteTree(db, testAccount("jane"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []struct {
+		handle  string
+		inviter string
+		code    string
+		depth   int
+	}{
+		{"john", "jane", "jane-1", 1},
+		{"eve", "jane", "jane-2", 1},
+		{"bob", "john", "john-1", 2},
+		{"alice", "bob", "bob-1", 3},
+	}
+	if len(tree) != len(exp) {
+		t.Fatalf("invalid invite tree size %d, expected %d", len(tree), len(exp))
+	}
+	for k, e := range exp {
+		inv := tree[k]
+		if inv.Account.Handle != e.handle || inv.Depth != e.depth || inv.Code != e.code {
+			t.Errorf("invalid invitation %d: %s at depth %d with %q, expected %s at depth %d with %q",
+				k, inv.Account.Handle, inv.Depth, inv.Code, e.handle, e.depth, e.code)
+		}
+		if inv.InvitedBy == nil || inv.InvitedBy.Handle != e.inviter {
+			t.Errorf("invalid inviter of %s, expected %s", inv.Account.Handle, e.inviter)
+		}
+	}
+
+	branch, err := loadInviteTree(db, testAccount("john"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(branch) != 2 || branch[0].Account.Handle != "bob" || branch[1].Account.Handle != "alice" || branch[1].Depth != 2 {
+		t.Errorf("invalid invite tree of john %v", branch)
+	}
+	if inviter, err := loadInviter(db, testAccount("bob")); err != nil || inviter == nil || inviter.Handle != "john" {
+		t.Errorf("invalid inviter of bob %v: %v", inviter, err)
+	}
+	if inviter, err := loadInviter(db, testAccount("jane")); err != nil || inviter != nil {
+		t.Errorf("jane wasn't invited, got %v: %v", inviter, err)
+	}
+}
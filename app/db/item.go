@@ -8,6 +8,7 @@ import (
 	"github.com/mariusor/littr.go/app"
 	"github.com/mariusor/littr.go/internal/log"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mariusor/littr.go/internal/errors"
@@ -27,6 +28,9 @@ type Item struct {
 	Metadata    app.ItemMetadata `sql:"metadata"`
 	Path        Path             `sql:"path"`
 	FullPath    Path
+	Replies     int64 `sql:"-"`
+	Ups         int64 `sql:"-"`
+	Downs       int64 `sql:"-"`
 	author      *Account
 }
 
@@ -79,6 +83,9 @@ func (i Item) Model() app.Item {
 		Score:       i.Score,
 		UpdatedAt:   i.UpdatedAt,
 		IsTop:       len(i.Path) == 0,
+		ReplyCount:  i.Replies,
+		Ups:         i.Ups,
+		Downs:       i.Downs,
 	}
 	if len(i.Path) > 0 {
 		res.FullPath = append(i.Path, byte('.'))
@@ -153,7 +160,15 @@ func saveItem(db *pg.DB, it app.Item) (app.Item, error) {
 	}
 
 	i.Metadata = *it.Metadata
-	i.Flags.Scan(it.Flags)
+	if len(i.Metadata.Language) == 0 {
+		i.Metadata.Language = app.DetectLanguage(fmt.Sprintf("%s\n%s", it.Title, it.Data))
+	}
+	// the locked and archived flags are changed only through lockItem and archiveItems
+	i.Flags.Scan(it.Flags &^ threadFlags)
+	mask := FlagBits{}
+	mask.Scan(threadFlags)
+	unpublished := FlagBits{}
+	unpublished.Scan(app.FlagsUnpublished)
 	var params = make([]interface{}, 0)
 
 	now := time.Now().UTC()
@@ -209,21 +224,42 @@ func saveItem(db *pg.DB, it app.Item) (app.Item, error) {
 		params = append(params, i.Flags)
 		params = append(params, now)
 		params = append(params, i.Key)
+		params = append(params, mask)
+		params = append(params, i.SubmittedAt)
+		params = append(params, unpublished)
 
+		// published items can't become drafts again, and only the unpublished ones can change their submission time
 		query = `UPDATE "items" SET "title" = ?0, "data" = ?1, "metadata" = ?2, "mime_type" = ?3,
-			"flags" = ?4::bit(8), "updated_at" = ?5 WHERE "key" ~* ?6;`
+			"flags" = (CASE WHEN "flags" & ?9::bit(8) = 0::bit(8) THEN ?4::bit(8) & ~?9::bit(8) ELSE ?4::bit(8) END) | ("flags" & ?7::bit(8)),
+			"submitted_at" = (CASE WHEN "flags" & ?9::bit(8) = 0::bit(8) THEN "submitted_at" ELSE ?8 END),
+			"updated_at" = ?5 WHERE "key" ~* ?6;`
 		hash = i.Key.Hash()
 	}
-	res, err = db.Query(i, query, params...)
-	if err != nil {
-		return it, &itemSaveError{err: errors.Annotate(err, "item save error"), item: i}
-	} else {
+	// the tags are saved together with the item, so the tag pages never miss an item or show a removed tag
+	err = db.RunInTransaction(func(tx *pg.Tx) error {
+		if res, err = tx.Query(i, query, params...); err != nil {
+			return &itemSaveError{err: errors.Annotate(err, "item save error"), item: i}
+		}
 		if rows := res.RowsAffected(); rows == 0 {
-			return it, &itemSaveError{err: errors.Errorf("could not save item %q", i.Key.Hash()), item: i}
+			return &itemSaveError{err: errors.Errorf("could not save item %q", i.Key.Hash()), item: i}
+		}
+		if err := saveItemTags(tx, i.Key, i.Metadata.Tags); err != nil {
+			return &itemSaveError{err: err, item: i}
 		}
+		return nil
+	})
+	if err != nil {
+		return it, err
 	}
 
-	col, err := loadItems(db, app.Filters{LoadItemsFilter: app.LoadItemsFilter{Key: app.Hashes{hash}}, MaxItems: 1})
+	f := app.Filters{
+		LoadItemsFilter: app.LoadItemsFilter{
+			Key:   app.Hashes{hash},
+			Draft: []bool{true, false},
+		},
+		MaxItems: 1,
+	}
+	col, err := loadItems(db, f)
 	if len(col) > 0 {
 		return col[0], nil
 	} else {
@@ -231,6 +267,113 @@ func saveItem(db *pg.DB, it app.Item) (app.Item, error) {
 	}
 }
 
+// threadFlags are the flags which apply to a whole thread, not only to the item they're set on
+const threadFlags = app.FlagsLocked | app.FlagsArchived
+
+// lockItem sets, or clears, the locked flag on the item with "it" hash and on all of its replies
+func lockItem(db *pg.DB, it app.Item, lock bool) error {
+	f := FlagBits{}
+	f.Scan(app.FlagsLocked)
+	flags := `"flags" & ~?1::bit(8)`
+	if lock {
+		flags = `"flags" | ?1::bit(8)`
+	}
+	query := fmt.Sprintf(`UPDATE "items" SET "flags" = %s WHERE "key" ~* ?0 OR "path" <@ (
+		SELECT (CASE WHEN "path" IS NOT NULL THEN concat("path", '.', "key") ELSE "key" END)::ltree FROM "items" WHERE "key" ~* ?0
+	);`, flags)
+	res, err := db.Exec(query, it.Hash, f)
+	if err != nil {
+		return errors.Annotatef(err, "DB query error")
+	}
+	if res.RowsAffected() == 0 {
+		return errors.NotFoundf("item %s", it.Hash)
+	}
+	return nil
+}
+
+// archiveItems sets the archived flag on the top level items submitted before "olderThan" and on all of their replies
+func archiveItems(db *pg.DB, olderThan time.Time) (int, error) {
+	f := FlagBits{}
+	f.Scan(app.FlagsArchived)
+	query := `UPDATE "items" SET "flags" = "flags" | ?1::bit(8) WHERE "flags" & ?1::bit(8) = B'00000000' AND (
+		("path" IS NULL AND "submitted_at" < ?0) OR
+		subpath("path", 0, 1)::varchar IN (SELECT "key" FROM "items" WHERE "path" IS NULL AND "submitted_at" < ?0)
+	);`
+	res, err := db.Exec(query, olderThan, f)
+	if err != nil {
+		return 0, errors.Annotatef(err, "DB query error")
+	}
+	return res.RowsAffected(), nil
+}
+
+// languagesTTL is the interval after which the languages of the instance are counted again
+const languagesTTL = 10 * time.Minute
+
+// instanceLanguages keeps the languages of the published items, so the instance information
+// doesn't group the whole items table on every request
+var instanceLanguages = struct {
+	sync.Mutex
+	languages []string
+	at        time.Time
+}{}
+
+// cachedLanguages returns the languages of the instance, calling "load" when they are older than languagesTTL
+func cachedLanguages(now time.Time, load func() ([]string, error)) ([]string, error) {
+	instanceLanguages.Lock()
+	defer instanceLanguages.Unlock()
+	if instanceLanguages.languages != nil && now.Sub(instanceLanguages.at) < languagesTTL {
+		return instanceLanguages.languages, nil
+	}
+	languages, err := load()
+	if err != nil {
+		return nil, err
+	}
+	instanceLanguages.languages = languages
+	instanceLanguages.at = now
+	return languages, nil
+}
+
+// loadLanguages returns the codes of the languages the published items are written in, the most used ones first
+func loadLanguages(db *pg.DB) ([]string, error) {
+	query := fmt.Sprintf(`SELECT "metadata"->>'language' AS "language" FROM "items"
+		WHERE "metadata"->>'language' IS NOT NULL AND "flags" & %d::bit(8) = 0::bit(8)
+		GROUP BY "language" ORDER BY COUNT("id") DESC, "language" ASC;`, app.FlagsDeleted|app.FlagsUnpublished)
+	res := make([]struct {
+		Language string `sql:"language"`
+	}, 0)
+	if _, err := db.Query(&res, query); err != nil {
+		return nil, errors.Annotatef(err, "DB query error")
+	}
+	languages := make([]string, len(res))
+	for k, r := range res {
+		languages[k] = r.Language
+	}
+	return languages, nil
+}
+
+// publishScheduledItems clears the scheduled flag of the items which have their submission time before "t"
+// It returns the items that got published.
+func publishScheduledItems(db *pg.DB, t time.Time) (app.ItemCollection, error) {
+	f := FlagBits{}
+	f.Scan(app.FlagsScheduled)
+	query := `UPDATE "items" SET "flags" = "flags" & ~?1::bit(8), "updated_at" = ?0
+		WHERE "flags" & ?1::bit(8) = ?1::bit(8) AND "submitted_at" <= ?0 RETURNING "key";`
+	published := make([]struct {
+		Key app.Key `sql:"key,size(32)"`
+	}, 0)
+	if _, err := db.Query(&published, query, t, f); err != nil {
+		return nil, errors.Annotatef(err, "DB query error")
+	}
+	if len(published) == 0 {
+		return app.ItemCollection{}, nil
+	}
+	hashes := make(app.Hashes, 0)
+	for _, p := range published {
+		hashes = append(hashes, p.Key.Hash())
+	}
+	return loadItems(db, app.Filters{LoadItemsFilter: app.LoadItemsFilter{Key: hashes}, MaxItems: len(hashes)})
+}
+
 type itemsView struct {
 	ItemID          int64               `sql:"item_id,"auto"`
 	ItemKey         app.Key             `sql:"item_key,size(32)"`
@@ -244,6 +387,9 @@ type itemsView struct {
 	ItemFlags       FlagBits            `sql:"item_flags"`
 	ItemMetadata    app.ItemMetadata    `sql:"item_metadata"`
 	Path            Path                `sql:"item_path"`
+	ItemReplies     int64               `sql:"item_replies"`
+	ItemUps         int64               `sql:"item_ups"`
+	ItemDowns       int64               `sql:"item_downs"`
 	AuthorID        int64               `sql:"author_id,auto"`
 	AuthorKey       app.Key             `sql:"author_key,size(32)"`
 	AuthorEmail     string              `sql:"author_email"`
@@ -284,12 +430,38 @@ func (i itemsView) item() Item {
 		Score:       i.ItemScore,
 		Flags:       i.ItemFlags,
 		Metadata:    i.ItemMetadata,
+		Replies:     i.ItemReplies,
+		Ups:         i.ItemUps,
+		Downs:       i.ItemDowns,
 		author:      &author,
 	}
 }
 
-func countItems(db *pg.DB, f app.Filters) (uint, error) {
+// isThreadFilter returns if the filter loads the comments of an item
+func isThreadFilter(f app.LoadItemsFilter) bool {
+	if len(f.InReplyTo) > 0 {
+		return true
+	}
+	for _, ctxt := range f.Context {
+		if ctxt != app.ContextNil && len(ctxt) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// itemsWhereClauses returns the conditions for loading the items matching "f"
+// The drafts and scheduled items are loaded only when explicitly requested.
+func itemsWhereClauses(f app.Filters) ([]string, []interface{}) {
 	wheres, whereValues := f.WithAuthorAlias("author").WithContentAlias("item").GetWhereClauses()
+	if len(f.Draft) == 0 {
+		wheres = append(wheres, fmt.Sprintf(`"item"."flags" & %d::bit(8) = 0::bit(8)`, app.FlagsUnpublished))
+	}
+	return wheres, whereValues
+}
+
+func countItems(db *pg.DB, f app.Filters) (uint, error) {
+	wheres, whereValues := itemsWhereClauses(f)
 	var fullWhere string
 
 	if len(wheres) == 0 {
@@ -311,7 +483,7 @@ func countItems(db *pg.DB, f app.Filters) (uint, error) {
 }
 
 func loadItems(db *pg.DB, f app.Filters) (app.ItemCollection, error) {
-	wheres, whereValues := f.WithAuthorAlias("author").WithContentAlias("item").GetWhereClauses()
+	wheres, whereValues := itemsWhereClauses(f)
 	var fullWhere string
 
 	if len(wheres) == 0 {
@@ -323,6 +495,11 @@ func loadItems(db *pg.DB, f app.Filters) (app.ItemCollection, error) {
 	}
 	// use hacker-news sort algorithm
 	// (votes - 1) / pow((item_hour_age+2), gravity)
+	orderBy := fmt.Sprintf(`(("item"."score" - 1) / ((extract(epoch from age(current_timestamp, "item"."submitted_at")) / 3600.00) ^ %f)) desc`, app.HNGravity)
+	if isThreadFilter(f.LoadItemsFilter) {
+		// threads are loaded breadth first, so the parent of every comment is part of the same page
+		orderBy = fmt.Sprintf(`nlevel("item"."path") asc, %s`, orderBy)
+	}
 	sel := fmt.Sprintf(`select 
 		"item"."id" as "item_id",
 		"item"."key" as "item_key",
@@ -335,6 +512,9 @@ func loadItems(db *pg.DB, f app.Filters) (app.ItemCollection, error) {
 		"item"."flags" as "item_flags",
 		"item"."metadata" as "item_metadata",
 		"item"."path" as "item_path",
+		coalesce("replies"."count", 0) as "item_replies",
+		(select count(*) from "votes" where "votes"."item_id" = "item"."id" and "votes"."weight" > 0) as "item_ups",
+		(select count(*) from "votes" where "votes"."item_id" = "item"."id" and "votes"."weight" < 0) as "item_downs",
 		"author"."id" as "author_id",
 		"author"."key" as "author_key",
 		"author"."handle" as "author_handle",
@@ -345,10 +525,11 @@ func loadItems(db *pg.DB, f app.Filters) (app.ItemCollection, error) {
 		"author"."flags" as "author_flags"
 		from "items" as "item"
 			left join "accounts" as "author" on "author"."id" = "item"."submitted_by" 
+			left join (select "path", count(*) as "count" from "items" where "path" is not null group by "path") as "replies" 
+				on "replies"."path" = (CASE WHEN "item"."path" IS NULL THEN "item"."key"::ltree 
+					ELSE ltree_addltree("item"."path", "item"."key"::ltree) END)
 		where %s 
-	order by 
-		(("item"."score" - 1) / ((extract(epoch from age(current_timestamp, "item"."submitted_at")) / 3600.00) ^ %f))
-	desc%s`, fullWhere, app.HNGravity, f.GetLimit())
+	order by %s%s`, fullWhere, orderBy, f.GetLimit())
 
 	agg := make([]itemsView, 0)
 	items := make(app.ItemCollection, 0)
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mariusor/littr.go/internal/errors"
+)
+
+func TestCachedLanguages(t *testing.T) {
+	now := time.Now()
+	loads := 0
+	load := func(l ...string) func() ([]string, error) {
+		return func() ([]string, error) {
+			loads++
+			return l, nil
+		}
+	}
+	instanceLanguages.languages = nil
+
+	if l, _ := cachedLanguages(now, load("en", "fr")); !reflect.DeepEqual(l, []string{"en", "fr"}) || loads != 1 {
+		t.Errorf("invalid languages %v after %d loads", l, loads)
+	}
+	if l, _ := cachedLanguages(now.Add(time.Minute), load("de")); !reflect.DeepEqual(l, []string{"en", "fr"}) || loads != 1 {
+		t.Errorf("the languages should have been cached, got %v after %d loads", l, loads)
+	}
+	failed := func() ([]string, error) { return nil, errors.Errorf("failed") }
+	if _, err := cachedLanguages(now.Add(languagesTTL), failed); err == nil {
+		t.Errorf("expected the error of the expired languages load")
+	}
+	if l, _ := cachedLanguages(now.Add(languagesTTL), load("de")); !reflect.DeepEqual(l, []string{"de"}) || loads != 2 {
+		t.Errorf("the expired languages should have been loaded again, got %v after %d loads", l, loads)
+	}
+}
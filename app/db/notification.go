@@ -0,0 +1,231 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+)
+
+type notificationsView struct {
+	ID              int64               `sql:"id,auto"`
+	Type            string              `sql:"type"`
+	CreatedAt       time.Time           `sql:"created_at"`
+	ReadAt          pg.NullTime         `sql:"read_at"`
+	ActorIRI        sql.NullString      `sql:"actor"`
+	ActorKey        app.Key             `sql:"actor_key,size(32)"`
+	ActorHandle     sql.NullString      `sql:"actor_handle"`
+	ActorMetadata   app.AccountMetadata `sql:"actor_metadata"`
+	ItemKey         app.Key             `sql:"item_key,size(32)"`
+	ItemTitle       sql.NullString      `sql:"item_title"`
+	ItemMimeType    sql.NullString      `sql:"item_mime_type"`
+	ItemData        sql.NullString      `sql:"item_data"`
+	ItemSubmittedAt pg.NullTime         `sql:"item_submitted_at"`
+	ItemMetadata    app.ItemMetadata    `sql:"item_metadata"`
+	ItemPath        Path                `sql:"item_path"`
+}
+
+func (n notificationsView) Model(a app.Account) app.Notification {
+	not := app.Notification{
+		Type:      app.NotificationType(n.Type),
+		For:       &a,
+		CreatedAt: n.CreatedAt,
+		ReadAt:    n.ReadAt.Time,
+	}
+	if n.ActorHandle.Valid {
+		m := n.ActorMetadata
+		not.Actor = &app.Account{
+			Hash:     n.ActorKey.Hash(),
+			Handle:   n.ActorHandle.String,
+			Metadata: &m,
+		}
+	} else if n.ActorIRI.Valid {
+		not.Actor = &app.Account{
+			Handle: n.ActorIRI.String,
+			Metadata: &app.AccountMetadata{
+				ID: n.ActorIRI.String,
+			},
+		}
+	}
+	if !n.ItemKey.IsEmpty() {
+		i := Item{
+			Key:         n.ItemKey,
+			Title:       n.ItemTitle,
+			MimeType:    n.ItemMimeType.String,
+			Data:        n.ItemData,
+			SubmittedAt: n.ItemSubmittedAt.Time,
+			Metadata:    n.ItemMetadata,
+			Path:        n.ItemPath,
+			author:      &Account{Key: n.ActorKey, Handle: n.ActorHandle.String, Metadata: n.ActorMetadata},
+		}
+		it := i.Model()
+		not.Item = &it
+	}
+	return not
+}
+
+func saveNotification(db *pg.DB, n app.Notification) (app.Notification, error) {
+	if n.For == nil || len(n.For.Hash) == 0 {
+		return n, errors.Errorf("invalid notification, missing account")
+	}
+	var actor, actorIRI, item interface{}
+	if n.Actor != nil {
+		if len(n.Actor.Hash) > 0 && n.Actor.IsLocal() {
+			// remote actors' hashes are not reliable, we match them by their IRI
+			actor = n.Actor.Hash
+		}
+		if n.Actor.HasMetadata() && len(n.Actor.Metadata.ID) > 0 {
+			actorIRI = n.Actor.Metadata.ID
+		}
+	}
+	if n.Item != nil && len(n.Item.Hash) > 0 {
+		item = n.Item.Hash
+	}
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now().UTC()
+	}
+
+	ins := `INSERT INTO "notifications" ("account_id", "type", "actor_id", "actor", "item_id", "created_at")
+	VALUES (
+		(SELECT "id" FROM "accounts" WHERE "key" ~* ?0),
+		?1,
+		(SELECT "id" FROM "accounts" WHERE "key" ~* ?2 OR "metadata"->>'id' = ?3 LIMIT 1),
+		?3,
+		(SELECT "id" FROM "items" WHERE "key" ~* ?4),
+		?5
+	) ON CONFLICT DO NOTHING;`
+	if _, err := db.Exec(ins, n.For.Hash, n.Type, actor, actorIRI, item, n.CreatedAt); err != nil {
+		return n, errors.Annotatef(err, "DB query error")
+	}
+	return n, nil
+}
+
+func mentionedHandle(t app.Tag) (string, bool) {
+	name := strings.TrimLeft(t.Name, "@~")
+	if len(name) == 0 {
+		return "", false
+	}
+	if len(t.URL) > 0 && strings.Contains(t.URL, "://") && !app.HostIsLocal(t.URL) {
+		return "", false
+	}
+	if at := strings.Index(name, "@"); at > 0 {
+		if host := name[at+1:]; host != app.Instance.HostName {
+			return "", false
+		}
+		name = name[:at]
+	}
+	return name, true
+}
+
+// saveItemNotifications adds the notifications for the author of the parent item and the
+// local accounts that have been mentioned in "it"
+func saveItemNotifications(db *pg.DB, it app.Item) error {
+	if it.SubmittedBy == nil {
+		return nil
+	}
+	if it.Parent != nil && len(it.Parent.Hash) > 0 {
+		ins := `INSERT INTO "notifications" ("account_id", "type", "actor_id", "item_id")
+		SELECT "parent"."submitted_by", ?1, "item"."submitted_by", "item"."id" FROM "items" AS "item"
+		INNER JOIN "items" AS "parent" ON "parent"."key" = ltree2text(subpath("item"."path", -1))
+		WHERE "item"."key" ~* ?0 AND "item"."path" IS NOT NULL AND "parent"."submitted_by" != "item"."submitted_by"
+		ON CONFLICT DO NOTHING;`
+		if _, err := db.Exec(ins, it.Hash, app.NotificationReply); err != nil {
+			return errors.Annotatef(err, "DB query error")
+		}
+	}
+	if it.Metadata == nil {
+		return nil
+	}
+	for _, men := range it.Metadata.Mentions {
+		handle, ok := mentionedHandle(men)
+		if !ok {
+			continue
+		}
+		accounts, err := loadAccounts(db, app.Filters{LoadAccountsFilter: app.LoadAccountsFilter{Handle: []string{handle}}})
+		if err != nil {
+			return err
+		}
+		for _, acc := range accounts {
+			if !acc.IsLocal() || acc.Hash == it.SubmittedBy.Hash {
+				continue
+			}
+			n := app.Notification{
+				Type:  app.NotificationMention,
+				For:   &acc,
+				Actor: it.SubmittedBy,
+				Item:  &it,
+			}
+			if _, err := saveNotification(db, n); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func loadNotifications(db *pg.DB, a app.Account, f app.Filters) (app.NotificationCollection, error) {
+	sel := fmt.Sprintf(`SELECT "n"."id", "n"."type", "n"."created_at", "n"."read_at", "n"."actor",
+		"actor"."key" AS "actor_key", "actor"."handle" AS "actor_handle", "actor"."metadata" AS "actor_metadata",
+		"item"."key" AS "item_key", "item"."title" AS "item_title", "item"."mime_type" AS "item_mime_type",
+		"item"."data" AS "item_data", "item"."submitted_at" AS "item_submitted_at", "item"."metadata" AS "item_metadata",
+		"item"."path" AS "item_path"
+	FROM "notifications" AS "n"
+		LEFT JOIN "accounts" AS "actor" ON "actor"."id" = "n"."actor_id"
+		LEFT JOIN "items" AS "item" ON "item"."id" = "n"."item_id"
+	WHERE "n"."account_id" = (SELECT "id" FROM "accounts" WHERE "key" ~* ?0)
+	ORDER BY "n"."created_at" DESC%s`, f.GetLimit())
+
+	agg := make([]notificationsView, 0)
+	notifications := make(app.NotificationCollection, 0)
+	if _, err := db.Query(&agg, sel, a.Hash); err != nil {
+		return notifications, errors.Annotatef(err, "DB query error")
+	}
+	for _, n := range agg {
+		notifications = append(notifications, n.Model(a))
+	}
+	return notifications, nil
+}
+
+func countNotifications(db *pg.DB, a app.Account, unread bool) (uint, error) {
+	sel := `SELECT COUNT(*) FROM "notifications" WHERE "account_id" = (SELECT "id" FROM "accounts" WHERE "key" ~* ?0)`
+	if unread {
+		sel += ` AND "read_at" IS NULL`
+	}
+	var count uint
+	if _, err := db.Query(&count, sel, a.Hash); err != nil {
+		return 0, errors.Annotatef(err, "DB query error")
+	}
+	return count, nil
+}
+
+func (c config) LoadNotifications(a app.Account, f app.Filters) (app.NotificationCollection, uint, error) {
+	var count uint
+	notifications, err := loadNotifications(c.DB, a, f)
+	if err == nil {
+		count, err = countNotifications(c.DB, a, false)
+	}
+	return notifications, count, err
+}
+
+func (c config) CountUnreadNotifications(a app.Account) (uint, error) {
+	return countNotifications(c.DB, a, true)
+}
+
+func (c config) SaveNotification(n app.Notification) (app.Notification, error) {
+	return saveNotification(c.DB, n)
+}
+
+func (c config) MarkNotificationsRead(a app.Account) error {
+	upd := `UPDATE "notifications" SET "read_at" = ?1
+	WHERE "account_id" = (SELECT "id" FROM "accounts" WHERE "key" ~* ?0) AND "read_at" IS NULL;`
+	if _, err := c.DB.Exec(upd, a.Hash, time.Now().UTC()); err != nil {
+		Logger.WithContext(log.Ctx{"account": a.Hash}).Error(err.Error())
+		return errors.Annotatef(err, "DB query error")
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/mariusor/littr.go/app"
+)
+
+func TestMentionedHandle(t *testing.T) {
+	app.Instance.HostName = "example.com"
+	app.Instance.APIURL = "https://example.com/api"
+
+	tests := []struct {
+		tag    app.Tag
+		handle string
+		local  bool
+	}{
+		{app.Tag{Name: "~jane", URL: "/~jane"}, "jane", true},
+		{app.Tag{Name: "@jane", URL: "https://example.com/~jane"}, "jane", true},
+		{app.Tag{Name: "@jane@example.com"}, "jane", true},
+		{app.Tag{Name: "@jane@remote.org"}, "", false},
+		{app.Tag{Name: "@jane", URL: "https://remote.org/users/jane"}, "", false},
+		{app.Tag{Name: "~"}, "", false},
+	}
+	for _, tt := range tests {
+		handle, local := mentionedHandle(tt.tag)
+		if handle != tt.handle || local != tt.local {
+			t.Errorf("invalid mention %q, %t for %v, expected %q, %t", handle, local, tt.tag, tt.handle, tt.local)
+		}
+	}
+}
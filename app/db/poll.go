@@ -0,0 +1,187 @@
+package db
+
+import (
+	"strings"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+)
+
+type pollChoice struct {
+	Choice string `sql:"choice"`
+	Count  int64  `sql:"count"`
+}
+
+// voterParams returns the values we use for identifying the account that voted in a poll:
+// local accounts are matched by their key, remote ones by their IRI
+func voterParams(a app.Account) (interface{}, string) {
+	var key interface{}
+	var iri string
+	if len(a.Hash) > 0 && a.IsLocal() {
+		key = a.Hash
+	}
+	if a.IsFederated() && a.HasMetadata() {
+		iri = a.Metadata.ID
+	}
+	return key, iri
+}
+
+func loadPollChoices(db orm.DB, a app.Account, it app.Item) ([]string, error) {
+	key, iri := voterParams(a)
+	sel := `SELECT "choice" FROM "poll_votes"
+	WHERE "item_id" = (SELECT "id" FROM "items" WHERE "key" ~* ?0)
+		AND ("account_id" = (SELECT "id" FROM "accounts" WHERE "key" ~* ?1) OR ("actor" != '' AND "actor" = ?2))`
+
+	agg := make([]pollChoice, 0)
+	choices := make([]string, 0)
+	if _, err := db.Query(&agg, sel, it.Hash, key, iri); err != nil {
+		return choices, errors.Annotatef(err, "DB query error")
+	}
+	for _, c := range agg {
+		choices = append(choices, c.Choice)
+	}
+	return choices, nil
+}
+
+// loadPollsChoices returns the options the "a" account chose in the polls with the "keys" keys, with a single query
+func loadPollsChoices(db orm.DB, a app.Account, keys []string) (map[app.Hash][]string, error) {
+	choices := make(map[app.Hash][]string)
+	if len(keys) == 0 {
+		return choices, nil
+	}
+	key, iri := voterParams(a)
+	sel := `SELECT "items"."key", "poll_votes"."choice" FROM "poll_votes"
+	INNER JOIN "items" ON "items"."id" = "poll_votes"."item_id"
+	WHERE "items"."key" IN (?0)
+		AND ("poll_votes"."account_id" = (SELECT "id" FROM "accounts" WHERE "key" ~* ?1) OR ("poll_votes"."actor" != '' AND "poll_votes"."actor" = ?2))`
+
+	var rows []struct {
+		Key    string
+		Choice string
+	}
+	if _, err := db.Query(&rows, sel, pg.In(keys), key, iri); err != nil {
+		return choices, errors.Annotatef(err, "DB query error")
+	}
+	for _, r := range rows {
+		h := app.Hash(strings.TrimSpace(r.Key))
+		choices[h] = append(choices[h], r.Choice)
+	}
+	return choices, nil
+}
+
+// updatePollResults counts the votes a local poll received and stores them in the item's metadata
+func updatePollResults(db orm.DB, it app.Item) error {
+	sel := `SELECT "choice", COUNT(*) AS "count" FROM "poll_votes"
+	WHERE "item_id" = (SELECT "id" FROM "items" WHERE "key" ~* ?0) GROUP BY "choice"`
+	agg := make([]pollChoice, 0)
+	if _, err := db.Query(&agg, sel, it.Hash); err != nil {
+		return errors.Annotatef(err, "DB query error")
+	}
+	poll := *it.Metadata.Poll
+	poll.Options = make(app.PollOptions, len(it.Metadata.Poll.Options))
+	for k, o := range it.Metadata.Poll.Options {
+		o.Count = 0
+		for _, c := range agg {
+			if c.Choice == o.Name {
+				o.Count = c.Count
+			}
+		}
+		poll.Options[k] = o
+	}
+	upd := `UPDATE "items" SET "metadata" = jsonb_set("metadata", '{poll}', ?1::jsonb) WHERE "key" ~* ?0;`
+	if _, err := db.Exec(upd, it.Hash, poll); err != nil {
+		return errors.Annotatef(err, "DB query error")
+	}
+	return nil
+}
+
+func savePollVote(db *pg.DB, v app.PollVote) error {
+	if v.Item == nil || v.SubmittedBy == nil {
+		return errors.Errorf("invalid poll vote, missing item or account")
+	}
+	items, err := loadItems(db, app.Filters{LoadItemsFilter: app.LoadItemsFilter{Key: app.Hashes{v.Item.Hash}}, MaxItems: 1})
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return errors.NotFoundf("poll %s", v.Item.Hash)
+	}
+	it := items[0]
+	if !it.IsPoll() {
+		return errors.NotValidf("item %s is not a poll", it.Hash)
+	}
+	if !it.IsLocal() {
+		// the answers to remote polls need to be delivered to the inbox of their author, which we can't do yet
+		return errors.NotImplementedf("voting in the remote poll %s is not supported", it.Hash)
+	}
+	poll := it.Metadata.Poll
+	if poll.IsClosed() {
+		return errors.Forbiddenf("poll %s is closed", it.Hash)
+	}
+	if len(v.Choices) == 0 {
+		return errors.NotValidf("missing choice")
+	}
+	if len(v.Choices) > 1 && !poll.Multiple {
+		return errors.NotValidf("only one choice allowed")
+	}
+	for _, c := range v.Choices {
+		if !poll.HasOption(c) {
+			return errors.NotValidf("invalid choice %q", c)
+		}
+	}
+	key, iri := voterParams(*v.SubmittedBy)
+	ins := `INSERT INTO "poll_votes" ("item_id", "account_id", "actor", "choice")
+	VALUES (
+		(SELECT "id" FROM "items" WHERE "key" ~* ?0),
+		(SELECT "id" FROM "accounts" WHERE "key" ~* ?1 OR ("metadata"->>'id' != '' AND "metadata"->>'id' = ?2) LIMIT 1),
+		?2,
+		?3
+	) ON CONFLICT DO NOTHING;`
+	return db.RunInTransaction(func(tx *pg.Tx) error {
+		// the poll stays locked until the vote is saved, so two votes of the same account can't both pass the check
+		if _, err := tx.Exec(`SELECT "id" FROM "items" WHERE "key" ~* ?0 FOR UPDATE`, it.Hash); err != nil {
+			return errors.Annotatef(err, "DB query error")
+		}
+		previous, err := loadPollChoices(tx, *v.SubmittedBy, it)
+		if err != nil {
+			return err
+		}
+		if len(previous) > 0 && !poll.Multiple {
+			return errors.Forbiddenf("already voted in poll %s", it.Hash)
+		}
+		for _, c := range v.Choices {
+			if _, err := tx.Exec(ins, it.Hash, key, iri, c); err != nil {
+				return errors.Annotatef(err, "DB query error")
+			}
+		}
+		return updatePollResults(tx, it)
+	})
+}
+
+func (c config) SavePollVote(v app.PollVote) error {
+	err := savePollVote(c.DB, v)
+	if err != nil {
+		ctx := log.Ctx{"choices": v.Choices}
+		if v.Item != nil {
+			ctx["item"] = v.Item.Hash
+		}
+		if v.SubmittedBy != nil {
+			ctx["account"] = v.SubmittedBy.Hash
+		}
+		Logger.WithContext(ctx).Error(err.Error())
+	}
+	return err
+}
+
+func (c config) LoadPollChoices(a app.Account, items app.ItemCollection) (map[app.Hash][]string, error) {
+	keys := make([]string, 0)
+	for _, it := range items {
+		if it.IsPoll() {
+			keys = append(keys, it.Hash.String())
+		}
+	}
+	return loadPollsChoices(c.DB, a, keys)
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/mariusor/littr.go/internal/errors"
+)
+
+// rateLimitCleanupEvery is the number of hits after which the expired windows are removed from the table
+const rateLimitCleanupEvery = 1000
+
+// RateLimitStore keeps the rate limit counters in the "rate_limits" table, so they are shared by all the
+// instances using the same database
+type RateLimitStore struct {
+	DB   *pg.DB
+	hits uint64
+}
+
+// Hit counts a new request for "key", starting a new window when the previous one ended
+func (s *RateLimitStore) Hit(key string, period time.Duration, now time.Time) (int, time.Time, error) {
+	if atomic.AddUint64(&s.hits, 1)%rateLimitCleanupEvery == 0 {
+		if _, err := s.DB.Exec(`DELETE FROM "rate_limits" WHERE "reset_at" <= ?0;`, now); err != nil {
+			Logger.Warn(err.Error())
+		}
+	}
+	upd := `INSERT INTO "rate_limits" ("key", "hits", "reset_at") VALUES (?0, 1, ?2)
+	ON CONFLICT ("key") DO UPDATE SET
+		"hits" = CASE WHEN "rate_limits"."reset_at" <= ?1 THEN 1 ELSE "rate_limits"."hits" + 1 END,
+		"reset_at" = CASE WHEN "rate_limits"."reset_at" <= ?1 THEN ?2 ELSE "rate_limits"."reset_at" END
+	RETURNING "hits", "reset_at";`
+
+	res := make([]struct {
+		Hits    int       `sql:"hits"`
+		ResetAt time.Time `sql:"reset_at"`
+	}, 0)
+	if _, err := s.DB.Query(&res, upd, key, now, now.Add(period)); err != nil {
+		return 0, now, errors.Annotatef(err, "DB query error")
+	}
+	if len(res) == 0 {
+		return 0, now, errors.Errorf("could not count the request for %s", key)
+	}
+	return res[0].Hits, res[0].ResetAt, nil
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"github.com/go-pg/pg"
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+)
+
+func saveBookmark(db *pg.DB, a app.Account, it app.Item) error {
+	ins := `INSERT INTO "saved_items" ("account_id", "item_id")
+	VALUES ((SELECT "id" FROM "accounts" WHERE "key" ~* ?0), (SELECT "id" FROM "items" WHERE "key" ~* ?1))
+	ON CONFLICT DO NOTHING;`
+	if _, err := db.Exec(ins, a.Hash, it.Hash); err != nil {
+		return errors.Annotatef(err, "DB query error")
+	}
+	return nil
+}
+
+func removeBookmark(db *pg.DB, a app.Account, it app.Item) error {
+	del := `DELETE FROM "saved_items"
+	WHERE "account_id" = (SELECT "id" FROM "accounts" WHERE "key" ~* ?0) AND "item_id" = (SELECT "id" FROM "items" WHERE "key" ~* ?1);`
+	if _, err := db.Exec(del, a.Hash, it.Hash); err != nil {
+		return errors.Annotatef(err, "DB query error")
+	}
+	return nil
+}
+
+func (c config) SaveBookmark(a app.Account, it app.Item) error {
+	if len(a.Hash) == 0 || len(it.Hash) == 0 {
+		return errors.Errorf("invalid account or item")
+	}
+	err := saveBookmark(c.DB, a, it)
+	if err != nil {
+		Logger.WithContext(log.Ctx{"account": a.Hash, "item": it.Hash}).Error(err.Error())
+	}
+	return err
+}
+
+func (c config) RemoveBookmark(a app.Account, it app.Item) error {
+	if len(a.Hash) == 0 || len(it.Hash) == 0 {
+		return errors.Errorf("invalid account or item")
+	}
+	err := removeBookmark(c.DB, a, it)
+	if err != nil {
+		Logger.WithContext(log.Ctx{"account": a.Hash, "item": it.Hash}).Error(err.Error())
+	}
+	return err
+}
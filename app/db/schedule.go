@@ -0,0 +1,30 @@
+package db
+
+import (
+	"time"
+)
+
+// ScheduleInterval is how often we check for scheduled items that need to be published
+const ScheduleInterval = time.Minute
+
+// RunScheduler publishes, every "interval", the scheduled items which reached their submission time.
+// It stops when the "stop" channel gets closed.
+func RunScheduler(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-t.C:
+			items, err := Config.PublishScheduledItems(now.UTC())
+			if err != nil {
+				Logger.Error(err.Error())
+				continue
+			}
+			if len(items) > 0 {
+				Logger.Infof("Published %d scheduled items", len(items))
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/internal/errors"
+)
+
+// sessionTouchInterval is the duration for which the last use of a session is not updated again
+const sessionTouchInterval = time.Minute
+
+type sessionView struct {
+	ID        string         `sql:"id"`
+	IP        sql.NullString `sql:"ip"`
+	UserAgent sql.NullString `sql:"user_agent"`
+	CreatedAt time.Time      `sql:"created_at"`
+	LastSeen  time.Time      `sql:"last_seen"`
+}
+
+func (s sessionView) Model() app.Session {
+	return app.Session{
+		ID:        s.ID,
+		IP:        s.IP.String,
+		UserAgent: s.UserAgent.String,
+		CreatedAt: s.CreatedAt,
+		LastSeen:  s.LastSeen,
+	}
+}
+
+func saveSession(db *pg.DB, s app.Session) (app.Session, error) {
+	if s.Account == nil || len(s.Account.Hash) == 0 {
+		return s, errors.Errorf("invalid session, missing account")
+	}
+	if len(s.ID) == 0 {
+		return s, errors.Errorf("invalid session, missing identifier")
+	}
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = time.Now().UTC()
+	}
+	s.LastSeen = s.CreatedAt
+	ins := `INSERT INTO "sessions" ("id", "account_id", "ip", "user_agent", "created_at", "last_seen")
+	VALUES (?0, (SELECT "id" FROM "accounts" WHERE "key" ~* ?1), ?2, ?3, ?4, ?4);`
+	if _, err := db.Exec(ins, s.ID, s.Account.Hash, s.IP, s.UserAgent, s.CreatedAt); err != nil {
+		return s, errors.Annotatef(err, "DB query error")
+	}
+	return s, nil
+}
+
+// touchSession checks the "id" session of the "a" account was not revoked, and saves the address of its last request.
+// The last use is updated at most once every sessionTouchInterval, so the requests don't all write to the database.
+func touchSession(db *pg.DB, a app.Account, id string, ip string) error {
+	sel := `SELECT "id", "ip", "last_seen" FROM "sessions"
+	WHERE "id" = ?0 AND "account_id" = (SELECT "id" FROM "accounts" WHERE "key" ~* ?1);`
+	agg := make([]sessionView, 0)
+	if _, err := db.Query(&agg, sel, id, a.Hash); err != nil {
+		return errors.Annotatef(err, "DB query error")
+	}
+	if len(agg) == 0 {
+		return errors.NotFoundf("session not found")
+	}
+	now := time.Now().UTC()
+	if agg[0].IP.String == ip && now.Sub(agg[0].LastSeen) < sessionTouchInterval {
+		return nil
+	}
+	upd := `UPDATE "sessions" SET "ip" = ?1, "last_seen" = ?2 WHERE "id" = ?0;`
+	if _, err := db.Exec(upd, id, ip, now); err != nil {
+		return errors.Annotatef(err, "DB query error")
+	}
+	return nil
+}
+
+func loadSessions(db *pg.DB, a app.Account) (app.SessionCollection, error) {
+	sel := `SELECT "id", "ip", "user_agent", "created_at", "last_seen" FROM "sessions"
+	WHERE "account_id" = (SELECT "id" FROM "accounts" WHERE "key" ~* ?0) ORDER BY "last_seen" DESC;`
+
+	agg := make([]sessionView, 0)
+	if _, err := db.Query(&agg, sel, a.Hash); err != nil {
+		return nil, errors.Annotatef(err, "DB query error")
+	}
+	sessions := make(app.SessionCollection, len(agg))
+	for k, s := range agg {
+		sessions[k] = s.Model()
+		sessions[k].Account = &a
+	}
+	return sessions, nil
+}
+
+// deleteSessions removes the "ids" sessions of the "a" account, or all of them when no identifier is passed
+func deleteSessions(db *pg.DB, a app.Account, ids ...string) error {
+	del := `DELETE FROM "sessions" WHERE "account_id" = (SELECT "id" FROM "accounts" WHERE "key" ~* ?0)`
+	params := []interface{}{a.Hash}
+	if len(ids) > 0 {
+		del += ` AND "id" IN (?1)`
+		params = append(params, pg.In(ids))
+	}
+	res, err := db.Exec(del, params...)
+	if err != nil {
+		return errors.Annotatef(err, "DB query error")
+	}
+	if len(ids) > 0 && res.RowsAffected() == 0 {
+		return errors.NotFoundf("session not found")
+	}
+	return nil
+}
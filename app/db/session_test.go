@@ -0,0 +1,69 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/go-pg/pg"
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/internal/errors"
+)
+
+func sessionIDs(t *testing.T, db *pg.DB, a app.Account) map[string]bool {
+	t.Helper()
+	sessions, err := loadSessions(db, a)
+	if err != nil {
+		t.Fatalf("unable to load the sessions of %s: %s", a.Handle, err)
+	}
+	ids := make(map[string]bool)
+	for _, s := range sessions {
+		ids[s.ID] = true
+	}
+	return ids
+}
+
+func TestDeleteSessions(t *testing.T) {
+	db, cleanup := testDB(t, "create-accounts", "create-sessions")
+	defer cleanup()
+	testAccounts(t, db, "jane", "john")
+
+	jane, john := testAccount("jane"), testAccount("john")
+	for _, s := range []app.Session{
+		{ID: "jane-1", Account: &jane},
+		{ID: "jane-2", Account: &jane},
+		{ID: "jane-3", Account: &jane},
+		{ID: "john-1", Account: &john},
+	} {
+		if _, err := saveSession(db, s); err != nil {
+			t.Fatalf("unable to save session %s: %s", s.ID, err)
+		}
+	}
+
+	if err := deleteSessions(db, jane, "jane-1"); err != nil {
+		t.Fatalf("unable to delete session: %s", err)
+	}
+	if ids := sessionIDs(t, db, jane); len(ids) != 2 || ids["jane-1"] || !ids["jane-2"] || !ids["jane-3"] {
+		t.Errorf("invalid sessions %v after deleting jane-1", ids)
+	}
+
+	// the sessions of the other accounts can't be deleted
+	if err := deleteSessions(db, jane, "john-1"); !errors.IsNotFound(err) {
+		t.Errorf("deleting the session of another account should fail with not found, got %v", err)
+	}
+	if err := deleteSessions(db, jane, "jane-1"); !errors.IsNotFound(err) {
+		t.Errorf("deleting a deleted session should fail with not found, got %v", err)
+	}
+
+	// without identifiers all the sessions of the account are deleted, which is the log out everywhere
+	if err := deleteSessions(db, jane); err != nil {
+		t.Fatalf("unable to delete the sessions: %s", err)
+	}
+	if ids := sessionIDs(t, db, jane); len(ids) != 0 {
+		t.Errorf("invalid sessions %v after deleting all of them", ids)
+	}
+	if err := deleteSessions(db, jane); err != nil {
+		t.Errorf("deleting all the sessions of an account without any should not fail: %s", err)
+	}
+	if ids := sessionIDs(t, db, john); len(ids) != 1 || !ids["john-1"] {
+		t.Errorf("the sessions %v of another account should not be deleted", ids)
+	}
+}
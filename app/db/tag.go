@@ -0,0 +1,92 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-pg/pg"
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/internal/errors"
+)
+
+type tagCount struct {
+	Name  string `sql:"name"`
+	Count int64  `sql:"count"`
+}
+
+// saveItemTags replaces the tags of the item with the "key" hash with the ones in the tags collection
+func saveItemTags(tx *pg.Tx, key app.Key, tags app.TagCollection) error {
+	delTags := `DELETE FROM "item_tags" WHERE "item_id" = (SELECT "id" FROM "items" WHERE "key" ~* ?0);`
+	if _, err := tx.Exec(delTags, key); err != nil {
+		return errors.Annotatef(err, "unable to remove tags for item %s", key.Hash())
+	}
+	for _, t := range tags {
+		name := app.NormalizeTagName(t.Name)
+		if len(name) == 0 {
+			continue
+		}
+		insTag := `INSERT INTO "tags" ("name") VALUES (?0) ON CONFLICT ("name") DO NOTHING;`
+		if _, err := tx.Exec(insTag, name); err != nil {
+			return errors.Annotatef(err, "unable to save tag %q", name)
+		}
+		insItemTag := `INSERT INTO "item_tags" ("item_id", "tag_id")
+		VALUES((SELECT "id" FROM "items" WHERE "key" ~* ?0), (SELECT "id" FROM "tags" WHERE "name" = ?1))
+		ON CONFLICT DO NOTHING;`
+		if _, err := tx.Exec(insItemTag, key, name); err != nil {
+			return errors.Annotatef(err, "unable to save tag %q for item %s", name, key.Hash())
+		}
+	}
+	return nil
+}
+
+func loadTags(db *pg.DB, f app.Filters) (app.TagCountCollection, error) {
+	wheres := []string{
+		`"item"."flags" & 1::bit(8) != 1::bit(8)`,
+		fmt.Sprintf(`"item"."flags" & %d::bit(8) = 0::bit(8)`, app.FlagsUnpublished),
+	}
+	whereValues := make([]interface{}, 0)
+	counter := 0
+	if len(f.LoadItemsFilter.Tag) > 0 {
+		tagWhere := make([]string, 0)
+		for _, tag := range f.LoadItemsFilter.Tag {
+			tagWhere = append(tagWhere, fmt.Sprintf(`"tags"."name" = ?%d`, counter))
+			whereValues = append(whereValues, interface{}(app.NormalizeTagName(tag)))
+			counter++
+		}
+		wheres = append(wheres, fmt.Sprintf("(%s)", strings.Join(tagWhere, " OR ")))
+	}
+	if !f.LoadItemsFilter.SubmittedAt.IsZero() {
+		op := ">="
+		if f.LoadItemsFilter.SubmittedAtMatchType == app.MatchBefore {
+			op = "<"
+		}
+		wheres = append(wheres, fmt.Sprintf(`"item"."submitted_at" %s ?%d`, op, counter))
+		whereValues = append(whereValues, interface{}(f.LoadItemsFilter.SubmittedAt))
+		counter++
+	}
+
+	sel := fmt.Sprintf(`SELECT "tags"."name" AS "name", COUNT("item"."id") AS "count" FROM "tags"
+		INNER JOIN "item_tags" ON "item_tags"."tag_id" = "tags"."id"
+		INNER JOIN "items" AS "item" ON "item"."id" = "item_tags"."item_id"
+	WHERE %s
+	GROUP BY "tags"."name" ORDER BY "count" DESC, "tags"."name" ASC%s`, strings.Join(wheres, " AND "), f.GetLimit())
+
+	agg := make([]tagCount, 0)
+	tags := make(app.TagCountCollection, 0)
+	if _, err := db.Query(&agg, sel, whereValues...); err != nil {
+		return tags, errors.Annotatef(err, "DB query error")
+	}
+	for _, t := range agg {
+		tags = append(tags, app.TagCount{
+			Tag: app.Tag{
+				Name: "#" + t.Name,
+			},
+			Count: t.Count,
+		})
+	}
+	return tags, nil
+}
+
+func (c config) LoadTags(f app.Filters) (app.TagCountCollection, error) {
+	return loadTags(c.DB, f)
+}
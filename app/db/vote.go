@@ -35,8 +35,8 @@ func (v Vote) Item() *Item {
 
 func VoteFlags(f FlagBits) app.FlagBits {
 	var ab uint8
-	for _, b := range f {
-		ab = ab | uint8(b)
+	for j, b := range f {
+		ab = ab | uint8(b&1)<<uint(len(f)-j-1)
 	}
 	return app.FlagBits(ab)
 }
@@ -37,6 +37,8 @@ type sessionAccount struct {
 	Hash   []byte
 	Handle string
 	OAuth  app.OAuth
+	// Session is the identifier of the server side session, which can be revoked from the sessions page
+	Session string
 }
 
 // ShowAccount serves /~handler request
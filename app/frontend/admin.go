@@ -0,0 +1,100 @@
+package frontend
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/app/db"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+)
+
+type adminModel struct {
+	Title    string
+	Staff    app.AccountCollection
+	Roles    []app.Role
+	Settings []adminSetting
+}
+
+type adminSetting struct {
+	Name  string
+	Value string
+}
+
+// instanceSettings returns the instance configuration which is relevant for the admins, without the credentials
+func instanceSettings(c app.Config) []adminSetting {
+	settings := []adminSetting{
+		{"Environment", string(c.Env)},
+		{"Sessions", fmt.Sprintf("%t", c.SessionsEnabled)},
+		{"Voting", fmt.Sprintf("%t", c.VotingEnabled)},
+		{"Downvoting", fmt.Sprintf("%t", c.DownvotingEnabled)},
+		{"Registration", fmt.Sprintf("%t", c.UserCreatingEnabled)},
+		{"Invite only", fmt.Sprintf("%t", c.InviteOnly)},
+		{"Archive after", c.ArchiveAfter.String()},
+		{"Moderators", strings.Join(c.Moderators, ", ")},
+		{"Admins", strings.Join(c.Admins, ", ")},
+		{"Rate limit store", c.RateLimitStore},
+	}
+	for _, action := range []string{app.ActionSubmit, app.ActionComment, app.ActionVote, app.ActionRegister, app.ActionOutbox} {
+		settings = append(settings, adminSetting{fmt.Sprintf("Rate limit %s", action), c.RateLimits[action].String()})
+	}
+	return settings
+}
+
+// ShowAdmin serves GET /admin request
+// The instance settings come from the environment, so they are only shown here.
+func (h *handler) ShowAdmin(w http.ResponseWriter, r *http.Request) {
+	staff, err := db.Config.LoadAccountsByRole(app.RoleModerator, app.RoleAdmin)
+	if err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	m := adminModel{
+		Title:    "Instance administration",
+		Staff:    staff,
+		Roles:    app.Roles,
+		Settings: instanceSettings(app.Instance.Config),
+	}
+	h.RenderTemplate(r, w, "admin", m)
+}
+
+// HandleAdminRoles serves POST /admin/roles request
+func (h *handler) HandleAdminRoles(w http.ResponseWriter, r *http.Request) {
+	handle := strings.TrimLeft(strings.TrimSpace(r.PostFormValue("handle")), "~@")
+	role := app.Role(r.PostFormValue("role"))
+	if !app.ValidRole(role) {
+		h.HandleErrors(w, r, errors.BadRequestf("invalid role %q", role))
+		return
+	}
+	a, err := db.Config.LoadAccount(app.Filters{LoadAccountsFilter: app.LoadAccountsFilter{Handle: []string{handle}}})
+	if err != nil || !a.IsLocal() {
+		h.HandleErrors(w, r, errors.NotFoundf("local account %q", handle))
+		return
+	}
+	if a.Hash == h.account.Hash {
+		h.HandleErrors(w, r, errors.Forbiddenf("you can't change your own role"))
+		return
+	}
+	if err := db.Config.SetAccountRole(a, role); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"handle": a.Handle,
+			"admin":  h.account.Handle,
+			"role":   role,
+		}).Error(err.Error())
+		h.HandleErrors(w, r, errors.NewNotValid(err, "unable to change the role"))
+		return
+	}
+	h.logger.WithContext(log.Ctx{
+		"handle": a.Handle,
+		"admin":  h.account.Handle,
+		"role":   role,
+	}).Info("changed account role")
+	msg := fmt.Sprintf("%s is now a %s", a.Handle, role)
+	if a.GetRole() != role {
+		msg = fmt.Sprintf("%s, but the environment configuration overrides it with %s", msg, a.GetRole())
+	}
+	h.addFlashMessage(Success, r, msg)
+	h.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
@@ -0,0 +1,240 @@
+package frontend
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/gorilla/securecookie"
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/app/oauth"
+	"github.com/mariusor/littr.go/internal/log"
+	"github.com/openshift/osin"
+)
+
+// oobRedirectURI is the redirect URI of the applications which can't receive redirects, the code is shown to the user instead
+const oobRedirectURI = "urn:ietf:wg:oauth:2.0:oob"
+
+// clientRegistration is the body of the registration request, it accepts both the RFC 7591 and the Mastodon names
+type clientRegistration struct {
+	ClientName   string   `json:"client_name"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scope        string   `json:"scope"`
+	Scopes       string   `json:"scopes"`
+	ClientURI    string   `json:"client_uri"`
+	Website      string   `json:"website"`
+}
+
+// clientRegistrationResponse is the body of the successful registration response
+type clientRegistrationResponse struct {
+	ClientID              string   `json:"client_id"`
+	ClientSecret          string   `json:"client_secret"`
+	ClientIDIssuedAt      int64    `json:"client_id_issued_at"`
+	ClientSecretExpiresAt int64    `json:"client_secret_expires_at"`
+	ClientName            string   `json:"client_name"`
+	RedirectURIs          []string `json:"redirect_uris"`
+	Scope                 string   `json:"scope"`
+	ClientURI             string   `json:"client_uri,omitempty"`
+	// the Mastodon clients expect these
+	ID          string `json:"id"`
+	RedirectURI string `json:"redirect_uri"`
+}
+
+// writeRegistrationError outputs an error in the format of RFC 7591
+func writeRegistrationError(w http.ResponseWriter, code string, desc string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":             code,
+		"error_description": desc,
+	})
+}
+
+// loadClientRegistration loads the registration request from either a JSON or a form encoded body
+func loadClientRegistration(r *http.Request) (clientRegistration, error) {
+	reg := clientRegistration{}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		err := json.NewDecoder(r.Body).Decode(&reg)
+		return reg, err
+	}
+	if err := r.ParseForm(); err != nil {
+		return reg, err
+	}
+	reg.ClientName = r.PostFormValue("client_name")
+	for _, u := range r.PostForm["redirect_uris"] {
+		// Mastodon accepts more URIs separated by new lines
+		reg.RedirectURIs = append(reg.RedirectURIs, strings.Fields(u)...)
+	}
+	reg.Scope = r.PostFormValue("scope")
+	reg.Scopes = r.PostFormValue("scopes")
+	reg.ClientURI = r.PostFormValue("client_uri")
+	reg.Website = r.PostFormValue("website")
+	return reg, nil
+}
+
+// validRedirectURI returns if "u" can be used as the redirect URI of a client.
+// Besides the http(s) URLs and the out of band URN, the native applications can use their own reverse domain
+// schemes, like "com.example.app:/callback". The URIs need to be absolute and without fragments.
+func validRedirectURI(u string) bool {
+	if u == oobRedirectURI {
+		return true
+	}
+	p, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	if len(p.Scheme) == 0 || len(p.Fragment) > 0 || strings.ContainsAny(u, "\r\n") {
+		return false
+	}
+	switch strings.ToLower(p.Scheme) {
+	case "http", "https":
+		return len(p.Host) > 0
+	case "javascript", "data", "vbscript", "file", "urn":
+		return false
+	}
+	return strings.Contains(p.Scheme, ".")
+}
+
+// HandleClientRegistration serves POST /oauth/register request
+// It registers a third party application as an OAuth2 client, in the spirit of RFC 7591 and of the Mastodon /api/v1/apps end-point.
+func (h *handler) HandleClientRegistration(w http.ResponseWriter, r *http.Request) {
+	s, err := h.tokenStorage()
+	if err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	reg, err := loadClientRegistration(r)
+	if err != nil {
+		writeRegistrationError(w, "invalid_client_metadata", "unable to load the client metadata")
+		return
+	}
+	reg.ClientName = strings.TrimSpace(reg.ClientName)
+	if len(reg.ClientName) == 0 || len(reg.ClientName) > 128 {
+		writeRegistrationError(w, "invalid_client_metadata", "client_name is required and it can have at most 128 characters")
+		return
+	}
+	if len(reg.RedirectURIs) == 0 {
+		writeRegistrationError(w, "invalid_redirect_uri", "at least one redirect URI is required")
+		return
+	}
+	for _, u := range reg.RedirectURIs {
+		if !validRedirectURI(u) {
+			writeRegistrationError(w, "invalid_redirect_uri", fmt.Sprintf("invalid redirect URI %q", u))
+			return
+		}
+	}
+	requested := reg.Scope
+	if len(requested) == 0 {
+		requested = reg.Scopes
+	}
+	scopes := []app.Scope{app.ScopeRead}
+	if len(requested) > 0 {
+		scopes = app.ParseScopes(requested)
+		if len(scopes) != len(strings.Fields(requested)) {
+			writeRegistrationError(w, "invalid_client_metadata", fmt.Sprintf("invalid scope %q", requested))
+			return
+		}
+	}
+	website := reg.ClientURI
+	if len(website) == 0 {
+		website = reg.Website
+	}
+	if len(website) > 0 {
+		if u, err := url.Parse(website); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			writeRegistrationError(w, "invalid_client_metadata", fmt.Sprintf("invalid client URI %q", website))
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+	meta := oauth.ClientMetadata{
+		Name:      reg.ClientName,
+		Website:   website,
+		Scope:     app.JoinScopes(scopes),
+		CreatedAt: now,
+	}
+	extra, _ := json.Marshal(meta)
+	c := osin.DefaultClient{
+		Id:          hex.EncodeToString(securecookie.GenerateRandomKey(16)),
+		Secret:      hex.EncodeToString(securecookie.GenerateRandomKey(32)),
+		RedirectUri: strings.Join(reg.RedirectURIs, "\n"),
+		UserData:    json.RawMessage(extra),
+	}
+	if err := s.CreateClient(&c); err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	h.logger.WithContext(log.Ctx{
+		"id":   c.Id,
+		"name": meta.Name,
+	}).Info("registered OAuth2 client")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(clientRegistrationResponse{
+		ClientID:         c.Id,
+		ClientSecret:     c.Secret,
+		ClientIDIssuedAt: now.Unix(),
+		ClientName:       meta.Name,
+		RedirectURIs:     reg.RedirectURIs,
+		Scope:            meta.Scope,
+		ClientURI:        meta.Website,
+		ID:               c.Id,
+		RedirectURI:      c.RedirectUri,
+	})
+}
+
+type appsModel struct {
+	Title   string
+	Account app.Account
+	Apps    []oauth.AuthorizedClient
+}
+
+// ShowApps serves GET /~{handle}/apps request
+// It lists the third party applications the account authorized, the frontend itself and the personal access tokens are not included.
+func (h *handler) ShowApps(w http.ResponseWriter, r *http.Request) {
+	s, err := h.tokenStorage()
+	if err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	m := appsModel{Title: "Authorized applications", Account: h.account}
+	if m.Apps, err = s.LoadAuthorizedClients(h.account.Hash.String(), os.Getenv("OAUTH2_KEY")); err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	h.RenderTemplate(r, w, "apps", m)
+}
+
+// HandleAppRevoke serves POST /~{handle}/apps/{id}/revoke request
+// It removes all the tokens the application holds for the account.
+func (h *handler) HandleAppRevoke(w http.ResponseWriter, r *http.Request) {
+	acc := h.account
+	back := fmt.Sprintf("%s/apps", acc.GetLink())
+
+	id := chi.URLParam(r, "id")
+	if id == os.Getenv("OAUTH2_KEY") || id == oauth.PersonalTokenClient {
+		h.addFlashMessage(Error, r, "this application can't be revoked")
+		h.Redirect(w, r, back, http.StatusSeeOther)
+		return
+	}
+	s, err := h.tokenStorage()
+	if err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	if err := s.RevokeClient(acc.Hash.String(), id); err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	h.addFlashMessage(Success, r, "The application can no longer access your account")
+	h.Redirect(w, r, back, http.StatusSeeOther)
+}
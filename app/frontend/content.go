@@ -33,6 +33,7 @@ type comment struct {
 type contentModel struct {
 	Title    string
 	Content  comment
+	Sort     string
 	nextPage int
 	prevPage int
 }
@@ -45,6 +46,18 @@ func (c contentModel) PrevPage() int {
 	return c.prevPage
 }
 
+// MoreReplies returns the number of direct replies of the comment that were not loaded in the current page
+// The replies of the thread root are paged, so it has none.
+func (c comment) MoreReplies() int64 {
+	if c.Parent == nil {
+		return 0
+	}
+	if more := c.ReplyCount - int64(len(c.Children)); more > 0 {
+		return more
+	}
+	return 0
+}
+
 func loadComments(items []app.Item) comments {
 	var comments = make([]*comment, len(items))
 	for k, item := range items {
@@ -144,7 +157,8 @@ func addLevelComments(comments comments) {
 	}
 }
 
-func reparentComments(allComments []*comment) {
+// reparentComments builds the comment tree under the first element, ordering every level by the "order" sort
+func reparentComments(allComments []*comment, order string) {
 	parFn := func(t []*comment, cur comment) *comment {
 		for _, n := range t {
 			if cur.Item.Parent != nil && cur.Item.Parent.Hash == n.Hash {
@@ -171,6 +185,9 @@ func reparentComments(allComments []*comment) {
 			cur.Parent.Children = append(cur.Parent.Children, cur)
 		}
 	}
+	if len(allComments) > 0 {
+		allComments[0].Children.sortBy(order)
+	}
 }
 
 // ShowItem serves /~{handle}/{hash} request
@@ -206,7 +223,9 @@ func (h *handler) ShowItem(w http.ResponseWriter, r *http.Request) {
 		f.LoadItemsFilter.AttributedTo = app.Hashes{auth.Hash}
 	}
 	i, err := itemLoader.LoadItem(f)
-
+	if err != nil {
+		i, err = h.loadDraft(r, itemLoader, app.Hash(hash))
+	}
 	if err != nil {
 		h.logger.WithContext(log.Ctx{
 			"handle": handle,
@@ -244,33 +263,50 @@ func (h *handler) ShowItem(w http.ResponseWriter, r *http.Request) {
 	allComments := make(comments, 1)
 	allComments[0] = &m.Content
 
+	// the pages of the item are made of its direct replies, together with their sub-threads
 	filter := app.Filters{
-		LoadItemsFilter: app.LoadItemsFilter{
-			Depth: 10,
-		},
 		MaxItems: MaxContentItems,
 		Page:     1,
 	}
 	if err := qstring.Unmarshal(r.URL.Query(), &filter); err != nil {
 		h.logger.Debug("unable to load url parameters")
 	}
-	filter.Context = []string{m.Content.Hash.String()}
-	contentItems, _, err := itemLoader.LoadItems(filter)
-	if len(contentItems) >= filter.MaxItems {
-		m.nextPage = filter.Page + 1
-	}
-	if filter.Page > 1 {
-		m.prevPage = filter.Page - 1
-	}
+	filter.InReplyTo = []string{m.Content.Hash.String()}
+	contentItems, count, err := itemLoader.LoadItems(filter)
 	if err != nil {
 		h.logger.Error(err.Error())
 		h.HandleErrors(w, r, errors.NewNotFound(err, "" /*, errors.ErrorStack(err)*/))
 		return
 	}
+	if int(count) > filter.Page*filter.MaxItems {
+		m.nextPage = filter.Page + 1
+	}
+	if filter.Page > 1 {
+		m.prevPage = filter.Page - 1
+	}
 	allComments = append(allComments, loadComments(contentItems)...)
+	if len(contentItems) > 0 {
+		replies := make([]string, len(contentItems))
+		for k, it := range contentItems {
+			replies[k] = it.Hash.String()
+		}
+		threads := app.Filters{
+			LoadItemsFilter: app.LoadItemsFilter{
+				Context: replies,
+				Depth:   MaxCommentDepth - 1,
+			},
+			MaxItems: MaxThreadItems,
+		}
+		if threadItems, _, err := itemLoader.LoadItems(threads); err == nil {
+			allComments = append(allComments, loadComments(threadItems)...)
+		} else {
+			h.logger.Error(err.Error())
+		}
+	}
 
 	//replaceTags(allComments)
-	reparentComments(allComments)
+	m.Sort = commentSortFromRequest(r, h.account)
+	reparentComments(allComments, m.Sort)
 	addLevelComments(allComments)
 
 	if ok && h.account.IsLogged() {
@@ -289,6 +325,7 @@ func (h *handler) ShowItem(w http.ResponseWriter, r *http.Request) {
 		} else {
 			h.logger.Error("could not load vote repository from Context")
 		}
+		loadPollChoices(&h.account, allComments, h.logger)
 	}
 
 	if len(m.Title) > 0 {
@@ -314,7 +351,6 @@ func (h *handler) HandleSubmit(w http.ResponseWriter, r *http.Request) {
 		h.HandleErrors(w, r, errors.NewMethodNotAllowed(err, ""))
 		return
 	}
-
 	acc := h.account
 	auth, authOk := app.ContextAuthenticated(r.Context())
 	if authOk && acc.IsLogged() {
@@ -326,12 +362,50 @@ func (h *handler) HandleSubmit(w http.ResponseWriter, r *http.Request) {
 		h.HandleErrors(w, r, errors.Errorf("could not load item repository from Context"))
 		return
 	}
-	saveVote := true
+	// replies to locked or archived threads are not accepted
+	for _, parent := range []*app.Item{n.Parent, n.OP} {
+		if parent == nil || len(parent.Hash) == 0 {
+			continue
+		}
+		p, err := itemLoader.LoadItem(app.Filters{LoadItemsFilter: app.LoadItemsFilter{Key: app.Hashes{parent.Hash}}})
+		if err != nil {
+			continue
+		}
+		if err := p.ValidateInteraction(); err != nil {
+			h.HandleErrors(w, r, err)
+			return
+		}
+	}
+	if n.Metadata.Attachments, err = h.attachmentsFromRequest(r); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"prev": err,
+		}).Error("unable to store attachments")
+		h.HandleErrors(w, r, errors.NewNotValid(err, "unable to store attachments"))
+		return
+	}
+	// the vote of the author is added when the item gets published
+	saveVote := n.IsPublished()
 	if len(n.Hash) > 0 {
-		if p, err := itemLoader.LoadItem(app.Filters{LoadItemsFilter: app.LoadItemsFilter{Key: app.Hashes{n.Hash}}}); err == nil {
+		saveVote = false
+		p, err := itemLoader.LoadItem(app.Filters{LoadItemsFilter: app.LoadItemsFilter{Key: app.Hashes{n.Hash}}})
+		if err != nil {
+			p, err = h.loadDraft(r, itemLoader, n.Hash)
+		}
+		if err == nil {
+			if p.IsPublished() {
+				// published items can't go back to being drafts
+				n.Flags &^= app.FlagsUnpublished
+			}
+			saveVote = !p.IsPublished() && n.IsPublished()
 			n.Title = p.Title
+			if p.IsPoll() {
+				// the options of a poll can't be changed after it was submitted
+				n.Metadata.Poll = p.Metadata.Poll
+			}
+			if p.HasAttachments() {
+				n.Metadata.Attachments = append(p.Metadata.Attachments, n.Metadata.Attachments...)
+			}
 		}
-		saveVote = false
 	}
 
 	var itemSaver app.CanSaveItems
@@ -392,6 +466,9 @@ func (h *handler) HandleDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	p, err := itemLoader.LoadItem(app.Filters{LoadItemsFilter: app.LoadItemsFilter{Key: app.Hashes{app.Hash(hash)}}})
+	if err != nil {
+		p, err = h.loadDraft(r, itemLoader, app.Hash(hash))
+	}
 	if err != nil {
 		h.logger.Error(err.Error())
 		h.HandleErrors(w, r, errors.NewNotFound(err, "not found"))
@@ -460,6 +537,11 @@ func (h *handler) HandleVoting(w http.ResponseWriter, r *http.Request) {
 		multiplier = -1
 	}
 	url := ItemPermaLink(p)
+	if err := p.ValidateInteraction(); err != nil {
+		h.addFlashMessage(Error, r, err.Error())
+		h.Redirect(w, r, url, http.StatusFound)
+		return
+	}
 
 	acc := h.account
 	if acc.IsLogged() {
@@ -0,0 +1,29 @@
+package frontend
+
+import (
+	"testing"
+
+	"github.com/mariusor/littr.go/app"
+)
+
+func TestComment_MoreReplies(t *testing.T) {
+	root := &comment{Item: app.Item{Hash: "root", ReplyCount: 120}}
+	reply := &comment{Item: app.Item{Hash: "reply", ReplyCount: 3, Parent: &app.Item{Hash: "root"}}}
+	child := &comment{Item: app.Item{Hash: "child", Parent: &app.Item{Hash: "reply"}}}
+	deep := &comment{Item: app.Item{Hash: "deep", ReplyCount: 2, Parent: &app.Item{Hash: "child"}}}
+
+	reparentComments(comments{root, reply, child, deep}, "")
+
+	if m := root.MoreReplies(); m != 0 {
+		t.Errorf("the replies of the thread root are paged, got %d more", m)
+	}
+	if m := reply.MoreReplies(); m != 2 {
+		t.Errorf("invalid more replies %d, expected 2", m)
+	}
+	if m := child.MoreReplies(); m != 0 {
+		t.Errorf("invalid more replies %d, expected 0", m)
+	}
+	if m := deep.MoreReplies(); m != 2 {
+		t.Errorf("invalid more replies %d for the thread continuation, expected 2", m)
+	}
+}
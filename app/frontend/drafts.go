@@ -0,0 +1,89 @@
+package frontend
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/qstring"
+)
+
+// ScheduleTimeFormat is the format of the "publish-at" field of the submit form, as sent by a datetime-local input
+const ScheduleTimeFormat = "2006-01-02T15:04"
+
+func scheduleFmt(t time.Time) string {
+	return t.UTC().Format(ScheduleTimeFormat)
+}
+
+// unpublishedFromRequest sets the draft or scheduled flags on a new top level submission:
+// the "draft" button saves it as a draft, and a future "publish-at" time schedules it.
+func unpublishedFromRequest(r *http.Request, i *app.Item) {
+	if len(r.PostFormValue("draft")) > 0 {
+		i.Flags |= app.FlagsDraft
+		return
+	}
+	publishAt, err := time.Parse(ScheduleTimeFormat, r.PostFormValue("publish-at"))
+	if err != nil || !publishAt.After(time.Now().UTC()) {
+		return
+	}
+	i.Flags |= app.FlagsScheduled
+	i.SubmittedAt = publishAt.UTC()
+}
+
+// loadDraft loads the unpublished item with the "hash" key, if it belongs to the current account
+func (h *handler) loadDraft(r *http.Request, loader app.CanLoadItems, hash app.Hash) (app.Item, error) {
+	acc := h.account
+	if !acc.IsLogged() {
+		return app.Item{}, errors.NotFoundf("item %s", hash)
+	}
+	if auth, ok := app.ContextAuthenticated(r.Context()); ok {
+		auth.WithAccount(&acc)
+	}
+	return loader.LoadItem(app.Filters{
+		LoadItemsFilter: app.LoadItemsFilter{
+			Key:          app.Hashes{hash},
+			AttributedTo: app.Hashes{acc.Hash},
+			Draft:        []bool{true},
+		},
+	})
+}
+
+// ShowDrafts serves /~{handle}/drafts request
+func (h *handler) ShowDrafts(w http.ResponseWriter, r *http.Request) {
+	handle := chi.URLParam(r, "handle")
+	acc := h.account
+	if handle != acc.Handle {
+		h.HandleErrors(w, r, errors.Forbiddenf("drafts are available only to their author"))
+		return
+	}
+	if auth, ok := app.ContextAuthenticated(r.Context()); ok {
+		auth.WithAccount(&acc)
+	}
+
+	filter := app.Filters{
+		LoadItemsFilter: app.LoadItemsFilter{
+			AttributedTo: app.Hashes{acc.Hash},
+			Draft:        []bool{true},
+		},
+		MaxItems: MaxContentItems,
+		Page:     1,
+	}
+	if err := qstring.Unmarshal(r.URL.Query(), &filter); err != nil {
+		h.logger.Debug("unable to load url parameters")
+	}
+	if m, err := loadItems(r.Context(), filter, &h.account, h.logger); err == nil {
+		m.Title = "Drafts and scheduled items"
+
+		if len(m.Items) >= filter.MaxItems {
+			m.nextPage = filter.Page + 1
+		}
+		if filter.Page > 1 {
+			m.prevPage = filter.Page - 1
+		}
+		h.RenderTemplate(r, w, "listing", m)
+	} else {
+		h.HandleErrors(w, r, errors.NewNotValid(err, "unable to load items"))
+	}
+}
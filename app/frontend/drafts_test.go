@@ -0,0 +1,42 @@
+package frontend
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mariusor/littr.go/app"
+)
+
+func TestUnpublishedFromRequest(t *testing.T) {
+	later := time.Now().UTC().Add(48 * time.Hour).Truncate(time.Minute)
+	tests := []struct {
+		form      url.Values
+		draft     bool
+		scheduled bool
+	}{
+		{url.Values{}, false, false},
+		{url.Values{"draft": {"Save draft"}, "publish-at": {scheduleFmt(later)}}, true, false},
+		{url.Values{"publish-at": {scheduleFmt(later)}}, false, true},
+		{url.Values{"publish-at": {scheduleFmt(time.Now().UTC().Add(-time.Hour))}}, false, false},
+		{url.Values{"publish-at": {"tomorrow"}}, false, false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", "/submit", strings.NewReader(tt.form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		i := app.Item{}
+		unpublishedFromRequest(r, &i)
+		if i.Draft() != tt.draft || i.Scheduled() != tt.scheduled {
+			t.Errorf("invalid draft %t and scheduled %t for %v, expected %t and %t", i.Draft(), i.Scheduled(), tt.form, tt.draft, tt.scheduled)
+		}
+		if i.IsPublished() == (tt.draft || tt.scheduled) {
+			t.Errorf("invalid published state for %v", tt.form)
+		}
+		if tt.scheduled && !i.SubmittedAt.Equal(later) {
+			t.Errorf("invalid submission time %s, expected %s", i.SubmittedAt, later)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/gob"
+	"encoding/hex"
 	"fmt"
 	"github.com/gorilla/csrf"
 	"github.com/openshift/osin"
@@ -13,16 +14,22 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/mariusor/littr.go/app"
 	"github.com/mariusor/littr.go/app/db"
+	"github.com/mariusor/littr.go/app/identity"
+	"github.com/mariusor/littr.go/app/mail"
+	"github.com/mariusor/littr.go/app/media"
+	"github.com/mariusor/littr.go/app/ratelimit"
 	"github.com/unrolled/render"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
 
 	"github.com/go-chi/chi"
+	"github.com/gorilla/securecookie"
 	"github.com/gorilla/sessions"
 	"github.com/mariusor/littr.go/internal/errors"
 	"github.com/mariusor/littr.go/internal/log"
@@ -78,6 +85,11 @@ func icon(icon string, c ...string) template.HTML {
 	return template.HTML(buf)
 }
 
+// hasIcon checks if the icons.svg asset contains the "icon" symbol
+func hasIcon(icon string) bool {
+	return bytes.Contains(asset("icons.svg"), []byte(fmt.Sprintf(`id="icon-%s"`, icon)))
+}
+
 func asset(p string) []byte {
 	file := filepath.Clean(p)
 	fullPath := filepath.Join(assetsDir, file)
@@ -179,14 +191,22 @@ type Config struct {
 	HostName        string
 	Secure          bool
 	SessionKeys     [][]byte
+	TokenKey        []byte
+	TwoFactorKey    []byte
 	SessionsBackend string
 	Logger          log.Logger
 	OAuthServer     *osin.Server
+	Storage         media.Storage
+	MaxUploadSize   int64
+	Mailer          mail.Mailer
+	RateLimiter     ratelimit.Limiter
+	TrustProxy      bool
 }
 
 func Init(c Config) (handler, error) {
 	// frontend
 	gob.Register(sessionAccount{})
+	gob.Register(pendingLogin{})
 	gob.Register(flash{})
 
 	var err error
@@ -203,6 +223,12 @@ func Init(c Config) (handler, error) {
 	}
 
 	c.SessionKeys = loadEnvSessionKeys()
+	if c.TokenKey = []byte(os.Getenv("TOKEN_KEY")); len(c.TokenKey) == 0 && c.Logger != nil {
+		c.Logger.Warn("no TOKEN_KEY configured, unable to send password reset and email verification links")
+	}
+	if c.TwoFactorKey = []byte(os.Getenv("TOTP_KEY")); len(c.TwoFactorKey) == 0 && c.Logger != nil {
+		c.Logger.Warn("no TOTP_KEY configured, unable to enable two-factor authentication")
+	}
 	h.sstor, err = InitSessionStore(c)
 	h.conf = c
 	h.os = c.OAuthServer
@@ -318,10 +344,11 @@ type headerEl struct {
 	Auth      bool
 	Name      string
 	URL       string
+	Count     uint
 }
 
-func headerMenu(r *http.Request) []headerEl {
-	sections := []string{"self", "federated", "followed"}
+func headerMenu(r *http.Request, acc app.Account) []headerEl {
+	sections := []string{"self", "federated", "followed", "notifications"}
 	ret := make([]headerEl, 0)
 	for _, s := range sections {
 		el := headerEl{
@@ -339,6 +366,12 @@ func headerMenu(r *http.Request) []headerEl {
 		case "followed":
 			el.Icon = "star"
 			el.Auth = true
+		case "notifications":
+			el.Icon = "at"
+			el.Auth = true
+			if acc.IsLogged() {
+				el.Count = unreadNotifications(acc, time.Now())
+			}
 		}
 		ret = append(ret, el)
 	}
@@ -480,6 +513,7 @@ func (h *handler) RenderTemplate(r *http.Request, w http.ResponseWriter, name st
 			"ShowAccountHandle": ShowAccountHandle,
 			"ItemLocalLink":     ItemLocalLink,
 			"ItemPermaLink":     ItemPermaLink,
+			"TagLink":           TagLink,
 			"ParentLink":        parentLink,
 			"OPLink":            opLink,
 			"IsYay":             isYay,
@@ -488,20 +522,25 @@ func (h *handler) RenderTemplate(r *http.Request, w http.ResponseWriter, name st
 			"NumberFmt":         func(i int64) string { return numberFormat("%d", i) },
 			"TimeFmt":           relTimeFmt,
 			"ISOTimeFmt":        isoTimeFmt,
+			"ScheduleFmt":       scheduleFmt,
 			"ScoreClass":        scoreClass,
 			"YayLink":           yayLink,
 			"NayLink":           nayLink,
-			"PageLink":          pageLink,
+			"PageLink":          pageLink(r),
+			"CommentSorts":      func() []string { return CommentSorts },
 			"CanPaginate":       canPaginate,
 			"Config":            func() app.Config { return app.Instance.Config },
 			"Info":              func() app.Info { return nodeInfo },
 			"Name":              appName,
-			"Menu":              func() []headerEl { return headerMenu(r) },
+			"Menu":              func() []headerEl { return headerMenu(r, h.account) },
 			"icon":              icon,
+			"hasIcon":           hasIcon,
+			"deviceName":        deviceName,
 			"asset":             func(p string) template.HTML { return template.HTML(asset(p)) },
 			"req":               func() *http.Request { return r },
 			"sameBase":          sameBasePath,
 			"sameHash":          sameHash,
+			"inRange":           inRange,
 			"fmtPubKey":         fmtPubKey,
 			"pluralize":         func(s string, cnt int) string { return pluralize(float64(cnt), s) },
 			csrf.TemplateTag:    func() template.HTML { return csrf.TemplateField(r) },
@@ -539,12 +578,6 @@ func (h *handler) RenderTemplate(r *http.Request, w http.ResponseWriter, name st
 	return err
 }
 
-// HandleAdmin serves /admin request
-func (h handler) HandleAdmin(w http.ResponseWriter, _ *http.Request) {
-	w.WriteHeader(200)
-	w.Write([]byte("done!!!"))
-}
-
 // HandleCallback serves /auth/{provider}/callback request
 func (h *handler) HandleCallback(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
@@ -566,6 +599,10 @@ func (h *handler) HandleCallback(w http.ResponseWriter, r *http.Request) {
 		h.HandleErrors(w, r, errors.Forbiddenf("%s error: Empty authentication token", provider))
 		return
 	}
+	if strings.ToLower(provider) != "local" {
+		h.handleProviderCallback(w, r, provider, code, state)
+		return
+	}
 
 	conf := GetOauth2Config(provider, h.conf.BaseURL)
 	tok, err := conf.Exchange(r.Context(), code)
@@ -585,11 +622,13 @@ func (h *handler) HandleCallback(w http.ResponseWriter, r *http.Request) {
 	}
 
 	s, _ := h.sstor.Get(r, sessionName)
-	h.account = loadCurrentAccountFromSession(s, h.logger)
+	sa, _ := s.Values[SessionUserKey].(sessionAccount)
+	h.account = loadCurrentAccountFromSession(s, ratelimit.ClientIP(r, h.conf.TrustProxy), h.logger)
 	s.Values[SessionUserKey] = sessionAccount{
-		Handle: h.account.Handle,
-		Hash:   []byte(h.account.Hash),
-		OAuth:  oauth,
+		Handle:  h.account.Handle,
+		Hash:    []byte(h.account.Hash),
+		OAuth:   oauth,
+		Session: sa.Session,
 	}
 	if strings.ToLower(provider) != "local" {
 		h.addFlashMessage(Success, r, fmt.Sprintf("Login successful with %s", provider))
@@ -600,89 +639,58 @@ func (h *handler) HandleCallback(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetOauth2Config(provider string, localBaseURL string) oauth2.Config {
-	var config oauth2.Config
-	switch strings.ToLower(provider) {
-	case "github":
-		config = oauth2.Config{
-			ClientID:     os.Getenv("GITHUB_KEY"),
-			ClientSecret: os.Getenv("GITHUB_SECRET"),
-			Endpoint: oauth2.Endpoint{
-				AuthURL:  "https://github.com/login/oauth/authorize",
-				TokenURL: "https://github.com/login/oauth/access_token",
-			},
-		}
-	case "gitlab":
-		config = oauth2.Config{
-			ClientID:     os.Getenv("GITLAB_KEY"),
-			ClientSecret: os.Getenv("GITLAB_SECRET"),
-			Endpoint: oauth2.Endpoint{
-				AuthURL:  "https://gitlab.com/login/oauth/authorize",
-				TokenURL: "https://gitlab.com/login/oauth/access_token",
-			},
-		}
-	case "facebook":
-		config = oauth2.Config{
-			ClientID:     os.Getenv("FACEBOOK_KEY"),
-			ClientSecret: os.Getenv("FACEBOOK_SECRET"),
-			Endpoint: oauth2.Endpoint{
-				AuthURL:  "https://graph.facebook.com/oauth/authorize",
-				TokenURL: "https://graph.facebook.com/oauth/access_token",
-			},
-		}
-	case "google":
-		config = oauth2.Config{
-			ClientID:     os.Getenv("GOOGLE_KEY"),
-			ClientSecret: os.Getenv("GOOGLE_SECRET"),
-			Endpoint: oauth2.Endpoint{
-				AuthURL:  "https://accounts.google.com/o/oauth2/auth", // access_type=offline
-				TokenURL: "https://accounts.google.com/o/oauth2/token",
-			},
-		}
-	case "local":
-		config = oauth2.Config{
-			ClientID:     os.Getenv("OAUTH2_KEY"),
-			ClientSecret: os.Getenv("OAUTH2_SECRET"),
-			Endpoint: oauth2.Endpoint{
-				AuthURL:  fmt.Sprintf("%s/oauth/authorize", localBaseURL),
-				TokenURL: fmt.Sprintf("%s/oauth/token", localBaseURL),
-			},
-		}
-	default:
-		config = oauth2.Config{}
+	if strings.ToLower(provider) != "local" {
+		p, _ := identity.Load(provider, providerRedirectURL(provider, localBaseURL))
+		return p.Config
+	}
+	config := oauth2.Config{
+		ClientID:     os.Getenv("OAUTH2_KEY"),
+		ClientSecret: os.Getenv("OAUTH2_SECRET"),
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  fmt.Sprintf("%s/oauth/authorize", localBaseURL),
+			TokenURL: fmt.Sprintf("%s/oauth/token", localBaseURL),
+		},
 	}
 	url := os.Getenv("OAUTH2_URL")
 	if url == "" {
-		url = fmt.Sprintf("%s/auth/%s/callback", localBaseURL, provider)
+		url = providerRedirectURL(provider, localBaseURL)
 	}
 	config.RedirectURL = url
 	return config
 }
 
+// providerRedirectURL returns the callback URL the "provider" redirects back to after the authorization
+func providerRedirectURL(provider string, localBaseURL string) string {
+	return fmt.Sprintf("%s/auth/%s/callback", localBaseURL, strings.ToLower(provider))
+}
+
 // HandleAuth serves /auth/{provider} request
+// It sends the user to log in at the external provider, the state it sends along is checked in HandleCallback.
 func (h *handler) HandleAuth(w http.ResponseWriter, r *http.Request) {
 	provider := chi.URLParam(r, "provider")
 
 	indexUrl := "/"
-	if strings.ToLower(provider) != "local" && os.Getenv("OAUTH2_KEY") == "" {
+	if strings.ToLower(provider) == "local" {
+		config := GetOauth2Config(provider, h.conf.BaseURL)
+		h.Redirect(w, r, config.AuthCodeURL("state", oauth2.AccessTypeOnline), http.StatusFound)
+		return
+	}
+	p, err := identity.Load(provider, providerRedirectURL(provider, h.conf.BaseURL))
+	if err != nil {
 		h.logger.WithContext(log.Ctx{
 			"provider": provider,
-		}).Info("Provider has no credentials set")
-		h.Redirect(w, r, indexUrl, http.StatusPermanentRedirect)
+		}).Info(err.Error())
+		h.addFlashMessage(Error, r, "Missing oauth provider")
+		h.Redirect(w, r, indexUrl, http.StatusSeeOther)
 		return
 	}
-
-	// TODO(marius): generated _CSRF state value to check in h.HandleCallback
-	config := GetOauth2Config(provider, h.conf.BaseURL)
-	if len(config.ClientID) == 0 {
-		s, err := h.sstor.Get(r, sessionName)
-		if err != nil {
-			h.logger.Debugf(err.Error())
-		}
-		s.AddFlash("Missing oauth provider")
-		h.Redirect(w, r, indexUrl, http.StatusPermanentRedirect)
+	state := hex.EncodeToString(securecookie.GenerateRandomKey(16))
+	s, err := h.sstor.Get(r, sessionName)
+	if err != nil {
+		h.logger.Debugf(err.Error())
 	}
-	// redirURL := "http://brutalinks.git/oauth/authorize?access_type=online&client_id=eaca4839ddf16cb4a5c4ca126db8de5c&redirect_uri=http%3A%2F%2Fbrutalinks.git%2Fauth%2Flocal%2Fcallback&response_type=code&state=state"
-	h.Redirect(w, r, config.AuthCodeURL("state", oauth2.AccessTypeOnline), http.StatusFound)
+	s.Values[SessionOAuthStateKey] = state
+	h.Redirect(w, r, p.AuthCodeURL(state, oauth2.AccessTypeOnline), http.StatusFound)
 }
 
 func isInverted(r *http.Request) bool {
@@ -695,11 +703,21 @@ func isInverted(r *http.Request) bool {
 	return false
 }
 
-func loadCurrentAccountFromSession(s *sessions.Session, l log.Logger) app.Account {
+// loadCurrentAccountFromSession loads the account of the session, or the anonymous one when the session was revoked.
+// The "ip" address is saved as the last one the session was used from.
+func loadCurrentAccountFromSession(s *sessions.Session, ip string, l log.Logger) app.Account {
 	// load the current account from the session or setting it to anonymous
 	if raw, ok := s.Values[SessionUserKey]; ok {
 		if a, ok := raw.(sessionAccount); ok {
-			if acc, err := db.Config.LoadAccount(app.Filters{LoadAccountsFilter: app.LoadAccountsFilter{Handle: []string{a.Handle}}}); err == nil {
+			if acc, err := db.Config.LoadAccount(app.Filters{LoadAccountsFilter: app.LoadAccountsFilter{Handle: []string{a.Handle}}}); err == nil && !acc.Deleted() && !acc.Blocked() {
+				if err := db.Config.TouchSession(acc, a.Session, ip); err != nil {
+					l.WithContext(log.Ctx{
+						"handle": acc.Handle,
+						"hash":   acc.Hash.String(),
+					}).Info(err.Error())
+					delete(s.Values, SessionUserKey)
+					return defaultAccount
+				}
 				l.WithContext(log.Ctx{
 					"handle": acc.Handle,
 					"hash":   acc.Hash.String(),
@@ -749,7 +767,7 @@ func (h *handler) LoadSession(next http.Handler) http.Handler {
 						}
 					}
 				} else {
-					h.account = loadCurrentAccountFromSession(s, h.logger)
+					h.account = loadCurrentAccountFromSession(s, ratelimit.ClientIP(r, h.conf.TrustProxy), h.logger)
 				}
 			} else {
 				h.logger.Warn("missing session store, unable to load session")
@@ -789,6 +807,7 @@ func (h *handler) HandleAbout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	m.Desc.Description = f.Description
+	m.Desc.Lang = f.Languages
 
 	h.RenderTemplate(r, w, "about", m)
 }
@@ -821,6 +840,9 @@ func httpErrorResponse(e error) int {
 	if errors.IsNotValid(e) {
 		return http.StatusInternalServerError
 	}
+	if errors.IsTooManyRequests(e) {
+		return http.StatusTooManyRequests
+	}
 	return http.StatusInternalServerError
 }
 
@@ -851,6 +873,13 @@ func (h *handler) HandleErrors(w http.ResponseWriter, r *http.Request, errs ...e
 	if r.Method == http.MethodPost {
 		renderErrors = false
 	}
+	for _, err := range errs {
+		// the clients need the status and the Retry-After header of the requests over their rate limit
+		if retry, ok := errors.RetryAfter(err); ok {
+			renderErrors = true
+			w.Header().Set("Retry-After", strconv.Itoa(int(retry.Seconds())))
+		}
+	}
 
 	status := http.StatusInternalServerError
 	for _, err := range errs {
@@ -0,0 +1,220 @@
+package frontend
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/app/db"
+	"github.com/mariusor/littr.go/app/identity"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+)
+
+// SessionOAuthStateKey holds the state sent to the external provider, until it redirects back
+const SessionOAuthStateKey = "__oauth_state"
+
+// maxHandleSuffix is the number of variations of a provider handle which are tried when it's already taken
+const maxHandleSuffix = 100
+
+type identitiesModel struct {
+	Title      string
+	Account    app.Account
+	Identities app.IdentityCollection
+	Providers  map[string]string
+}
+
+// availableHandle returns "handle", or the first variation of it with a number appended, which is not used by another account
+func availableHandle(handle string) (string, error) {
+	for i := 1; i <= maxHandleSuffix; i++ {
+		h := handle
+		if i > 1 {
+			h = fmt.Sprintf("%s%d", handle, i)
+		}
+		_, err := db.Config.LoadAccount(app.Filters{LoadAccountsFilter: app.LoadAccountsFilter{Handle: []string{h}}})
+		if errors.IsNotFound(err) {
+			return h, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", errors.Forbiddenf("the %s handle is not available", handle)
+}
+
+// accountFromProfile creates a new local account for the "p" provider profile.
+// An existing account with the same email is not linked automatically, as the provider could be used to take it over,
+// its owner needs to log in and link the provider account from the settings.
+func (h *handler) accountFromProfile(p identity.Profile) (app.Account, error) {
+	if err := validateRegistration(); err != nil {
+		return app.Account{}, err
+	}
+	if app.Instance.Config.InviteOnly {
+		return app.Account{}, errors.Forbiddenf("registration needs an invite, register with one and link your %s account from the settings", p.Provider)
+	}
+	if len(p.Email) > 0 {
+		f := app.Filters{LoadAccountsFilter: app.LoadAccountsFilter{Email: []string{p.Email}}}
+		if accounts, _, err := db.Config.LoadAccounts(f); err == nil && len(accounts) > 0 {
+			return app.Account{}, errors.Forbiddenf("an account with the %s email exists, log in and link your %s account from its settings", p.Email, p.Provider)
+		}
+	}
+	handle, err := availableHandle(identity.SuggestHandle(p))
+	if err != nil {
+		return app.Account{}, err
+	}
+	now := time.Now().UTC()
+	a := app.Account{
+		Handle:    handle,
+		Email:     p.Email,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Metadata: &app.AccountMetadata{
+			Provider: p.Provider,
+			Name:     p.Name,
+		},
+	}
+	if !p.EmailVerified {
+		a.Flags |= app.FlagsUnverified
+	}
+	return db.Config.SaveAccount(a)
+}
+
+// handleProviderCallback finishes the login with an external provider.
+// When an account is logged in, the provider account is linked to it, otherwise the linked account is logged in,
+// or a new account is created when the provider account is not linked to any.
+func (h *handler) handleProviderCallback(w http.ResponseWriter, r *http.Request, provider string, code string, state string) {
+	s, _ := h.sstor.Get(r, sessionName)
+	expected, _ := s.Values[SessionOAuthStateKey].(string)
+	delete(s.Values, SessionOAuthStateKey)
+	if len(expected) == 0 || subtle.ConstantTimeCompare([]byte(expected), []byte(state)) != 1 {
+		h.HandleErrors(w, r, errors.Forbiddenf("%s error: invalid authentication state", provider))
+		return
+	}
+	p, err := identity.Load(provider, providerRedirectURL(provider, h.conf.BaseURL))
+	if err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	tok, err := p.Exchange(r.Context(), code)
+	if err != nil {
+		h.logger.WithContext(log.Ctx{"provider": p.Name}).Error(err.Error())
+		h.HandleErrors(w, r, errors.Forbiddenf("%s error: unable to log in", p.Label))
+		return
+	}
+	prof, err := p.FetchProfile(r.Context(), tok, expected)
+	if err != nil {
+		h.logger.WithContext(log.Ctx{"provider": p.Name}).Error(err.Error())
+		h.HandleErrors(w, r, errors.Forbiddenf("%s error: unable to load your profile", p.Label))
+		return
+	}
+	ident := app.Identity{
+		Provider:  prof.Provider,
+		Subject:   prof.ID,
+		Handle:    prof.Handle,
+		Email:     prof.Email,
+		LastLogin: time.Now().UTC(),
+	}
+
+	if h.account.IsLogged() {
+		back := fmt.Sprintf("%s/identities", h.account.GetLink())
+		acc := h.account
+		ident.Account = &acc
+		if _, err := db.Config.SaveIdentity(ident); err != nil {
+			h.logger.WithContext(log.Ctx{
+				"handle":   acc.Handle,
+				"provider": p.Name,
+			}).Warn(err.Error())
+			h.addFlashMessage(Error, r, fmt.Sprintf("Unable to link your %s account, it might be linked to a different account", p.Label))
+		} else {
+			h.addFlashMessage(Success, r, fmt.Sprintf("Your %s account was linked", p.Label))
+		}
+		h.Redirect(w, r, back, http.StatusSeeOther)
+		return
+	}
+
+	a, err := db.Config.LoadIdentityAccount(prof.Provider, prof.ID)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			h.HandleErrors(w, r, err)
+			return
+		}
+		if a, err = h.accountFromProfile(prof); err != nil {
+			h.logger.WithContext(log.Ctx{
+				"provider": p.Name,
+				"subject":  prof.ID,
+			}).Warn(err.Error())
+			h.addFlashMessage(Error, r, fmt.Sprintf("Login failed: %s", errors.HttpError(err).Message))
+			h.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		h.logger.WithContext(log.Ctx{
+			"handle":   a.Handle,
+			"provider": p.Name,
+		}).Info("created account from provider identity")
+		if len(a.Email) > 0 && !a.IsVerified() {
+			if err := h.sendVerification(a); err != nil {
+				h.logger.WithContext(log.Ctx{
+					"handle": a.Handle,
+				}).Error(err.Error())
+			}
+		}
+		h.addFlashMessage(Info, r, fmt.Sprintf("The %s account was created for your %s account", a.Handle, p.Label))
+	}
+	if a.Deleted() || a.Blocked() {
+		h.addFlashMessage(Error, r, "Login failed: the account is no longer active")
+		h.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+	ident.Account = &a
+	if _, err := db.Config.SaveIdentity(ident); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"handle":   a.Handle,
+			"provider": p.Name,
+		}).Error(err.Error())
+	}
+	h.continueLogin(w, r, a)
+}
+
+// ShowIdentities serves GET /~{handle}/identities request
+func (h *handler) ShowIdentities(w http.ResponseWriter, r *http.Request) {
+	m := identitiesModel{
+		Title:     "Linked accounts",
+		Account:   h.account,
+		Providers: identity.Available(),
+	}
+	var err error
+	if m.Identities, err = db.Config.LoadIdentities(h.account); err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	h.RenderTemplate(r, w, "identities", m)
+}
+
+// HandleIdentityUnlink serves POST /~{handle}/identities/unlink request
+// The last provider account can't be unlinked when the account has no password, as it couldn't log in anymore.
+func (h *handler) HandleIdentityUnlink(w http.ResponseWriter, r *http.Request) {
+	acc := h.account
+	back := fmt.Sprintf("%s/identities", acc.GetLink())
+
+	provider := r.PostFormValue("provider")
+	subject := r.PostFormValue("subject")
+	identities, err := db.Config.LoadIdentities(acc)
+	if err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	hasPassword := acc.Metadata != nil && len(acc.Metadata.Password) > 0
+	if !hasPassword && len(identities) <= 1 {
+		h.addFlashMessage(Error, r, "Set a password before unlinking the only account you can log in with")
+		h.Redirect(w, r, back, http.StatusSeeOther)
+		return
+	}
+	if err := db.Config.DeleteIdentity(acc, provider, subject); err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	h.addFlashMessage(Success, r, "The account was unlinked")
+	h.Redirect(w, r, back, http.StatusSeeOther)
+}
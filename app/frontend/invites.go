@@ -0,0 +1,169 @@
+package frontend
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/gorilla/securecookie"
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/app/db"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+)
+
+type invitesModel struct {
+	Title     string
+	Account   app.Account
+	Invites   app.InviteCollection
+	Invited   app.InvitationCollection
+	BaseURL   string
+	MaxUses   int
+	CanCreate bool
+}
+
+type inviteTreeModel struct {
+	Title     string
+	Account   app.Account
+	InvitedBy *app.Account
+	Invited   app.InvitationCollection
+}
+
+// newInviteCode returns a random code which is hard to guess, but still short enough to be typed
+func newInviteCode() string {
+	return hex.EncodeToString(securecookie.GenerateRandomKey(10))
+}
+
+// recentInvites returns the number of invites "a" created in the current quota period
+func recentInvites(a app.Account) (int, error) {
+	return db.Config.CountInvites(a, time.Now().UTC().Add(-app.InviteQuotaPeriod))
+}
+
+// ShowInvites serves GET /~{handle}/invites request
+func (h *handler) ShowInvites(w http.ResponseWriter, r *http.Request) {
+	m := invitesModel{
+		Title:   "Invites",
+		Account: h.account,
+		BaseURL: h.conf.BaseURL,
+		MaxUses: app.Instance.Config.InviteMaxUses,
+	}
+	var err error
+	if m.Invites, err = db.Config.LoadInvites(h.account); err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	if m.Invited, err = db.Config.LoadInviteTree(h.account); err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	if recent, err := recentInvites(h.account); err == nil {
+		m.CanCreate = h.account.ValidateCreateInvite(recent) == nil
+	}
+	h.RenderTemplate(r, w, "invites", m)
+}
+
+// HandleInvites serves POST /~{handle}/invites request
+// It creates a new invite, within the score and quota limits of the instance.
+func (h *handler) HandleInvites(w http.ResponseWriter, r *http.Request) {
+	acc := h.account
+	back := fmt.Sprintf("%s/invites", acc.GetLink())
+
+	recent, err := recentInvites(acc)
+	if err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	if err := acc.ValidateCreateInvite(recent); err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	uses := 1
+	if u, err := strconv.Atoi(r.PostFormValue("uses")); err == nil && u > 0 {
+		uses = u
+	}
+	if uses > app.Instance.Config.InviteMaxUses {
+		uses = app.Instance.Config.InviteMaxUses
+	}
+	now := time.Now().UTC()
+	inv := app.Invite{
+		Code:      newInviteCode(),
+		CreatedBy: &acc,
+		MaxUses:   uses,
+		CreatedAt: now,
+		ExpiresAt: now.Add(app.InviteTTL),
+	}
+	if _, err := db.Config.SaveInvite(inv); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"handle": acc.Handle,
+		}).Error(err.Error())
+		h.HandleErrors(w, r, errors.NewNotValid(err, "unable to create the invite"))
+		return
+	}
+	h.addFlashMessage(Success, r, fmt.Sprintf("Invite created, share the link %s/register?invite=%s", h.conf.BaseURL, inv.Code))
+	h.Redirect(w, r, back, http.StatusSeeOther)
+}
+
+// loadInviteTreeRoot loads the account of the invite tree from the handle in the URL
+func loadInviteTreeRoot(r *http.Request) (app.Account, error) {
+	handle := chi.URLParam(r, "handle")
+	a, err := db.Config.LoadAccount(app.Filters{LoadAccountsFilter: app.LoadAccountsFilter{Handle: []string{handle}}})
+	if err != nil {
+		return a, errors.NewNotFound(err, "not found")
+	}
+	return a, nil
+}
+
+// ShowInviteTree serves GET /~{handle}/invited request
+// It shows moderators who invited the account and the accounts invited by it, directly or not.
+func (h *handler) ShowInviteTree(w http.ResponseWriter, r *http.Request) {
+	a, err := loadInviteTreeRoot(r)
+	if err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	m := inviteTreeModel{Title: fmt.Sprintf("Invite tree of %s", a.Handle), Account: a}
+	if m.InvitedBy, err = db.Config.LoadInviter(a); err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	if m.Invited, err = db.Config.LoadInviteTree(a); err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	h.RenderTemplate(r, w, "invite-tree", m)
+}
+
+// HandleInviteTreePrune serves POST /~{handle}/invited/prune request
+// It blocks the account together with all the accounts from its invite tree.
+func (h *handler) HandleInviteTreePrune(w http.ResponseWriter, r *http.Request) {
+	a, err := loadInviteTreeRoot(r)
+	if err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	back := fmt.Sprintf("%s/invited", a.GetLink())
+	if a.Hash == h.account.Hash || a.IsModerator() {
+		h.addFlashMessage(Error, r, "moderator accounts can't be blocked")
+		h.Redirect(w, r, back, http.StatusSeeOther)
+		return
+	}
+	count, err := db.Config.PruneInviteTree(a)
+	if err != nil {
+		h.logger.WithContext(log.Ctx{
+			"handle":    a.Handle,
+			"moderator": h.account.Handle,
+		}).Error(err.Error())
+		h.addFlashMessage(Error, r, "unable to prune the invite tree")
+	} else {
+		h.logger.WithContext(log.Ctx{
+			"handle":    a.Handle,
+			"moderator": h.account.Handle,
+			"blocked":   count,
+		}).Info("pruned invite tree")
+		h.addFlashMessage(Success, r, fmt.Sprintf("%d accounts were blocked", count))
+	}
+	h.Redirect(w, r, back, http.StatusSeeOther)
+}
@@ -0,0 +1,99 @@
+package frontend
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/app/db"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+)
+
+type languagesModel struct {
+	Title     string
+	Preferred []string
+	Instance  []string
+}
+
+// parseLanguages returns the valid language codes from a comma or space separated list
+func parseLanguages(s string) []string {
+	languages := make([]string, 0)
+	for _, l := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool { return r == ',' || r == ' ' }) {
+		if !app.ValidLanguage(l) || l == app.LanguageUndetermined {
+			continue
+		}
+		dup := false
+		for _, ex := range languages {
+			if ex == l {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			languages = append(languages, l)
+		}
+	}
+	return languages
+}
+
+// languageFilter sets the language filter from the "lang" URL parameter, falling back to
+// the preferred languages of the logged account, together with the items we don't know the language of.
+// An URL parameter without any valid language code, eg: "?lang=all", disables the filter.
+func languageFilter(r *http.Request, acc app.Account, f *app.Filters) {
+	if _, ok := r.URL.Query()["lang"]; ok {
+		valid := make([]string, 0)
+		for _, l := range f.Language {
+			if l = strings.ToLower(l); app.ValidLanguage(l) {
+				valid = append(valid, l)
+			}
+		}
+		f.Language = valid
+		return
+	}
+	if acc.Metadata != nil && len(acc.Metadata.Languages) > 0 {
+		f.Language = append(append(make([]string, 0), acc.Metadata.Languages...), app.LanguageUndetermined)
+	}
+}
+
+// ShowLanguages serves GET /languages request
+func (h *handler) ShowLanguages(w http.ResponseWriter, r *http.Request) {
+	m := languagesModel{Title: "Preferred languages"}
+	if h.account.Metadata != nil {
+		m.Preferred = h.account.Metadata.Languages
+	}
+	if inf, err := db.Config.LoadInfo(); err == nil {
+		m.Instance = inf.Languages
+	} else {
+		h.logger.Error(err.Error())
+	}
+	h.RenderTemplate(r, w, "languages", m)
+}
+
+// HandleLanguages serves POST /languages request
+func (h *handler) HandleLanguages(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		h.HandleErrors(w, r, errors.NewBadRequest(err, "unable to parse the form"))
+		return
+	}
+	acc := h.account
+	// the metadata is shared with the other requests of the account, so it's changed on a copy
+	m := app.AccountMetadata{}
+	if acc.Metadata != nil {
+		m = *acc.Metadata
+	}
+	m.Languages = parseLanguages(r.PostFormValue("languages"))
+	acc.Metadata = &m
+	if _, err := db.Config.SaveAccount(acc); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"handle":    acc.Handle,
+			"languages": acc.Metadata.Languages,
+		}).Error(err.Error())
+		h.addFlashMessage(Error, r, "unable to save the preferred languages")
+	} else if len(acc.Metadata.Languages) == 0 {
+		h.addFlashMessage(Success, r, "showing submissions in all languages")
+	} else {
+		h.addFlashMessage(Success, r, "showing submissions in "+strings.Join(acc.Metadata.Languages, ", "))
+	}
+	h.Redirect(w, r, "/languages", http.StatusSeeOther)
+}
@@ -0,0 +1,23 @@
+package frontend
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLanguages(t *testing.T) {
+	tests := map[string][]string{
+		"":                 {},
+		"en":               {"en"},
+		"EN, fr":           {"en", "fr"},
+		"en fr,de":         {"en", "fr", "de"},
+		"en,,en , fr":      {"en", "fr"},
+		"english, fr, und": {"fr"},
+		"e1, ro":           {"ro"},
+	}
+	for s, exp := range tests {
+		if l := parseLanguages(s); !reflect.DeepEqual(l, exp) {
+			t.Errorf("invalid languages %v for %q, expected %v", l, s, exp)
+		}
+	}
+}
@@ -4,13 +4,14 @@ import (
 	"context"
 	"fmt"
 	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/app/identity"
 	"github.com/mariusor/littr.go/internal/log"
 	"github.com/mariusor/qstring"
 	"html/template"
 	"net/http"
-	"os"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/mariusor/littr.go/internal/errors"
@@ -18,6 +19,10 @@ import (
 
 const (
 	MaxContentItems = 50
+	// MaxCommentDepth is the number of comment levels shown below an item, deeper threads are continued on their own page
+	MaxCommentDepth = 6
+	// MaxThreadItems is the number of comments loaded for the sub-threads of the direct replies shown in a page
+	MaxThreadItems = 200
 )
 
 func isYay(v *app.Vote) bool {
@@ -38,22 +43,17 @@ type aboutModel struct {
 	Desc  app.Desc
 }
 
-func getAuthProviders() map[string]string {
-	p := make(map[string]string)
-	if os.Getenv("GITHUB_KEY") != "" {
-		p["github"] = "Github"
-	}
-	if os.Getenv("GITLAB_KEY") != "" {
-		p["gitlab"] = "Gitlab"
-	}
-	if os.Getenv("GOOGLE_KEY") != "" {
-		p["google"] = "Google"
-	}
-	if os.Getenv("FACEBOOK_KEY") != "" {
-		p["facebook"] = "Facebook"
-	}
+type tagsModel struct {
+	Title    string
+	Trending app.TagCountCollection
+	Tags     app.TagCountCollection
+}
+
+// TrendingTagsWindow is the period for which we count the tag usage when showing trending tags
+const TrendingTagsWindow = 7 * 24 * time.Hour
 
-	return p
+func getAuthProviders() map[string]string {
+	return identity.Available()
 }
 
 func parentLink(c app.Item) string {
@@ -120,6 +120,12 @@ func ItemLocalLink(i app.Item) string {
 	return fmt.Sprintf("%s/%s", AccountLocalLink(*i.SubmittedBy), i.Hash.Short())
 }
 
+// TagLink returns the local link to the listing of the items tagged with "t", from the tags index
+func TagLink(t app.TagCount) string {
+	// @todo(marius) :link_generation:
+	return fmt.Sprintf("/t/%s", app.NormalizeTagName(t.Name))
+}
+
 func scoreLink(i app.Item, dir string) string {
 	// @todo(marius) :link_generation:
 	return fmt.Sprintf("%s/%s", ItemPermaLink(i), dir)
@@ -138,11 +144,15 @@ func canPaginate(m interface{}) bool {
 	return ok
 }
 
-func pageLink(p int) template.HTML {
-	if p >= 1 {
-		return template.HTML(fmt.Sprintf("?page=%d", p))
-	} else {
-		return template.HTML("")
+// pageLink returns the link to the "p" page, keeping the other URL parameters of the current request
+func pageLink(r *http.Request) func(p int) template.HTML {
+	return func(p int) template.HTML {
+		if p < 1 {
+			return template.HTML("")
+		}
+		q := r.URL.Query()
+		q.Set("page", fmt.Sprintf("%d", p))
+		return template.HTML(fmt.Sprintf("?%s", q.Encode()))
 	}
 }
 
@@ -160,6 +170,7 @@ func (h *handler) HandleIndex(w http.ResponseWriter, r *http.Request) {
 	if err := qstring.Unmarshal(r.URL.Query(), &filter); err != nil {
 		h.logger.Debug("unable to load url parameters")
 	}
+	languageFilter(r, h.account, &filter)
 
 	base := path.Base(r.URL.Path)
 	switch strings.ToLower(base) {
@@ -222,25 +233,42 @@ func loadItems(c context.Context, filter app.Filters, acc *app.Account, l log.Lo
 		} else {
 			l.Error("could not load vote repository from Context")
 		}
+		if auth, ok := app.ContextAuthenticated(c); ok {
+			// the saved items collection is private, so we need to be authorized to load it
+			auth.WithAccount(acc)
+		}
+		acc.Saved, _, err = itemLoader.LoadItems(app.Filters{
+			LoadItemsFilter: app.LoadItemsFilter{
+				SavedBy: []string{acc.Hash.String()},
+				Key:     m.Items.getItemsHashes(),
+			},
+			MaxItems: MaxContentItems,
+		})
+		if err != nil {
+			l.Error(err.Error())
+		}
+		loadPollChoices(acc, m.Items, l)
 	}
 	return m, nil
 }
 
-// HandleDomains serves /tags/{domain} request
+// HandleTags serves /t/{tag} request
 func (h *handler) HandleTags(w http.ResponseWriter, r *http.Request) {
-	tag := chi.URLParam(r, "tag")
+	tag := app.NormalizeTagName(chi.URLParam(r, "tag"))
 	filter := app.Filters{
 		MaxItems: MaxContentItems,
 		Page:     1,
 	}
 	if len(tag) == 0 {
 		h.HandleErrors(w, r, errors.BadRequestf("missing tag"))
+		return
 	}
-	filter.Content = "#" + tag
-	filter.ContentMatchType = app.MatchFuzzy
+	filter.Tag = []string{tag}
+	filter.Deleted = []bool{false}
 	if err := qstring.Unmarshal(r.URL.Query(), &filter); err != nil {
 		h.logger.Debug("unable to load url parameters")
 	}
+	languageFilter(r, h.account, &filter)
 	if m, err := loadItems(r.Context(), filter, &h.account, h.logger); err == nil {
 		m.Title = fmt.Sprintf("Submissions tagged as #%s", tag)
 
@@ -256,6 +284,35 @@ func (h *handler) HandleTags(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// HandleTagsIndex serves /t request
+func (h *handler) HandleTagsIndex(w http.ResponseWriter, r *http.Request) {
+	m := tagsModel{Title: "Tags"}
+
+	tagLoader, ok := app.ContextTagLoader(r.Context())
+	if !ok {
+		h.HandleErrors(w, r, errors.Errorf("could not load tag repository from Context"))
+		return
+	}
+	var err error
+	trending := app.Filters{
+		LoadItemsFilter: app.LoadItemsFilter{
+			SubmittedAt:          time.Now().UTC().Add(-TrendingTagsWindow),
+			SubmittedAtMatchType: app.MatchAfter,
+		},
+		MaxItems: 10,
+	}
+	if m.Trending, err = tagLoader.LoadTags(trending); err != nil {
+		h.HandleErrors(w, r, errors.NewNotValid(err, "oops!"))
+		return
+	}
+	if m.Tags, err = tagLoader.LoadTags(app.Filters{MaxItems: MaxContentItems * 4}); err != nil {
+		h.HandleErrors(w, r, errors.NewNotValid(err, "oops!"))
+		return
+	}
+
+	h.RenderTemplate(r, w, "tags", m)
+}
+
 // HandleDomains serves /domains/{domain} request
 func (h *handler) HandleDomains(w http.ResponseWriter, r *http.Request) {
 	domain := chi.URLParam(r, "domain")
@@ -277,6 +334,7 @@ func (h *handler) HandleDomains(w http.ResponseWriter, r *http.Request) {
 	if err := qstring.Unmarshal(r.URL.Query(), &filter); err != nil {
 		h.logger.Debug("unable to load url parameters")
 	}
+	languageFilter(r, h.account, &filter)
 	if m, err := loadItems(r.Context(), filter, &h.account, h.logger); err == nil {
 		m.Title = fmt.Sprintf("Submissions from %s", domain)
 
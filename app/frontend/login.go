@@ -1,13 +1,17 @@
 package frontend
 
 import (
+	"encoding/hex"
 	"fmt"
+	"github.com/gorilla/securecookie"
 	"github.com/mariusor/littr.go/app"
 	"github.com/mariusor/littr.go/app/db"
+	"github.com/mariusor/littr.go/app/ratelimit"
 	"github.com/mariusor/littr.go/internal/log"
 	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/oauth2"
 	"net/http"
+	"time"
 )
 
 const SessionUserKey = "__current_acct"
@@ -55,10 +59,60 @@ func (h *handler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if a.Blocked() {
+		h.logger.WithContext(log.Ctx{
+			"handle": handle,
+		}).Warn("blocked account tried to log in")
+		h.addFlashMessage(Error, r, "Login failed: your account was blocked by a moderator")
+		h.Redirect(w, r, backUrl, http.StatusSeeOther)
+		return
+	}
+
+	h.continueLogin(w, r, a)
+}
+
+// continueLogin asks for the second login factor when "a" has one, or logs it in
+func (h *handler) continueLogin(w http.ResponseWriter, r *http.Request, a app.Account) {
+	if a.HasTwoFactor() {
+		// the session is created only after the second step of the login
+		s, _ := h.sstor.Get(r, sessionName)
+		delete(s.Values, SessionUserKey)
+		s.Values[SessionTwoFactorKey] = pendingLogin{
+			Handle: a.Handle,
+			Hash:   []byte(a.Hash),
+			Since:  time.Now().UTC(),
+		}
+		if err := s.Save(r, w); err != nil {
+			h.logger.Error(err.Error())
+		}
+		h.Redirect(w, r, "/login/2fa", http.StatusSeeOther)
+		return
+	}
+	h.loginAccount(w, r, a)
+}
+
+// loginAccount saves the "a" account in the session and continues the login with the local OAuth2 authorization.
+// The session is also saved server side, so it can be listed and revoked from the sessions page.
+func (h *handler) loginAccount(w http.ResponseWriter, r *http.Request, a app.Account) {
+	sess, err := db.Config.SaveSession(app.Session{
+		ID:        hex.EncodeToString(securecookie.GenerateRandomKey(32)),
+		Account:   &a,
+		IP:        ratelimit.ClientIP(r, h.conf.TrustProxy),
+		UserAgent: r.UserAgent(),
+	})
+	if err != nil {
+		h.logger.WithContext(log.Ctx{
+			"handle": a.Handle,
+		}).Error(err.Error())
+		h.HandleErrors(w, r, err)
+		return
+	}
 	s, _ := h.sstor.Get(r, sessionName)
+	delete(s.Values, SessionTwoFactorKey)
 	s.Values[SessionUserKey] = sessionAccount{
-		Handle: a.Handle,
-		Hash:   []byte(a.Hash),
+		Handle:  a.Handle,
+		Hash:    []byte(a.Hash),
+		Session: sess.ID,
 	}
 	if err := s.Save(r, w); err != nil {
 		h.logger.Error(err.Error())
@@ -84,6 +138,11 @@ func (h *handler) HandleLogout(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		h.logger.Error(err.Error())
 	}
+	if sa, ok := s.Values[SessionUserKey].(sessionAccount); ok && h.account.IsLogged() {
+		if err := db.Config.DeleteSessions(h.account, sa.Session); err != nil {
+			h.logger.Warn(err.Error())
+		}
+	}
 	s.Values[SessionUserKey] = nil
 	backUrl := "/"
 	if r.Header.Get("Referer") != "" {
@@ -0,0 +1,81 @@
+package frontend
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+
+	"github.com/go-chi/chi"
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/app/media"
+	"github.com/mariusor/littr.go/internal/errors"
+)
+
+// maxFormSize is the size limit of the fields of a form, besides its files
+const maxFormSize = 1 << 20
+
+// LimitRequestBody limits the size of the request bodies to the largest upload they can contain,
+// so the multipart forms don't get read whole before their files are checked
+func (h *handler) LimitRequestBody(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		max := h.conf.MaxUploadSize
+		if max <= 0 {
+			max = media.DefaultMaxUploadSize
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, int64(app.MaxAttachments)*max+maxFormSize)
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// attachmentsFromRequest stores the images uploaded in the "attachments" field of a multipart submit form
+func (h *handler) attachmentsFromRequest(r *http.Request) (app.AttachmentCollection, error) {
+	if r.MultipartForm == nil || r.MultipartForm.File == nil {
+		return nil, nil
+	}
+	files := r.MultipartForm.File["attachments"]
+	if len(files) == 0 {
+		return nil, nil
+	}
+	if h.conf.Storage == nil {
+		return nil, errors.NotImplementedf("media uploads are not enabled")
+	}
+	if len(files) > app.MaxAttachments {
+		return nil, errors.NotValidf("too many files, the maximum is %d", app.MaxAttachments)
+	}
+	attachments := make(app.AttachmentCollection, 0)
+	for _, fh := range files {
+		f, err := fh.Open()
+		if err != nil {
+			return attachments, errors.Annotatef(err, "unable to read %s", fh.Filename)
+		}
+		att, err := media.Upload(h.conf.Storage, f, filepath.Base(fh.Filename), h.conf.MaxUploadSize)
+		f.Close()
+		if err != nil {
+			return attachments, err
+		}
+		attachments = append(attachments, att)
+	}
+	return attachments, nil
+}
+
+// ServeMedia serves /media/{path} requests
+func (h *handler) ServeMedia(w http.ResponseWriter, r *http.Request) {
+	if h.conf.Storage == nil {
+		h.HandleErrors(w, r, errors.NotFoundf("%q", r.RequestURI))
+		return
+	}
+	name := filepath.Base(filepath.Clean(chi.URLParam(r, "path")))
+	f, err := h.conf.Storage.Open(name)
+	if err != nil {
+		h.HandleErrors(w, r, errors.NewNotFound(err, "%q", r.RequestURI))
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", media.MimeTypeForExtension(filepath.Ext(name)))
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, f)
+}
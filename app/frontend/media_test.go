@@ -0,0 +1,34 @@
+package frontend
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mariusor/littr.go/app"
+)
+
+func TestLimitRequestBody(t *testing.T) {
+	h := handler{conf: Config{MaxUploadSize: 10}}
+	max := int(app.MaxAttachments*10 + maxFormSize)
+
+	var read int
+	var err error
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body []byte
+		body, err = ioutil.ReadAll(r.Body)
+		read = len(body)
+	})
+	for _, size := range []int{0, max, max + 1} {
+		r := httptest.NewRequest(http.MethodPost, "/submit", bytes.NewReader(make([]byte, size)))
+		h.LimitRequestBody(next).ServeHTTP(httptest.NewRecorder(), r)
+		if size <= max && (err != nil || read != size) {
+			t.Errorf("the body of %d bytes should be read whole, read %d: %v", size, read, err)
+		}
+		if size > max && err == nil {
+			t.Errorf("the body of %d bytes should not be read, the limit is %d", size, max)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package frontend
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/app/db"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+)
+
+const Lock = "lock"
+const Unlock = "unlock"
+
+// HandleLock serves POST /~{handle}/{hash}/lock and /~{handle}/{hash}/unlock requests
+func (h *handler) HandleLock(w http.ResponseWriter, r *http.Request) {
+	hash := chi.URLParam(r, "hash")
+
+	val := r.Context().Value(app.RepositoryCtxtKey)
+	itemLoader, ok := val.(app.CanLoadItems)
+	if !ok {
+		h.logger.Error("could not load item repository from Context")
+		return
+	}
+	p, err := itemLoader.LoadItem(app.Filters{LoadItemsFilter: app.LoadItemsFilter{Key: app.Hashes{app.Hash(hash)}}})
+	if err != nil {
+		h.logger.Error(err.Error())
+		h.HandleErrors(w, r, errors.NewNotFound(err, "not found"))
+		return
+	}
+
+	action := path.Base(r.URL.Path)
+	if err := db.Config.LockItem(p, action == Lock); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"hash":      p.Hash,
+			"moderator": h.account.Handle,
+			"action":    action,
+		}).Error(err.Error())
+		h.addFlashMessage(Error, r, fmt.Sprintf("unable to %s thread", action))
+	} else {
+		h.addFlashMessage(Success, r, fmt.Sprintf("thread %sed", action))
+	}
+	h.Redirect(w, r, ItemPermaLink(p), http.StatusSeeOther)
+}
+
+// HandleAccountBlock serves POST /~{handle}/block and /~{handle}/unblock requests
+// The blocked accounts can't log in, submit or vote.
+func (h *handler) HandleAccountBlock(w http.ResponseWriter, r *http.Request) {
+	handle := chi.URLParam(r, "handle")
+	a, err := db.Config.LoadAccount(app.Filters{LoadAccountsFilter: app.LoadAccountsFilter{Handle: []string{handle}}})
+	if err != nil {
+		h.HandleErrors(w, r, errors.NewNotFound(err, "not found"))
+		return
+	}
+	action := path.Base(r.URL.Path)
+	if a.Hash == h.account.Hash || a.IsModerator() {
+		h.addFlashMessage(Error, r, "moderator accounts can't be blocked")
+		h.Redirect(w, r, a.GetLink(), http.StatusSeeOther)
+		return
+	}
+	if action == "block" {
+		a.Block()
+	} else {
+		a.Unblock()
+	}
+	a.UpdatedAt = time.Now().UTC()
+	if _, err := db.Config.SaveAccount(a); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"handle":    a.Handle,
+			"moderator": h.account.Handle,
+			"action":    action,
+		}).Error(err.Error())
+		h.addFlashMessage(Error, r, fmt.Sprintf("unable to %s account", action))
+	} else {
+		h.logger.WithContext(log.Ctx{
+			"handle":    a.Handle,
+			"moderator": h.account.Handle,
+		}).Infof("account %sed", action)
+		h.addFlashMessage(Success, r, fmt.Sprintf("account %sed", action))
+	}
+	h.Redirect(w, r, a.GetLink(), http.StatusSeeOther)
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -122,21 +123,66 @@ func ContentFromRequest(r *http.Request, acc app.Account) (app.Item, error) {
 	if len(hash) > 0 {
 		i.Hash = app.Hash(hash)
 	}
+	if i.Parent == nil && len(i.Hash) == 0 {
+		i.Metadata.Poll = pollFromRequest(r)
+	}
+	if i.Parent == nil {
+		unpublishedFromRequest(r, &i)
+	}
 	return i, nil
 }
 
+// DefaultPollDuration is the time a poll accepts votes, if the submitter didn't choose one
+const DefaultPollDuration = 24 * time.Hour
+
+// pollFromRequest loads the poll options of a new submission, one per line, from the "poll-options" field.
+// It returns nil if there are less than two distinct options.
+func pollFromRequest(r *http.Request) *app.Poll {
+	options := make(app.PollOptions, 0)
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(r.PostFormValue("poll-options"), "\n") {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 || seen[name] || len(options) >= app.MaxPollOptions {
+			continue
+		}
+		seen[name] = true
+		options = append(options, app.PollOption{Name: name})
+	}
+	if len(options) < 2 {
+		return nil
+	}
+	duration := DefaultPollDuration
+	if days, err := strconv.Atoi(r.PostFormValue("poll-duration")); err == nil && days > 0 {
+		duration = time.Duration(days) * 24 * time.Hour
+	}
+	return &app.Poll{
+		Options:  options,
+		Multiple: len(r.PostFormValue("poll-multiple")) > 0,
+		EndTime:  time.Now().UTC().Add(duration),
+	}
+}
+
 // ShowSubmit serves GET /submit request
 func (h *handler) ShowSubmit(w http.ResponseWriter, r *http.Request) {
 	h.RenderTemplate(r, w, "new", contentModel{Title: "New submission"})
 }
 
-func (h *handler) ValidatePermissions(actions ...string) func(http.Handler) http.Handler {
-	if len(actions) == 0 {
+// ValidatePermissions lets through only the requests of the accounts which have all the "perms" permissions.
+// Without any permissions it falls back to allowing only the author of the current item.
+func (h *handler) ValidatePermissions(perms ...app.Permission) func(http.Handler) http.Handler {
+	if len(perms) == 0 {
 		return h.ValidateItemAuthor
 	}
-	// @todo(marius): implement permission logic
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
+			if !h.account.Can(perms...) {
+				h.logger.WithContext(log.Ctx{
+					"handle":      h.account.Handle,
+					"permissions": perms,
+				}).Warn("missing permissions")
+				h.HandleErrors(w, r, errors.Forbiddenf("you are not allowed to perform this action"))
+				return
+			}
 			next.ServeHTTP(w, r)
 		}
 		return http.HandlerFunc(fn)
@@ -163,6 +209,18 @@ func (h *handler) ValidateLoggedIn(eh app.ErrorHandler) app.Handler {
 }
 
 func (h *handler) ValidateItemAuthor(next http.Handler) http.Handler {
+	return h.ValidateItemAuthorOr()(next)
+}
+
+// ValidateItemAuthorOr lets through the requests of the author of the current item,
+// and of the accounts which have all the "perms" permissions, when there are any.
+func (h *handler) ValidateItemAuthorOr(perms ...app.Permission) app.Handler {
+	return func(next http.Handler) http.Handler {
+		return h.validateItemAuthor(next, perms...)
+	}
+}
+
+func (h *handler) validateItemAuthor(next http.Handler, perms ...app.Permission) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		acc := h.account
 		hash := chi.URLParam(r, "hash")
@@ -176,12 +234,16 @@ func (h *handler) ValidateItemAuthor(next http.Handler) http.Handler {
 				return
 			}
 			m, err := itemLoader.LoadItem(app.Filters{LoadItemsFilter: app.LoadItemsFilter{Key: app.Hashes{app.Hash(hash)}}})
+			if err != nil {
+				m, err = h.loadDraft(r, itemLoader, app.Hash(hash))
+			}
 			if err != nil {
 				h.logger.Error(err.Error())
 				h.HandleErrors(w, r, errors.NewNotFound(err, "item"))
 				return
 			}
-			if !sameHash(m.SubmittedBy.Hash, acc.Hash) {
+			allowed := len(perms) > 0 && acc.Can(perms...)
+			if !sameHash(m.SubmittedBy.Hash, acc.Hash) && !allowed {
 				url.Path = path.Dir(url.Path)
 				h.Redirect(w, r, url.RequestURI(), http.StatusTemporaryRedirect)
 				return
@@ -0,0 +1,115 @@
+package frontend
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/app/db"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/qstring"
+)
+
+type notificationsModel struct {
+	Title         string
+	User          *app.Account
+	Notifications app.NotificationCollection
+}
+
+// unreadTTL is the duration for which the number of unread notifications shown in the header is not counted again.
+// The counts are kept by every instance of the application, so a change made through another one shows up at most
+// after this interval.
+const unreadTTL = time.Minute
+
+// maxUnreadCounts is the number of accounts for which the unread notifications are kept at once
+const maxUnreadCounts = 10000
+
+type unreadCount struct {
+	count uint
+	at    time.Time
+}
+
+// unreadCounts keeps the number of unread notifications of the logged accounts, so the header menu
+// doesn't count them on every page render
+var unreadCounts = struct {
+	sync.Mutex
+	m    map[app.Hash]unreadCount
+	last time.Time
+}{m: make(map[app.Hash]unreadCount)}
+
+// pruneUnreadCounts removes the expired counts, at most once every unreadTTL.
+// The caller needs to hold the lock of unreadCounts.
+func pruneUnreadCounts(now time.Time) {
+	if now.Sub(unreadCounts.last) < unreadTTL && len(unreadCounts.m) < maxUnreadCounts {
+		return
+	}
+	for h, c := range unreadCounts.m {
+		if now.Sub(c.at) >= unreadTTL {
+			delete(unreadCounts.m, h)
+		}
+	}
+	unreadCounts.last = now
+}
+
+// setUnreadCount saves the "count" of unread notifications of the account with the "hash" key,
+// if the expired counts made room for it
+func setUnreadCount(hash app.Hash, count uint, now time.Time) {
+	unreadCounts.Lock()
+	defer unreadCounts.Unlock()
+	pruneUnreadCounts(now)
+	if _, ok := unreadCounts.m[hash]; !ok && len(unreadCounts.m) >= maxUnreadCounts {
+		return
+	}
+	unreadCounts.m[hash] = unreadCount{count: count, at: now}
+}
+
+// unreadNotifications returns the number of notifications the "acc" account didn't see yet
+func unreadNotifications(acc app.Account, now time.Time) uint {
+	unreadCounts.Lock()
+	c, ok := unreadCounts.m[acc.Hash]
+	unreadCounts.Unlock()
+	if ok && now.Sub(c.at) < unreadTTL {
+		return c.count
+	}
+	count, err := db.Config.CountUnreadNotifications(acc)
+	if err != nil {
+		return 0
+	}
+	setUnreadCount(acc.Hash, count, now)
+	return count
+}
+
+// clearUnreadNotifications resets the number of unread notifications of the "acc" account
+func clearUnreadNotifications(acc app.Account, now time.Time) {
+	setUnreadCount(acc.Hash, 0, now)
+}
+
+// ShowNotifications serves /notifications request
+func (h *handler) ShowNotifications(w http.ResponseWriter, r *http.Request) {
+	filter := app.Filters{
+		MaxItems: MaxContentItems,
+		Page:     1,
+	}
+	if err := qstring.Unmarshal(r.URL.Query(), &filter); err != nil {
+		h.logger.Debug("unable to load url parameters")
+	}
+	notifications, _, err := db.Config.LoadNotifications(h.account, filter)
+	if err != nil {
+		h.HandleErrors(w, r, errors.NewNotValid(err, "oops!"))
+		return
+	}
+	// the loaded notifications keep their unread state, so the template can still highlight them
+	if err := db.Config.MarkNotificationsRead(h.account); err != nil {
+		h.logger.Error(err.Error())
+	} else {
+		clearUnreadNotifications(h.account, time.Now())
+	}
+
+	m := notificationsModel{
+		Title:         "Notifications",
+		User:          &h.account,
+		Notifications: notifications,
+	}
+	h.RenderTemplate(r, w, "notifications", m)
+}
@@ -0,0 +1,52 @@
+package frontend
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mariusor/littr.go/app"
+)
+
+func TestUnreadNotifications(t *testing.T) {
+	now := time.Now()
+	acc := app.Account{Hash: app.Hash("dc6f5f5bf55bc1073715c98c69fa7ca8"), Handle: "jane"}
+	unreadCounts.m[acc.Hash] = unreadCount{count: 3, at: now.Add(-time.Second)}
+
+	if c := unreadNotifications(acc, now); c != 3 {
+		t.Errorf("invalid unread count %d, expected %d", c, 3)
+	}
+	clearUnreadNotifications(acc, now)
+	if c := unreadNotifications(acc, now); c != 0 {
+		t.Errorf("invalid unread count %d after reading the notifications, expected %d", c, 0)
+	}
+
+	old := app.Account{Hash: app.Hash("eacff9ddf379bd9fc8274c5a9f4cae08"), Handle: "anonymous"}
+	unreadCounts.m[old.Hash] = unreadCount{count: 1, at: now.Add(-2 * unreadTTL)}
+	clearUnreadNotifications(acc, now.Add(2*unreadTTL))
+	if _, ok := unreadCounts.m[old.Hash]; ok {
+		t.Errorf("the expired unread count should have been removed")
+	}
+}
+
+func TestSetUnreadCount(t *testing.T) {
+	now := time.Now()
+	unreadCounts.m = make(map[app.Hash]unreadCount)
+	for i := 0; i < maxUnreadCounts; i++ {
+		setUnreadCount(app.Hash(fmt.Sprintf("%032d", i)), 1, now)
+	}
+	extra := app.Hash("dc6f5f5bf55bc1073715c98c69fa7ca8")
+	setUnreadCount(extra, 1, now)
+	if _, ok := unreadCounts.m[extra]; ok || len(unreadCounts.m) != maxUnreadCounts {
+		t.Errorf("the unread counts should be limited to %d accounts, found %d", maxUnreadCounts, len(unreadCounts.m))
+	}
+	setUnreadCount(app.Hash(fmt.Sprintf("%032d", 0)), 2, now)
+	if c := unreadCounts.m[app.Hash(fmt.Sprintf("%032d", 0))]; c.count != 2 {
+		t.Errorf("the count of a known account should be updated, found %d", c.count)
+	}
+	setUnreadCount(extra, 1, now.Add(unreadTTL))
+	if _, ok := unreadCounts.m[extra]; !ok || len(unreadCounts.m) != 1 {
+		t.Errorf("the expired counts should have been removed, found %d", len(unreadCounts.m))
+	}
+	unreadCounts.m = make(map[app.Hash]unreadCount)
+}
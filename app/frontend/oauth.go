@@ -2,8 +2,14 @@ package frontend
 
 import (
 	"encoding/json"
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/app/db"
+	"github.com/mariusor/littr.go/app/oauth"
+	"github.com/mariusor/littr.go/app/ratelimit"
 	"github.com/openshift/osin"
 	"net/http"
+	"os"
+	"strings"
 )
 
 func redirectOrOutput(rs *osin.Response, w http.ResponseWriter, r *http.Request, h *handler) {
@@ -47,17 +53,43 @@ func (h *handler) Authorize(w http.ResponseWriter, r *http.Request) {
 	resp := s.NewResponse()
 	defer resp.Close()
 
-	if ar := s.HandleAuthorizeRequest(resp, r); ar != nil {
+	ar := s.HandleAuthorizeRequest(resp, r)
+	if ar != nil {
+		// the account is loaded from the session, which is created only after the second login factor was validated
 		if h.account.IsLogged() {
 			ar.Authorized = true
-			b, _ := json.Marshal(h.account)
-			ar.UserData = b
+			if ar.Client.GetId() != os.Getenv("OAUTH2_KEY") {
+				ar.Scope = grantedScope(ar.Scope, oauth.LoadClientMetadata(ar.Client.GetUserData()).Scope)
+				// the third party applications need the consent of the account
+				if r.Method != http.MethodPost {
+					h.showAuthorizeConsent(w, r, ar)
+					return
+				}
+				ar.Authorized = len(r.PostFormValue("allow")) > 0
+			}
+			ar.UserData = tokenUserData(h.account)
 		}
 		s.FinishAuthorizeRequest(resp, r, ar)
 	}
+	// the applications which can't receive redirects get the code from the account, which copies it from the page
+	if resp.Type == osin.REDIRECT && outOfBand(r, ar) {
+		h.RenderTemplate(r, w, "authorize-code", loadAuthorizeCode(resp))
+		return
+	}
 	redirectOrOutput(resp, w, r, h)
 }
 
+// outOfBand returns if the authorization request wants the code shown to the account instead of a redirect.
+// The redirect URL of the response can't be used for this, osin rebuilds the out of band URN into "urn:///".
+func outOfBand(r *http.Request, ar *osin.AuthorizeRequest) bool {
+	u := r.FormValue("redirect_uri")
+	if u == oobRedirectURI {
+		return true
+	}
+	// without a redirect_uri parameter osin falls back on the URI the client registered
+	return len(u) == 0 && ar != nil && ar.Client != nil && ar.Client.GetRedirectUri() == oobRedirectURI
+}
+
 func (h *handler) Token(w http.ResponseWriter, r *http.Request) {
 	s := h.os
 	resp := s.NewResponse()
@@ -65,13 +97,82 @@ func (h *handler) Token(w http.ResponseWriter, r *http.Request) {
 
 	if ar := s.HandleAccessRequest(resp, r); ar != nil {
 		if who, ok := ar.UserData.(json.RawMessage); ok {
-			if err := json.Unmarshal([]byte(who), &h.account); err == nil {
-				ar.Authorized = h.account.IsLogged()
-			} else {
+			acc := app.Account{}
+			if err := json.Unmarshal([]byte(who), &acc); err == nil && len(acc.Hash) > 0 {
+				// the account could have been blocked since the code or the refresh token were issued
+				acc, err = db.Config.LoadAccount(app.Filters{LoadAccountsFilter: app.LoadAccountsFilter{Key: app.Hashes{acc.Hash}}})
+				ar.Authorized = err == nil && acc.IsLogged() && !acc.Deleted() && !acc.Blocked()
+			} else if err != nil {
 				h.logger.Errorf("%s", err)
 			}
 		}
 		s.FinishAccessRequest(resp, r, ar)
+		// the token is shown on the sessions page of the account, with the client it was issued to
+		if tok, ok := resp.Output["access_token"].(string); ok && !resp.IsError {
+			if st, err := h.tokenStorage(); err == nil {
+				st.TouchAccess(tok, ratelimit.ClientIP(r, h.conf.TrustProxy), r.UserAgent())
+			}
+		}
 	}
 	redirectOrOutput(resp, w, r, h)
 }
+
+type authorizeModel struct {
+	Title  string
+	App    oauth.ClientMetadata
+	Scopes []app.Scope
+	Action string
+}
+
+type authorizeCodeModel struct {
+	Title string
+	Code  string
+	Error string
+}
+
+// loadAuthorizeCode loads the authorization code, or the error, of a response which would redirect to the out of band URI
+func loadAuthorizeCode(rs *osin.Response) authorizeCodeModel {
+	m := authorizeCodeModel{Title: "Authorization code"}
+	if rs.IsError {
+		m.Title = "Authorization failed"
+		m.Error, _ = rs.Output["error_description"].(string)
+		if len(m.Error) == 0 {
+			m.Error, _ = rs.Output["error"].(string)
+		}
+		return m
+	}
+	m.Code, _ = rs.Output["code"].(string)
+	return m
+}
+
+// showAuthorizeConsent asks the current account if the third party application of "ar" can act on its behalf
+func (h *handler) showAuthorizeConsent(w http.ResponseWriter, r *http.Request, ar *osin.AuthorizeRequest) {
+	m := authorizeModel{
+		Title:  "Authorize application",
+		App:    oauth.LoadClientMetadata(ar.Client.GetUserData()),
+		Scopes: app.ParseScopes(ar.Scope),
+		Action: "/oauth/authorize?" + r.URL.RawQuery,
+	}
+	if len(m.App.Name) == 0 {
+		m.App.Name = ar.Client.GetId()
+	}
+	h.RenderTemplate(r, w, "authorize", m)
+}
+
+// grantedScope returns the scopes from "requested" which the client registered for,
+// or all the registered ones when it didn't request any
+func grantedScope(requested string, registered string) string {
+	allowed := app.ParseScopes(registered)
+	if len(strings.TrimSpace(requested)) == 0 {
+		return app.JoinScopes(allowed)
+	}
+	granted := make([]app.Scope, 0)
+	for _, s := range app.ParseScopes(requested) {
+		for _, a := range allowed {
+			if s == a {
+				granted = append(granted, s)
+			}
+		}
+	}
+	return app.JoinScopes(granted)
+}
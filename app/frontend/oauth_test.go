@@ -0,0 +1,115 @@
+package frontend
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openshift/osin"
+)
+
+func TestGrantedScope(t *testing.T) {
+	tests := []struct {
+		requested  string
+		registered string
+		granted    string
+	}{
+		{"", "read write:items", "read write:items"},
+		{"read", "read write:items", "read"},
+		{"read write:items follow", "read write:items", "read write:items"},
+		{"admin", "read", ""},
+		{"read", "", ""},
+		{"read unknown", "read", "read"},
+	}
+	for _, tt := range tests {
+		if g := grantedScope(tt.requested, tt.registered); g != tt.granted {
+			t.Errorf("invalid scope granted for %q with %q registered: %q, expected %q", tt.requested, tt.registered, g, tt.granted)
+		}
+	}
+}
+
+type clientStorage struct {
+	osin.Storage
+	clients map[string]osin.Client
+}
+
+func (s clientStorage) Clone() osin.Storage { return s }
+func (s clientStorage) Close()              {}
+func (s clientStorage) GetClient(id string) (osin.Client, error) {
+	if c, ok := s.clients[id]; ok {
+		return c, nil
+	}
+	return nil, osin.ErrNotFound
+}
+func (s clientStorage) SaveAuthorize(*osin.AuthorizeData) error { return nil }
+
+func TestOutOfBand(t *testing.T) {
+	srv := osin.NewServer(osin.NewServerConfig(), clientStorage{clients: map[string]osin.Client{
+		"native": &osin.DefaultClient{Id: "native", RedirectUri: oobRedirectURI},
+		"web":    &osin.DefaultClient{Id: "web", RedirectUri: "https://example.com/callback"},
+	}})
+	tests := []struct {
+		query      string
+		authorized bool
+		oob        bool
+	}{
+		{"client_id=native&redirect_uri=urn:ietf:wg:oauth:2.0:oob", true, true},
+		{"client_id=native&redirect_uri=urn%3Aietf%3Awg%3Aoauth%3A2.0%3Aoob", true, true},
+		{"client_id=native", true, true},
+		{"client_id=native&redirect_uri=urn:ietf:wg:oauth:2.0:oob", false, true},
+		{"client_id=web&redirect_uri=https://example.com/callback", true, false},
+		{"client_id=web", true, false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/oauth/authorize?response_type=code&"+tt.query, nil)
+		rs := srv.NewResponse()
+		ar := srv.HandleAuthorizeRequest(rs, r)
+		if ar == nil {
+			t.Fatalf("authorization request %q failed: %v", tt.query, rs.Output)
+		}
+		ar.Authorized = tt.authorized
+		srv.FinishAuthorizeRequest(rs, r, ar)
+		if rs.Type != osin.REDIRECT {
+			t.Fatalf("invalid response type for %q: %v, expected a redirect", tt.query, rs.Type)
+		}
+		if oob := outOfBand(r, ar); oob != tt.oob {
+			t.Errorf("invalid out of band detection for %q: %t, expected %t", tt.query, oob, tt.oob)
+		}
+		if !tt.oob {
+			continue
+		}
+		m := loadAuthorizeCode(rs)
+		if tt.authorized && (len(m.Code) == 0 || len(m.Error) > 0) {
+			t.Errorf("invalid authorization code %v for %q, expected the code of the response", m, tt.query)
+		}
+		if !tt.authorized && (len(m.Code) > 0 || len(m.Error) == 0) {
+			t.Errorf("invalid authorization code %v for %q, expected the error of the response", m, tt.query)
+		}
+	}
+}
+
+func TestValidRedirectURI(t *testing.T) {
+	tests := map[string]bool{
+		oobRedirectURI:                      true,
+		"https://example.com/callback":      true,
+		"http://localhost:8080/cb":          true,
+		"com.example.app:/callback":         true,
+		"com.example.app://callback":        true,
+		"https:///callback":                 false,
+		"https://example.com/cb#fragment":   false,
+		"/callback":                         false,
+		"javascript:alert(1)":               false,
+		"JavaScript://example.com/%0aalert": false,
+		"data:text/html,<script></script>":  false,
+		"vbscript:msgbox":                   false,
+		"file:///etc/passwd":                false,
+		"urn:ietf:wg:oauth:2.0:other":       false,
+		"urn:example.com:cb":                false,
+		"myapp:/callback":                   false,
+		"https://example.com/\ncb":          false,
+	}
+	for u, valid := range tests {
+		if v := validRedirectURI(u); v != valid {
+			t.Errorf("invalid redirect URI validation for %q: %t, expected %t", u, v, valid)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package frontend
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/app/db"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+)
+
+// loadPollChoices loads the options the "acc" account chose in the polls found in "items"
+func loadPollChoices(acc *app.Account, items comments, l log.Logger) {
+	polls := make(app.ItemCollection, 0)
+	for _, it := range items {
+		if it.IsPoll() {
+			polls = append(polls, it.Item)
+		}
+	}
+	if len(polls) == 0 {
+		return
+	}
+	choices, err := db.Config.LoadPollChoices(*acc, polls)
+	if err != nil {
+		l.Error(err.Error())
+		return
+	}
+	if acc.Choices == nil {
+		acc.Choices = make(map[app.Hash][]string)
+	}
+	for _, it := range polls {
+		acc.Choices[it.Hash] = choices[it.Hash]
+	}
+}
+
+// HandlePollVote serves POST /~{handle}/{hash}/poll request
+func (h *handler) HandlePollVote(w http.ResponseWriter, r *http.Request) {
+	hash := chi.URLParam(r, "hash")
+
+	val := r.Context().Value(app.RepositoryCtxtKey)
+	itemLoader, ok := val.(app.CanLoadItems)
+	if !ok {
+		h.logger.Error("could not load item repository from Context")
+		return
+	}
+	p, err := itemLoader.LoadItem(app.Filters{LoadItemsFilter: app.LoadItemsFilter{Key: app.Hashes{app.Hash(hash)}}})
+	if err != nil {
+		h.logger.Error(err.Error())
+		h.HandleErrors(w, r, errors.NewNotFound(err, "not found"))
+		return
+	}
+	if !p.IsPoll() {
+		h.HandleErrors(w, r, errors.NotValidf("item %s is not a poll", hash))
+		return
+	}
+	url := ItemPermaLink(p)
+	if !p.IsLocal() {
+		h.addFlashMessage(Error, r, "voting in remote polls is not supported")
+		h.Redirect(w, r, url, http.StatusFound)
+		return
+	}
+	if err := p.ValidateInteraction(); err != nil {
+		h.addFlashMessage(Error, r, err.Error())
+		h.Redirect(w, r, url, http.StatusFound)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		h.HandleErrors(w, r, errors.NewNotValid(err, "invalid request"))
+		return
+	}
+	choices := r.PostForm["choice"]
+	if len(choices) == 0 {
+		h.addFlashMessage(Error, r, "please choose an option")
+		h.Redirect(w, r, url, http.StatusFound)
+		return
+	}
+
+	acc := h.account
+	if auth, ok := val.(app.Authenticated); ok {
+		auth.WithAccount(&acc)
+	}
+	voter, ok := val.(app.CanSavePollVotes)
+	if !ok {
+		h.logger.Error("could not load poll repository from Context")
+		return
+	}
+	v := app.PollVote{
+		Item:        &p,
+		SubmittedBy: &acc,
+		Choices:     choices,
+	}
+	if err := voter.SavePollVote(v); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"hash":    p.Hash,
+			"account": acc.Handle,
+			"choices": choices,
+		}).Error(err.Error())
+		h.addFlashMessage(Error, r, "unable to vote in poll")
+	}
+	h.Redirect(w, r, url, http.StatusSeeOther)
+}
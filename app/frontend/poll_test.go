@@ -0,0 +1,39 @@
+package frontend
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPollFromRequest(t *testing.T) {
+	form := url.Values{
+		"poll-options":  {"yes\n no \n\nyes\nmaybe"},
+		"poll-duration": {"2"},
+		"poll-multiple": {"on"},
+	}
+	r := httptest.NewRequest("POST", "/submit", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	p := pollFromRequest(r)
+	if p == nil {
+		t.Fatalf("the poll should have been loaded")
+	}
+	if len(p.Options) != 3 || p.Options[0].Name != "yes" || p.Options[1].Name != "no" || p.Options[2].Name != "maybe" {
+		t.Errorf("invalid options %v", p.Options)
+	}
+	if !p.Multiple {
+		t.Errorf("the poll should allow multiple choices")
+	}
+	if end := time.Now().UTC().Add(48 * time.Hour); p.EndTime.After(end) || p.EndTime.Before(end.Add(-time.Minute)) {
+		t.Errorf("invalid end time %s, expected %s", p.EndTime, end)
+	}
+
+	single := httptest.NewRequest("POST", "/submit", strings.NewReader("poll-options=yes"))
+	single.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if p := pollFromRequest(single); p != nil {
+		t.Errorf("a poll with a single option should not be loaded")
+	}
+}
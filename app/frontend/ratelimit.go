@@ -0,0 +1,32 @@
+package frontend
+
+import (
+	"net/http"
+
+	"github.com/mariusor/littr.go/app/ratelimit"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+)
+
+// RateLimit refuses the requests of the current account, or address, which used up their budget for "action"
+func (h *handler) RateLimit(action string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ip := ratelimit.ClientIP(r, h.conf.TrustProxy)
+			if err := h.conf.RateLimiter.Allow(action, h.account, ip); err != nil {
+				if errors.IsTooManyRequests(err) {
+					h.logger.WithContext(log.Ctx{
+						"action": action,
+						"handle": h.account.Handle,
+						"ip":     ip,
+					}).Warn(err.Error())
+					h.HandleErrors(w, r, err)
+					return
+				}
+				h.logger.Error(err.Error())
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
@@ -1,9 +1,11 @@
 package frontend
 
 import (
+	"fmt"
 	"github.com/mariusor/littr.go/app"
 	"github.com/mariusor/littr.go/internal/log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/mariusor/littr.go/internal/errors"
@@ -14,8 +16,20 @@ import (
 )
 
 type registerModel struct {
-	Title   string
-	Account app.Account
+	Title      string
+	Account    app.Account
+	InviteOnly bool
+	Invite     string
+}
+
+// hashPassword generates a new salt and returns it together with the bcrypt hash of the salted password
+func hashPassword(pw string) ([]byte, []byte, error) {
+	salt := securecookie.GenerateRandomKey(8)
+	saltedpw := []byte(pw)
+	saltedpw = append(saltedpw, salt...)
+
+	savpw, err := bcrypt.GenerateFromPassword(saltedpw, 14)
+	return salt, savpw, err
 }
 
 func accountFromRequest(r *http.Request, l log.Logger) (*app.Account, []error) {
@@ -29,6 +43,10 @@ func accountFromRequest(r *http.Request, l log.Logger) (*app.Account, []error) {
 	if pw != pwConfirm {
 		errs = append(errs, errors.Errorf("the passwords don't match"))
 	}
+	email := strings.TrimSpace(r.PostFormValue("email"))
+	if len(email) == 0 {
+		errs = append(errs, errors.Errorf("missing email"))
+	}
 
 	/*
 		agree := r.PostFormValue("agree")
@@ -48,11 +66,11 @@ func accountFromRequest(r *http.Request, l log.Logger) (*app.Account, []error) {
 	a.CreatedAt = now
 	a.UpdatedAt = now
 
-	salt := securecookie.GenerateRandomKey(8)
-	saltedpw := []byte(pw)
-	saltedpw = append(saltedpw, salt...)
+	// new accounts can't submit or vote until they confirm their email address
+	a.Email = email
+	a.Flags |= app.FlagsUnverified
 
-	savpw, err := bcrypt.GenerateFromPassword(saltedpw, 14)
+	salt, savpw, err := hashPassword(pw)
 	if err != nil {
 		l.Error(err.Error())
 	}
@@ -70,21 +88,73 @@ func accountFromRequest(r *http.Request, l log.Logger) (*app.Account, []error) {
 	return &a, nil
 }
 
+// validateRegistration returns an error if new accounts can't be created on the instance
+func validateRegistration() error {
+	if !app.Instance.Config.UserCreatingEnabled {
+		return errors.Forbiddenf("registration of new accounts is closed")
+	}
+	return nil
+}
+
 // ShowRegister serves GET /register requests
 func (h *handler) ShowRegister(w http.ResponseWriter, r *http.Request) {
-	m := registerModel{}
+	if err := validateRegistration(); err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	m := registerModel{
+		InviteOnly: app.Instance.Config.InviteOnly,
+		Invite:     r.URL.Query().Get("invite"),
+	}
 
 	h.RenderTemplate(r, w, "register", m)
 }
 
 // HandleRegister handles POST /register requests
+// When the instance is invite only, a use of the invite is claimed before creating the account
+// and given back if the creation fails.
 func (h *handler) HandleRegister(w http.ResponseWriter, r *http.Request) {
+	if err := validateRegistration(); err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	code := strings.TrimSpace(r.PostFormValue("invite"))
+	if app.Instance.Config.InviteOnly {
+		if _, err := db.Config.ClaimInvite(code); err != nil {
+			h.logger.WithContext(log.Ctx{
+				"invite": code,
+			}).Warn(err.Error())
+			h.HandleErrors(w, r, errors.Forbiddenf("a valid invite is needed for registering a new account"))
+			return
+		}
+	}
 	a, errs := accountFromRequest(r, h.logger)
 
 	if len(errs) > 0 {
+		if app.Instance.Config.InviteOnly {
+			if err := db.Config.ReleaseInvite(code); err != nil {
+				h.logger.Error(err.Error())
+			}
+		}
 		h.HandleErrors(w, r, errs...)
 		return
 	}
+	if app.Instance.Config.InviteOnly {
+		if err := db.Config.SaveInvitation(code, *a); err != nil {
+			h.logger.WithContext(log.Ctx{
+				"handle": a.Handle,
+				"invite": code,
+			}).Error(err.Error())
+		}
+	}
+	if err := h.sendVerification(*a); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"handle": a.Handle,
+		}).Error(err.Error())
+		h.addFlashMessage(Error, r, "Unable to send the verification email, please log in and ask for a new one")
+	} else {
+		h.addFlashMessage(Info, r, fmt.Sprintf("We sent a verification link to %s, please follow it to confirm your account", a.Email))
+	}
 	h.Redirect(w, r, a.GetLink(), http.StatusSeeOther)
 	return
 }
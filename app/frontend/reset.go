@@ -0,0 +1,192 @@
+package frontend
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/app/db"
+	"github.com/mariusor/littr.go/app/mail"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+)
+
+// ResetTokenTTL is the duration for which a password reset link is valid
+const ResetTokenTTL = time.Hour
+
+// MinPasswordLength is the minimum number of characters of a new password
+const MinPasswordLength = 8
+
+type resetModel struct {
+	Title string
+}
+
+// resetSignature signs the account hash and the expiration time of a reset token.
+// The current password hash is part of the signature, so the token can't be used again after the password changed.
+func resetSignature(key []byte, a app.Account, expires int64) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(a.Hash))
+	mac.Write([]byte(strconv.FormatInt(expires, 10)))
+	if a.Metadata != nil {
+		mac.Write(a.Metadata.Password)
+	}
+	return mac.Sum(nil)
+}
+
+// newResetToken returns a token with the format: hash.expires.signature
+func newResetToken(key []byte, a app.Account, now time.Time) string {
+	expires := now.Add(ResetTokenTTL).Unix()
+	sig := resetSignature(key, a, expires)
+	return fmt.Sprintf("%s.%d.%s", a.Hash, expires, base64.RawURLEncoding.EncodeToString(sig))
+}
+
+// parseSignedToken returns the account hash, the expiration time and the signature of a reset or verification token
+func parseSignedToken(tok string) (app.Hash, int64, []byte, error) {
+	parts := strings.Split(tok, ".")
+	if len(parts) != 3 {
+		return "", 0, nil, errors.NotValidf("invalid token")
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", 0, nil, errors.NotValidf("invalid token")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", 0, nil, errors.NotValidf("invalid token")
+	}
+	return app.Hash(parts[0]), expires, sig, nil
+}
+
+// signingKey returns the key we sign the reset and verification tokens with
+func (h *handler) signingKey() ([]byte, error) {
+	if len(h.conf.TokenKey) == 0 {
+		return nil, errors.NotImplementedf("signed links are not available")
+	}
+	for _, k := range h.conf.SessionKeys {
+		if bytes.Equal(k, h.conf.TokenKey) {
+			return nil, errors.NotImplementedf("signed links are not available, the token key is one of the session keys")
+		}
+	}
+	return h.conf.TokenKey, nil
+}
+
+// loadResetAccount returns the account the "tok" reset token was generated for, if the token is still valid
+func (h *handler) loadResetAccount(tok string) (app.Account, error) {
+	key, err := h.signingKey()
+	if err != nil {
+		return app.Account{}, err
+	}
+	invalid := errors.Forbiddenf("the password reset link is invalid or it has expired")
+	hash, expires, sig, err := parseSignedToken(tok)
+	if err != nil || len(hash) == 0 {
+		return app.Account{}, invalid
+	}
+	if time.Now().UTC().Unix() > expires {
+		return app.Account{}, invalid
+	}
+	a, err := db.Config.LoadAccount(app.Filters{LoadAccountsFilter: app.LoadAccountsFilter{Key: app.Hashes{hash}}})
+	if err != nil || a.Metadata == nil || len(a.Metadata.Password) == 0 {
+		return app.Account{}, invalid
+	}
+	if !hmac.Equal(sig, resetSignature(key, a, expires)) {
+		return app.Account{}, invalid
+	}
+	return a, nil
+}
+
+// ShowForgot serves GET /forgot request
+func (h *handler) ShowForgot(w http.ResponseWriter, r *http.Request) {
+	h.RenderTemplate(r, w, "forgot", resetModel{Title: "Forgot password"})
+}
+
+// HandleForgot serves POST /forgot request
+// We don't disclose if an account with the email exists, the response is the same in both cases.
+func (h *handler) HandleForgot(w http.ResponseWriter, r *http.Request) {
+	email := strings.TrimSpace(r.PostFormValue("email"))
+	if len(email) == 0 {
+		h.HandleErrors(w, r, errors.BadRequestf("missing email"))
+		return
+	}
+	key, err := h.signingKey()
+	if err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	a, err := db.Config.LoadAccount(app.Filters{LoadAccountsFilter: app.LoadAccountsFilter{Email: []string{email}}})
+	if err == nil && a.Metadata != nil && len(a.Metadata.Password) > 0 && h.conf.Mailer != nil {
+		link := fmt.Sprintf("%s/reset/%s", h.conf.BaseURL, newResetToken(key, a, time.Now().UTC()))
+		body := bytes.Buffer{}
+		fmt.Fprintf(&body, "Hello %s,\n\n", a.Handle)
+		fmt.Fprintf(&body, "Somebody asked to reset the password of your account on %s.\n", h.conf.HostName)
+		fmt.Fprintf(&body, "If it was you, please follow the link below in the next %s:\n\n", ResetTokenTTL)
+		fmt.Fprintf(&body, "%s\n\n", link)
+		fmt.Fprintf(&body, "Otherwise you can ignore this message, your password stays the same.\n")
+
+		m := mail.Message{
+			To:      a.Email,
+			Subject: fmt.Sprintf("Reset your password on %s", h.conf.HostName),
+			Body:    body.String(),
+		}
+		if err := h.conf.Mailer.Send(m); err != nil {
+			h.logger.WithContext(log.Ctx{
+				"handle": a.Handle,
+			}).Error(err.Error())
+		}
+	}
+	h.addFlashMessage(Info, r, "If an account with that email exists, we sent it a link for resetting the password")
+	h.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// ShowReset serves GET /reset/{token} request
+func (h *handler) ShowReset(w http.ResponseWriter, r *http.Request) {
+	tok := chi.URLParam(r, "token")
+	if _, err := h.loadResetAccount(tok); err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	h.RenderTemplate(r, w, "reset", resetModel{Title: "Reset password"})
+}
+
+// HandleReset serves POST /reset/{token} request
+func (h *handler) HandleReset(w http.ResponseWriter, r *http.Request) {
+	tok := chi.URLParam(r, "token")
+	a, err := h.loadResetAccount(tok)
+	if err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	pw := r.PostFormValue("pw")
+	if pw != r.PostFormValue("pw-confirm") {
+		h.HandleErrors(w, r, errors.BadRequestf("the passwords don't match"))
+		return
+	}
+	if len(pw) < MinPasswordLength {
+		h.HandleErrors(w, r, errors.BadRequestf("the password must have at least %d characters", MinPasswordLength))
+		return
+	}
+	salt, savpw, err := hashPassword(pw)
+	if err != nil {
+		h.HandleErrors(w, r, errors.NewNotValid(err, "unable to save the password"))
+		return
+	}
+	a.Metadata.Salt = salt
+	a.Metadata.Password = savpw
+	a.UpdatedAt = time.Now().UTC()
+	if _, err := db.Config.SaveAccount(a); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"handle": a.Handle,
+		}).Error(err.Error())
+		h.HandleErrors(w, r, errors.NewNotValid(err, "unable to save the password"))
+		return
+	}
+	h.addFlashMessage(Success, r, "Your password was changed, you can log in now")
+	h.Redirect(w, r, "/login", http.StatusSeeOther)
+}
@@ -0,0 +1,82 @@
+package frontend
+
+import (
+	"crypto/hmac"
+	"testing"
+	"time"
+
+	"github.com/mariusor/littr.go/app"
+)
+
+func TestNewResetToken(t *testing.T) {
+	key := []byte("dsa3Thahquoh9chaeGhoo8ohshieSoo1")
+	a := app.Account{
+		Hash:     app.Hash("dc6f5f5bf55bc1073715c98c69fa7ca8"),
+		Handle:   "jane",
+		Metadata: &app.AccountMetadata{Password: []byte("$2a$10$old-password-hash")},
+	}
+	now := time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	hash, expires, sig, err := parseSignedToken(newResetToken(key, a, now))
+	if err != nil {
+		t.Fatalf("unable to parse the reset token: %s", err)
+	}
+	if hash != a.Hash {
+		t.Errorf("invalid hash %s, expected %s", hash, a.Hash)
+	}
+	if exp := now.Add(ResetTokenTTL).Unix(); expires != exp {
+		t.Errorf("invalid expiration time %d, expected %d", expires, exp)
+	}
+	if !hmac.Equal(sig, resetSignature(key, a, expires)) {
+		t.Errorf("the reset token signature should be valid")
+	}
+	if hmac.Equal(sig, resetSignature([]byte("another-key"), a, expires)) {
+		t.Errorf("the reset token signature should not be valid for a different key")
+	}
+	if hmac.Equal(sig, resetSignature(key, a, expires+3600)) {
+		t.Errorf("the reset token signature should not be valid for a different expiration time")
+	}
+	changed := a
+	changed.Metadata = &app.AccountMetadata{Password: []byte("$2a$10$new-password-hash")}
+	if hmac.Equal(sig, resetSignature(key, changed, expires)) {
+		t.Errorf("the reset token signature should not be valid after the password changed")
+	}
+}
+
+func TestParseSignedToken(t *testing.T) {
+	for _, tok := range []string{
+		"",
+		"dc6f5f5bf55bc1073715c98c69fa7ca8",
+		"dc6f5f5bf55bc1073715c98c69fa7ca8.tomorrow.c2ln",
+		"dc6f5f5bf55bc1073715c98c69fa7ca8.1556704800.not+base64!",
+		"dc6f5f5bf55bc1073715c98c69fa7ca8.1556704800.c2ln.extra",
+	} {
+		if _, _, _, err := parseSignedToken(tok); err == nil {
+			t.Errorf("the %q token should not be valid", tok)
+		}
+	}
+}
+
+func TestSigningKey(t *testing.T) {
+	sessKeys := [][]byte{[]byte("16_chars_enc_key="), []byte("16_chars_enc_key+")}
+	tests := []struct {
+		name  string
+		conf  Config
+		valid bool
+	}{
+		{"no token key", Config{SessionKeys: sessKeys}, false},
+		{"session auth key", Config{SessionKeys: sessKeys, TokenKey: []byte("16_chars_enc_key=")}, false},
+		{"session encryption key", Config{SessionKeys: sessKeys, TokenKey: []byte("16_chars_enc_key+")}, false},
+		{"own key", Config{SessionKeys: sessKeys, TokenKey: []byte("dsa3Thahquoh9chaeGhoo8ohshieSoo1")}, true},
+	}
+	for _, tt := range tests {
+		h := handler{conf: tt.conf}
+		key, err := h.signingKey()
+		if tt.valid && (err != nil || string(key) != string(tt.conf.TokenKey)) {
+			t.Errorf("%s: invalid signing key %q: %v", tt.name, key, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%s: expected the signing key to be refused", tt.name)
+		}
+	}
+}
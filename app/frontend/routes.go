@@ -13,6 +13,7 @@ import (
 func (h *handler) Routes() func(chi.Router) {
 	return func(r chi.Router) {
 		r.Use(middleware.GetHead)
+		r.Use(h.LimitRequestBody)
 		r.Use(h.LoadSession)
 		r.Use(app.NeedsDBBackend(h.HandleErrors))
 		r.Use(app.ReqLogger(h.logger))
@@ -23,32 +24,73 @@ func (h *handler) Routes() func(chi.Router) {
 		r.Get("/", h.HandleIndex)
 		r.With(h.CSRF).Group(func(r chi.Router) {
 			r.Get("/submit", h.ShowSubmit)
-			r.Post("/submit", h.HandleSubmit)
+			r.With(h.ValidateVerified, h.RateLimit(app.ActionSubmit)).Post("/submit", h.HandleSubmit)
 			r.Get("/register", h.ShowRegister)
-			r.Post("/register", h.HandleRegister)
+			r.With(h.RateLimit(app.ActionRegister)).Post("/register", h.HandleRegister)
 		})
 
 		r.Route("/~{handle}", func(r chi.Router) {
 			r.Get("/", h.ShowAccount)
+			r.With(h.NeedsSessions, h.ValidateLoggedIn(h.HandleErrors)).Get("/saved", h.ShowSaved)
+			r.With(h.NeedsSessions, h.ValidateLoggedIn(h.HandleErrors)).Get("/drafts", h.ShowDrafts)
+			r.With(h.CSRF, h.NeedsSessions, h.ValidateLoggedIn(h.HandleErrors), h.ValidateSettingsOwner).Group(func(r chi.Router) {
+				r.Get("/settings", h.ShowSettings)
+				r.Post("/settings", h.HandleSettings)
+				r.Get("/export", h.HandleExport)
+				r.Post("/delete", h.HandleAccountDelete)
+				r.Post("/move", h.HandleAccountMove)
+				r.Get("/2fa", h.ShowTwoFactor)
+				r.Post("/2fa", h.HandleTwoFactor)
+				r.Get("/invites", h.ShowInvites)
+				r.Post("/invites", h.HandleInvites)
+				r.Get("/tokens", h.ShowTokens)
+				r.Post("/tokens", h.HandleTokens)
+				r.Post("/tokens/{id}/revoke", h.HandleTokenRevoke)
+				r.Get("/apps", h.ShowApps)
+				r.Post("/apps/{id}/revoke", h.HandleAppRevoke)
+				r.Get("/identities", h.ShowIdentities)
+				r.Post("/identities/unlink", h.HandleIdentityUnlink)
+				r.Get("/sessions", h.ShowSessions)
+				r.Post("/sessions/logout", h.HandleLogoutEverywhere)
+				r.Post("/sessions/{id}/revoke", h.HandleSessionRevoke)
+				r.Post("/sessions/tokens/{id}/revoke", h.HandleAccessTokenRevoke)
+			})
+			r.With(h.CSRF, h.NeedsSessions, h.ValidateLoggedIn(h.HandleErrors), h.ValidatePermissions(app.PermBanAccount)).Group(func(r chi.Router) {
+				r.Get("/invited", h.ShowInviteTree)
+				r.Post("/invited/prune", h.HandleInviteTreePrune)
+				r.Post("/block", h.HandleAccountBlock)
+				r.Post("/unblock", h.HandleAccountBlock)
+			})
+			r.With(h.CSRF, h.NeedsSessions, h.ValidateLoggedIn(h.HandleErrors), h.ValidatePermissions(app.PermVerifyAccount)).Post("/verify", h.HandleAccountVerify)
 
 			r.Route("/{hash}", func(r chi.Router) {
 				r.Use(h.CSRF)
 				r.Get("/", h.ShowItem)
-				r.Post("/", h.HandleSubmit)
+				r.With(h.ValidateVerified, h.RateLimit(app.ActionComment)).Post("/", h.HandleSubmit)
 
 				r.Group(func(r chi.Router) {
 					r.Use(h.ValidateLoggedIn(h.HandleErrors))
-					r.Get("/yay", h.HandleVoting)
-					r.Get("/nay", h.HandleVoting)
+					r.With(h.ValidateVerified, h.RateLimit(app.ActionVote)).Get("/yay", h.HandleVoting)
+					r.With(h.ValidateVerified, h.RateLimit(app.ActionVote)).Get("/nay", h.HandleVoting)
+
+					r.Get("/save", h.HandleSave)
+					r.Get("/unsave", h.HandleSave)
+
+					r.With(h.ValidateVerified, h.RateLimit(app.ActionVote)).Post("/poll", h.HandlePollVote)
 
 					r.Get("/bad", h.ShowReport)
 					r.Post("/bad", h.HandleReport)
 
+					r.With(h.ValidatePermissions(app.PermLockItem)).Group(func(r chi.Router) {
+						r.Post("/lock", h.HandleLock)
+						r.Post("/unlock", h.HandleLock)
+					})
+
 					r.With(h.ValidateItemAuthor).Group(func(r chi.Router) {
 						r.Get("/edit", h.ShowItem)
 						r.Post("/edit", h.HandleSubmit)
-						r.Get("/rm", h.HandleDelete)
 					})
+					r.With(h.ValidateItemAuthorOr(app.PermDeleteItem)).Get("/rm", h.HandleDelete)
 				})
 			})
 		})
@@ -66,17 +108,37 @@ func (h *handler) Routes() func(chi.Router) {
 		r.Get("/d/", h.HandleDomains)
 		r.Get("/d/{domain}", h.HandleDomains)
 		// @todo(marius) :link_generation:
+		r.Get("/t", h.HandleTagsIndex)
 		r.Get("/t/{tag}", h.HandleTags)
 
 		r.With(h.NeedsSessions).Get("/logout", h.HandleLogout)
 		r.With(h.CSRF, h.NeedsSessions).Group(func(r chi.Router) {
 			r.Get("/login", h.ShowLogin)
 			r.Post("/login", h.HandleLogin)
+			r.Get("/login/2fa", h.ShowLoginTwoFactor)
+			r.Post("/login/2fa", h.HandleLoginTwoFactor)
+			r.Get("/forgot", h.ShowForgot)
+			r.Post("/forgot", h.HandleForgot)
+			r.Get("/reset/{token}", h.ShowReset)
+			r.Post("/reset/{token}", h.HandleReset)
 		})
+		r.With(h.NeedsSessions).Get("/verify/{token}", h.HandleVerify)
+		r.With(h.NeedsSessions, h.ValidateLoggedIn(h.HandleErrors)).Get("/verify", h.HandleResendVerification)
 
 		r.Get("/self", h.HandleIndex)
 		r.Get("/federated", h.HandleIndex)
 		r.With(h.NeedsSessions, h.ValidateLoggedIn(h.HandleErrors)).Get("/followed", h.HandleIndex)
+		r.With(h.NeedsSessions, h.ValidateLoggedIn(h.HandleErrors)).Get("/notifications", h.ShowNotifications)
+		r.With(h.CSRF, h.NeedsSessions, h.ValidateLoggedIn(h.HandleErrors)).Group(func(r chi.Router) {
+			r.Post("/sort", h.HandleCommentSort)
+			r.Get("/languages", h.ShowLanguages)
+			r.Post("/languages", h.HandleLanguages)
+		})
+
+		r.With(h.CSRF, h.NeedsSessions, h.ValidateLoggedIn(h.HandleErrors), h.ValidatePermissions(app.PermInstanceSettings)).Route("/admin", func(r chi.Router) {
+			r.Get("/", h.ShowAdmin)
+			r.Post("/roles", h.HandleAdminRoles)
+		})
 
 		r.Route("/auth", func(r chi.Router) {
 			r.Use(h.NeedsSessions)
@@ -87,10 +149,12 @@ func (h *handler) Routes() func(chi.Router) {
 		r.Route("/oauth", func(r chi.Router) {
 			r.Use(h.NeedsSessions)
 			// Authorization code endpoint
-			r.With(h.ValidateLoggedIn(h.RedirectToLogin)).Get("/authorize", h.Authorize)
-			r.Post("/authorize", h.Authorize)
+			r.With(h.CSRF, h.ValidateLoggedIn(h.RedirectToLogin)).Get("/authorize", h.Authorize)
+			r.With(h.CSRF).Post("/authorize", h.Authorize)
 			// Access token endpoint
 			r.Post("/token", h.Token)
+			// Client registration endpoint
+			r.With(h.RateLimit(app.ActionClient)).Post("/register", h.HandleClientRegistration)
 		})
 
 		r.NotFound(func(w http.ResponseWriter, r *http.Request) {
@@ -119,6 +183,7 @@ func (h *handler) Routes() func(chi.Router) {
 		}))
 		r.With(app.StripCookies).Get("/css/{path}", serveFiles(filepath.Join(assets, "css")))
 		r.With(app.StripCookies).Get("/js/{path}", serveFiles(filepath.Join(assets, "js")))
+		r.With(app.StripCookies).Get("/media/{path}", h.ServeMedia)
 	}
 }
 
@@ -0,0 +1,104 @@
+package frontend
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+	"github.com/mariusor/qstring"
+)
+
+const Save = "save"
+const Unsave = "unsave"
+
+// ShowSaved serves /~{handle}/saved request
+func (h *handler) ShowSaved(w http.ResponseWriter, r *http.Request) {
+	handle := chi.URLParam(r, "handle")
+	acc := h.account
+	if handle != acc.Handle {
+		h.HandleErrors(w, r, errors.Forbiddenf("saved items are available only to their owner"))
+		return
+	}
+	if auth, ok := app.ContextAuthenticated(r.Context()); ok {
+		auth.WithAccount(&acc)
+	}
+
+	filter := app.Filters{
+		LoadItemsFilter: app.LoadItemsFilter{
+			SavedBy: []string{acc.Hash.String()},
+		},
+		MaxItems: MaxContentItems,
+		Page:     1,
+	}
+	if err := qstring.Unmarshal(r.URL.Query(), &filter); err != nil {
+		h.logger.Debug("unable to load url parameters")
+	}
+	if m, err := loadItems(r.Context(), filter, &h.account, h.logger); err == nil {
+		m.Title = "Saved items"
+
+		if len(m.Items) >= filter.MaxItems {
+			m.nextPage = filter.Page + 1
+		}
+		if filter.Page > 1 {
+			m.prevPage = filter.Page - 1
+		}
+		h.RenderTemplate(r, w, "listing", m)
+	} else {
+		h.HandleErrors(w, r, errors.NewNotValid(err, "unable to load items"))
+	}
+}
+
+// HandleSave serves /~{handle}/{hash}/save and /~{handle}/{hash}/unsave requests
+func (h *handler) HandleSave(w http.ResponseWriter, r *http.Request) {
+	hash := chi.URLParam(r, "hash")
+
+	val := r.Context().Value(app.RepositoryCtxtKey)
+	itemLoader, ok := val.(app.CanLoadItems)
+	if !ok {
+		h.logger.Error("could not load item repository from Context")
+		return
+	}
+
+	p, err := itemLoader.LoadItem(app.Filters{LoadItemsFilter: app.LoadItemsFilter{Key: app.Hashes{app.Hash(hash)}}})
+	if err != nil {
+		h.logger.Error(err.Error())
+		h.HandleErrors(w, r, errors.NewNotFound(err, "not found"))
+		return
+	}
+
+	url := ItemPermaLink(p)
+	backUrl := r.Header.Get("Referer")
+	if !strings.Contains(backUrl, url) && strings.Contains(backUrl, app.Instance.BaseURL) {
+		url = fmt.Sprintf("%s#item-%s", backUrl, p.Hash)
+	}
+
+	acc := h.account
+	if auth, ok := val.(app.Authenticated); ok {
+		auth.WithAccount(&acc)
+	}
+	saver, ok := val.(app.CanSaveBookmarks)
+	if !ok {
+		h.logger.Error("could not load bookmark repository from Context")
+		return
+	}
+	action := path.Base(r.URL.Path)
+	if action == Unsave {
+		err = saver.RemoveBookmark(acc, p)
+	} else {
+		err = saver.SaveBookmark(acc, p)
+	}
+	if err != nil {
+		h.logger.WithContext(log.Ctx{
+			"hash":    p.Hash,
+			"account": acc.Handle,
+			"action":  action,
+		}).Error(err.Error())
+		h.addFlashMessage(Error, r, fmt.Sprintf("unable to %s item", action))
+	}
+	h.Redirect(w, r, url, http.StatusFound)
+}
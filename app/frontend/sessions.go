@@ -0,0 +1,183 @@
+package frontend
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/app/db"
+	"github.com/mariusor/littr.go/app/oauth"
+	"github.com/mariusor/littr.go/internal/log"
+)
+
+type sessionsModel struct {
+	Title   string
+	Account app.Account
+	// Current is the identifier of the session the page was loaded with
+	Current  string
+	Sessions app.SessionCollection
+	Tokens   []oauth.AccessToken
+}
+
+var (
+	browsers = []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+	}
+	systems = []struct{ token, name string }{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// deviceName returns a short description of the browser and the operating system of the "ua" user agent
+func deviceName(ua string) string {
+	browser, system := "", ""
+	for _, b := range browsers {
+		if strings.Contains(ua, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, s := range systems {
+		if strings.Contains(ua, s.token) {
+			system = s.name
+			break
+		}
+	}
+	switch {
+	case len(browser) > 0 && len(system) > 0:
+		return fmt.Sprintf("%s on %s", browser, system)
+	case len(browser) > 0:
+		return browser
+	case len(system) > 0:
+		return system
+	case len(ua) > 64:
+		return ua[:64]
+	case len(ua) > 0:
+		return ua
+	}
+	return "Unknown device"
+}
+
+// currentSession returns the identifier of the server side session of the request
+func (h *handler) currentSession(r *http.Request) string {
+	s, err := h.sstor.Get(r, sessionName)
+	if err != nil {
+		return ""
+	}
+	sa, _ := s.Values[SessionUserKey].(sessionAccount)
+	return sa.Session
+}
+
+// endCurrentSession logs out the account of the request, after its session was revoked
+func (h *handler) endCurrentSession(r *http.Request) {
+	if s, err := h.sstor.Get(r, sessionName); err == nil {
+		s.Values[SessionUserKey] = nil
+	}
+}
+
+// ShowSessions serves GET /~{handle}/sessions request
+// It lists the browser sessions the account is logged in with and the access tokens of the applications.
+func (h *handler) ShowSessions(w http.ResponseWriter, r *http.Request) {
+	s, err := h.tokenStorage()
+	if err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	m := sessionsModel{
+		Title:   "Sessions",
+		Account: h.account,
+		Current: h.currentSession(r),
+	}
+	if m.Sessions, err = db.Config.LoadSessions(h.account); err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	// the tokens of the frontend belong to the browser sessions
+	if m.Tokens, err = s.LoadAccessTokens(h.account.Hash.String(), os.Getenv("OAUTH2_KEY")); err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	h.RenderTemplate(r, w, "sessions", m)
+}
+
+// HandleSessionRevoke serves POST /~{handle}/sessions/{id}/revoke request
+func (h *handler) HandleSessionRevoke(w http.ResponseWriter, r *http.Request) {
+	acc := h.account
+	back := fmt.Sprintf("%s/sessions", acc.GetLink())
+
+	id := chi.URLParam(r, "id")
+	if err := db.Config.DeleteSessions(acc, id); err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	h.logger.WithContext(log.Ctx{
+		"handle": acc.Handle,
+	}).Info("revoked session")
+	if id == h.currentSession(r) {
+		h.endCurrentSession(r)
+		h.addFlashMessage(Success, r, "You were logged out")
+		h.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+	h.addFlashMessage(Success, r, "The session was logged out")
+	h.Redirect(w, r, back, http.StatusSeeOther)
+}
+
+// HandleAccessTokenRevoke serves POST /~{handle}/sessions/tokens/{id}/revoke request
+func (h *handler) HandleAccessTokenRevoke(w http.ResponseWriter, r *http.Request) {
+	acc := h.account
+	back := fmt.Sprintf("%s/sessions", acc.GetLink())
+
+	s, err := h.tokenStorage()
+	if err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	if err := s.RemoveAccessToken(acc.Hash.String(), chi.URLParam(r, "id")); err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	h.addFlashMessage(Success, r, "The access token was revoked")
+	h.Redirect(w, r, back, http.StatusSeeOther)
+}
+
+// HandleLogoutEverywhere serves POST /~{handle}/sessions/logout request
+// It ends all the browser sessions of the account, the current one included, and revokes the refresh and access tokens
+// of all the applications. The personal access tokens are kept.
+func (h *handler) HandleLogoutEverywhere(w http.ResponseWriter, r *http.Request) {
+	acc := h.account
+
+	s, err := h.tokenStorage()
+	if err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	if err := s.RevokeAccountTokens(acc.Hash.String()); err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	if err := db.Config.DeleteSessions(acc); err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	h.logger.WithContext(log.Ctx{
+		"handle": acc.Handle,
+	}).Info("logged out of all sessions")
+	h.endCurrentSession(r)
+	h.addFlashMessage(Success, r, "You were logged out of all your sessions")
+	h.Redirect(w, r, "/", http.StatusSeeOther)
+}
@@ -0,0 +1,27 @@
+package frontend
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeviceName(t *testing.T) {
+	tests := []struct {
+		ua   string
+		name string
+	}{
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:68.0) Gecko/20100101 Firefox/68.0", "Firefox on Linux"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/76.0.3809.100 Safari/537.36 Edg/76.0.182.42", "Edge on Windows"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 12_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/12.1.2 Mobile/15E148 Safari/604.1", "Safari on iOS"},
+		{"Mozilla/5.0 (Linux; Android 9; Pixel 3) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/76.0.3809.111 Mobile Safari/537.36", "Chrome on Android"},
+		{"curl/7.65.3", "curl"},
+		{"Tusky/9.1.1", "Tusky/9.1.1"},
+		{strings.Repeat("x", 100), strings.Repeat("x", 64)},
+		{"", "Unknown device"},
+	}
+	for _, tt := range tests {
+		if n := deviceName(tt.ua); n != tt.name {
+			t.Errorf("invalid device name for %q: %q, expected %q", tt.ua, n, tt.name)
+		}
+	}
+}
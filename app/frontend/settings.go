@@ -0,0 +1,366 @@
+package frontend
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/app/db"
+	"github.com/mariusor/littr.go/app/media"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MaxNameLength is the maximum number of characters of an account's display name
+const MaxNameLength = 64
+
+// MaxBlurbLength is the maximum number of characters of an account's bio
+const MaxBlurbLength = 1024
+
+// MaxAliases is the maximum number of actors an account can declare as its aliases
+const MaxAliases = 10
+
+type settingsModel struct {
+	Title   string
+	Account app.Account
+}
+
+// checkPassword returns an error if "pw" is not the password of the "a" account
+func checkPassword(a app.Account, pw string) error {
+	if a.Metadata == nil || len(a.Metadata.Password) == 0 {
+		return errors.Forbiddenf("the account doesn't have a password")
+	}
+	saltyPw := append([]byte(pw), a.Metadata.Salt...)
+	return bcrypt.CompareHashAndPassword(a.Metadata.Password, saltyPw)
+}
+
+// validActorIRI returns an error if "iri" is not an absolute http(s) URL
+func validActorIRI(iri string) error {
+	u, err := url.Parse(iri)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return errors.BadRequestf("%q is not a valid actor address", iri)
+	}
+	return nil
+}
+
+// aliasesFromRequest returns the actor IRIs, one per line, of the "aliases" field of the settings form
+func aliasesFromRequest(r *http.Request) ([]string, error) {
+	aliases := make([]string, 0)
+	for _, l := range strings.Split(r.PostFormValue("aliases"), "\n") {
+		iri := strings.TrimSpace(l)
+		if len(iri) == 0 {
+			continue
+		}
+		if err := validActorIRI(iri); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, iri)
+	}
+	if len(aliases) > MaxAliases {
+		return nil, errors.BadRequestf("you can have at most %d aliases", MaxAliases)
+	}
+	return aliases, nil
+}
+
+// sameAliases returns if the "a" and "b" lists contain the same actor IRIs in the same order
+func sameAliases(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// avatarFromRequest stores the image uploaded in the "avatar" field of the settings form
+func (h *handler) avatarFromRequest(r *http.Request) (*app.ImageMetadata, error) {
+	if r.MultipartForm == nil || r.MultipartForm.File == nil {
+		return nil, nil
+	}
+	files := r.MultipartForm.File["avatar"]
+	if len(files) == 0 {
+		return nil, nil
+	}
+	if h.conf.Storage == nil {
+		return nil, errors.NotImplementedf("media uploads are not enabled")
+	}
+	fh := files[0]
+	f, err := fh.Open()
+	if err != nil {
+		return nil, errors.Annotatef(err, "unable to read %s", fh.Filename)
+	}
+	defer f.Close()
+	att, err := media.Upload(h.conf.Storage, f, filepath.Base(fh.Filename), h.conf.MaxUploadSize)
+	if err != nil {
+		return nil, err
+	}
+	return &app.ImageMetadata{URI: att.URL, MimeType: att.MimeType}, nil
+}
+
+// ValidateSettingsOwner lets through only the requests for the settings of the logged account
+func (h *handler) ValidateSettingsOwner(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if handle := chi.URLParam(r, "handle"); handle != h.account.Handle || !h.account.IsLocal() {
+			h.HandleErrors(w, r, errors.Forbiddenf("you can only change the settings of your own account"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// ShowSettings serves GET /~{handle}/settings request
+func (h *handler) ShowSettings(w http.ResponseWriter, r *http.Request) {
+	m := settingsModel{Title: "Settings", Account: h.account}
+	h.RenderTemplate(r, w, "settings", m)
+}
+
+// HandleSettings serves POST /~{handle}/settings request
+// The email and the password are saved directly, the profile changes are published as an Update of the actor.
+func (h *handler) HandleSettings(w http.ResponseWriter, r *http.Request) {
+	acc := h.account
+	meta := app.AccountMetadata{}
+	if acc.Metadata != nil {
+		meta = *acc.Metadata
+	}
+	acc.Metadata = &meta
+	back := fmt.Sprintf("%s/settings", acc.GetLink())
+
+	name := strings.TrimSpace(r.PostFormValue("name"))
+	blurb := strings.TrimSpace(r.PostFormValue("blurb"))
+	if len([]rune(name)) > MaxNameLength {
+		h.HandleErrors(w, r, errors.BadRequestf("the name must have at most %d characters", MaxNameLength))
+		return
+	}
+	if len([]rune(blurb)) > MaxBlurbLength {
+		h.HandleErrors(w, r, errors.BadRequestf("the bio must have at most %d characters", MaxBlurbLength))
+		return
+	}
+	aliases, err := aliasesFromRequest(r)
+	if err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	icon, err := h.avatarFromRequest(r)
+	if err != nil {
+		h.logger.WithContext(log.Ctx{
+			"prev": err,
+		}).Error("unable to store avatar")
+		h.HandleErrors(w, r, errors.NewNotValid(err, "unable to store the avatar"))
+		return
+	}
+
+	// the email and the password changes need the current password, except for the accounts created
+	// from a provider identity which didn't set one yet, they can only be logged in through the provider
+	email := strings.TrimSpace(r.PostFormValue("email"))
+	pw := r.PostFormValue("pw")
+	emailChanged := len(email) > 0 && !strings.EqualFold(email, acc.Email)
+	hasPassword := acc.Metadata != nil && len(acc.Metadata.Password) > 0
+	if hasPassword && (emailChanged || len(pw) > 0) {
+		if err := checkPassword(acc, r.PostFormValue("current-pw")); err != nil {
+			h.HandleErrors(w, r, errors.Forbiddenf("the current password is wrong"))
+			return
+		}
+	}
+	if len(pw) > 0 {
+		if pw != r.PostFormValue("pw-confirm") {
+			h.HandleErrors(w, r, errors.BadRequestf("the passwords don't match"))
+			return
+		}
+		if len(pw) < MinPasswordLength {
+			h.HandleErrors(w, r, errors.BadRequestf("the password must have at least %d characters", MinPasswordLength))
+			return
+		}
+		salt, savpw, err := hashPassword(pw)
+		if err != nil {
+			h.HandleErrors(w, r, errors.NewNotValid(err, "unable to save the password"))
+			return
+		}
+		acc.Metadata.Salt = salt
+		acc.Metadata.Password = savpw
+	}
+	if emailChanged {
+		// a new address needs to be confirmed again
+		acc.Email = email
+		acc.Flags |= app.FlagsUnverified
+	}
+	if emailChanged || len(pw) > 0 {
+		acc.UpdatedAt = time.Now().UTC()
+		if _, err := db.Config.SaveAccount(acc); err != nil {
+			h.logger.WithContext(log.Ctx{
+				"handle": acc.Handle,
+			}).Error(err.Error())
+			h.HandleErrors(w, r, errors.NewNotValid(err, "unable to save the account"))
+			return
+		}
+		if len(pw) > 0 {
+			h.addFlashMessage(Success, r, "Your password was changed")
+		}
+		if emailChanged {
+			if err := h.sendVerification(acc); err != nil {
+				h.logger.WithContext(log.Ctx{
+					"handle": acc.Handle,
+				}).Error(err.Error())
+				h.addFlashMessage(Error, r, "Unable to send the verification email")
+			} else {
+				h.addFlashMessage(Info, r, fmt.Sprintf("We sent a verification link to %s", acc.Email))
+			}
+		}
+	}
+
+	profileChanged := name != acc.Metadata.Name || blurb != string(acc.Metadata.Blurb) || icon != nil ||
+		!sameAliases(aliases, acc.Metadata.AlsoKnownAs)
+	if !profileChanged {
+		h.Redirect(w, r, back, http.StatusSeeOther)
+		return
+	}
+	acc.Metadata.Name = name
+	acc.Metadata.Blurb = []byte(blurb)
+	acc.Metadata.AlsoKnownAs = aliases
+	if icon != nil {
+		acc.Metadata.Icon = *icon
+	}
+	acc.UpdatedAt = time.Now().UTC()
+
+	auth, authOk := app.ContextAuthenticated(r.Context())
+	if authOk {
+		auth.WithAccount(&acc)
+	}
+	accountSaver, ok := app.ContextAccountSaver(r.Context())
+	if !ok {
+		h.HandleErrors(w, r, errors.Errorf("could not load account repository from Context"))
+		return
+	}
+	if _, err := accountSaver.SaveAccount(acc); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"handle": acc.Handle,
+		}).Error(err.Error())
+		h.addFlashMessage(Error, r, "unable to save the profile")
+	} else {
+		h.addFlashMessage(Success, r, "profile saved")
+	}
+	h.Redirect(w, r, back, http.StatusSeeOther)
+}
+
+// HandleExport serves GET /~{handle}/export request
+func (h *handler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	exporter, ok := app.ContextAccountExporter(r.Context())
+	if !ok {
+		h.HandleErrors(w, r, errors.NotImplementedf("account export is not available"))
+		return
+	}
+	buf := bytes.Buffer{}
+	if err := exporter.ExportAccount(h.account, &buf); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"handle": h.account.Handle,
+		}).Error(err.Error())
+		h.HandleErrors(w, r, errors.NewNotValid(err, "unable to export the account"))
+		return
+	}
+	name := fmt.Sprintf("%s-%s.zip", h.account.Handle, time.Now().UTC().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	w.WriteHeader(http.StatusOK)
+	buf.WriteTo(w)
+}
+
+// HandleAccountDelete serves POST /~{handle}/delete request
+// The items of the account are tombstoned, its personal data removed and a Delete of the actor is published.
+func (h *handler) HandleAccountDelete(w http.ResponseWriter, r *http.Request) {
+	acc := h.account
+	back := fmt.Sprintf("%s/settings", acc.GetLink())
+	if err := checkPassword(acc, r.PostFormValue("current-pw")); err != nil {
+		h.addFlashMessage(Error, r, "the current password is wrong")
+		h.Redirect(w, r, back, http.StatusSeeOther)
+		return
+	}
+	if r.PostFormValue("confirm") != acc.Handle {
+		h.addFlashMessage(Error, r, "type your handle to confirm the deletion")
+		h.Redirect(w, r, back, http.StatusSeeOther)
+		return
+	}
+
+	auth, authOk := app.ContextAuthenticated(r.Context())
+	if authOk {
+		auth.WithAccount(&acc)
+	}
+	deleter, ok := app.ContextAccountDeleter(r.Context())
+	if !ok {
+		h.HandleErrors(w, r, errors.Errorf("could not load account repository from Context"))
+		return
+	}
+	if err := deleter.DeleteAccount(acc); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"handle": acc.Handle,
+		}).Error(err.Error())
+		h.addFlashMessage(Error, r, "unable to delete the account")
+		h.Redirect(w, r, back, http.StatusSeeOther)
+		return
+	}
+	if err := db.Config.DeleteSessions(acc); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"handle": acc.Handle,
+		}).Warn(err.Error())
+	}
+
+	if s, err := h.sstor.Get(r, sessionName); err == nil {
+		s.Values[SessionUserKey] = nil
+	}
+	h.addFlashMessage(Success, r, "Your account was deleted")
+	h.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// HandleAccountMove serves POST /~{handle}/move request
+// It publishes a Move of the actor to the new one, which needs to list the current actor as its alias.
+func (h *handler) HandleAccountMove(w http.ResponseWriter, r *http.Request) {
+	acc := h.account
+	back := fmt.Sprintf("%s/settings", acc.GetLink())
+	if err := checkPassword(acc, r.PostFormValue("current-pw")); err != nil {
+		h.addFlashMessage(Error, r, "the current password is wrong")
+		h.Redirect(w, r, back, http.StatusSeeOther)
+		return
+	}
+	if acc.Moved() {
+		h.addFlashMessage(Error, r, fmt.Sprintf("your account was already moved to %s", acc.Metadata.MovedTo))
+		h.Redirect(w, r, back, http.StatusSeeOther)
+		return
+	}
+	target := strings.TrimSpace(r.PostFormValue("target"))
+	if err := validActorIRI(target); err != nil {
+		h.addFlashMessage(Error, r, err.Error())
+		h.Redirect(w, r, back, http.StatusSeeOther)
+		return
+	}
+
+	auth, authOk := app.ContextAuthenticated(r.Context())
+	if authOk {
+		auth.WithAccount(&acc)
+	}
+	mover, ok := app.ContextAccountMover(r.Context())
+	if !ok {
+		h.HandleErrors(w, r, errors.Errorf("could not load account repository from Context"))
+		return
+	}
+	to := app.Account{Metadata: &app.AccountMetadata{ID: target}}
+	if err := mover.MoveAccount(acc, to); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"handle": acc.Handle,
+			"target": target,
+		}).Error(err.Error())
+		h.addFlashMessage(Error, r, "unable to move the account, check that the new account lists this one as an alias")
+		h.Redirect(w, r, back, http.StatusSeeOther)
+		return
+	}
+	h.addFlashMessage(Success, r, fmt.Sprintf("Your followers were moved to %s", target))
+	h.Redirect(w, r, acc.GetLink(), http.StatusSeeOther)
+}
@@ -0,0 +1,130 @@
+package frontend
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/app/db"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+)
+
+const (
+	// SortBest orders the comments by the lower bound of the Wilson score confidence interval of their votes
+	SortBest = "best"
+	// SortTop orders the comments by their score
+	SortTop = "top"
+	// SortNew orders the comments from the newest to the oldest
+	SortNew = "new"
+	// SortOld orders the comments from the oldest to the newest
+	SortOld = "old"
+	// SortControversial orders the comments which received a lot of votes, but balanced between ups and downs, first
+	SortControversial = "controversial"
+)
+
+// DefaultCommentSort is the order used for comments when the request or the account don't specify one
+const DefaultCommentSort = SortBest
+
+// CommentSorts are the valid comment orders
+var CommentSorts = []string{
+	SortBest,
+	SortTop,
+	SortNew,
+	SortOld,
+	SortControversial,
+}
+
+func isValidCommentSort(s string) bool {
+	for _, valid := range CommentSorts {
+		if valid == s {
+			return true
+		}
+	}
+	return false
+}
+
+// commentSortFromRequest returns the comment order from the "sort" URL parameter,
+// falling back to the default of the logged account
+func commentSortFromRequest(r *http.Request, acc app.Account) string {
+	if s := strings.ToLower(r.URL.Query().Get("sort")); isValidCommentSort(s) {
+		return s
+	}
+	if acc.Metadata != nil && isValidCommentSort(acc.Metadata.CommentSort) {
+		return acc.Metadata.CommentSort
+	}
+	return DefaultCommentSort
+}
+
+// controversy is the score used by reddit for its controversial sort:
+// the total number of votes raised to the power of the balance between ups and downs
+func controversy(ups, downs int64) float64 {
+	if ups <= 0 || downs <= 0 {
+		return 0
+	}
+	magnitude := float64(ups + downs)
+	balance := float64(downs) / float64(ups)
+	if ups < downs {
+		balance = float64(ups) / float64(downs)
+	}
+	return math.Pow(magnitude, balance)
+}
+
+// sortBy orders the comments, and recursively their children, by the "order" comment sort
+func (c comments) sortBy(order string) {
+	var less func(i, j *comment) bool
+	switch order {
+	case SortTop:
+		less = func(i, j *comment) bool { return i.Score > j.Score }
+	case SortNew:
+		less = func(i, j *comment) bool { return i.SubmittedAt.After(j.SubmittedAt) }
+	case SortOld:
+		less = func(i, j *comment) bool { return i.SubmittedAt.Before(j.SubmittedAt) }
+	case SortControversial:
+		less = func(i, j *comment) bool { return controversy(i.Ups, i.Downs) > controversy(j.Ups, j.Downs) }
+	default:
+		less = func(i, j *comment) bool { return app.Wilson(i.Ups, i.Downs) > app.Wilson(j.Ups, j.Downs) }
+	}
+	sort.SliceStable(c, func(i, j int) bool { return less(c[i], c[j]) })
+	for _, com := range c {
+		if len(com.Children) > 1 {
+			com.Children.sortBy(order)
+		}
+	}
+}
+
+// HandleCommentSort serves POST /sort request
+// It saves the comment order as the default of the logged account.
+func (h *handler) HandleCommentSort(w http.ResponseWriter, r *http.Request) {
+	order := strings.ToLower(r.PostFormValue("sort"))
+	if !isValidCommentSort(order) {
+		h.HandleErrors(w, r, errors.NotValidf("invalid comment sort %q", order))
+		return
+	}
+	acc := h.account
+	// the metadata is shared with the other requests of the account, so it's changed on a copy
+	m := app.AccountMetadata{}
+	if acc.Metadata != nil {
+		m = *acc.Metadata
+	}
+	m.CommentSort = order
+	acc.Metadata = &m
+	if _, err := db.Config.SaveAccount(acc); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"handle": acc.Handle,
+			"sort":   order,
+		}).Error(err.Error())
+		h.addFlashMessage(Error, r, "unable to save the comment order")
+	} else {
+		h.addFlashMessage(Success, r, fmt.Sprintf("comments will be sorted by %q", order))
+	}
+
+	backURL := r.Header.Get("Referer")
+	if len(backURL) == 0 || !strings.Contains(backURL, app.Instance.BaseURL) {
+		backURL = "/"
+	}
+	h.Redirect(w, r, backURL, http.StatusFound)
+}
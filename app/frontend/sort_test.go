@@ -0,0 +1,75 @@
+package frontend
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mariusor/littr.go/app"
+)
+
+func TestControversy(t *testing.T) {
+	if c := controversy(10, 0); c != 0 {
+		t.Errorf("invalid controversy %f for unanimous votes, expected 0", c)
+	}
+	if controversy(50, 50) <= controversy(90, 10) {
+		t.Errorf("balanced votes should be more controversial than unbalanced ones")
+	}
+	if controversy(50, 50) <= controversy(5, 5) {
+		t.Errorf("more votes should be more controversial")
+	}
+	if controversy(10, 90) != controversy(90, 10) {
+		t.Errorf("controversy should not depend on the direction of the votes")
+	}
+}
+
+func TestComments_SortBy(t *testing.T) {
+	now := time.Now().UTC()
+	mk := func(hash string, score, ups, downs int64, age time.Duration) *comment {
+		return &comment{Item: app.Item{Hash: app.Hash(hash), Score: score, Ups: ups, Downs: downs, SubmittedAt: now.Add(-age)}}
+	}
+	tests := map[string][]string{
+		SortBest:          {"liked", "balanced", "disliked"},
+		SortTop:           {"balanced", "liked", "disliked"},
+		SortNew:           {"disliked", "balanced", "liked"},
+		SortOld:           {"liked", "balanced", "disliked"},
+		SortControversial: {"balanced", "disliked", "liked"},
+	}
+	for order, exp := range tests {
+		c := comments{
+			mk("disliked", -5, 1, 6, time.Minute),
+			mk("liked", 8, 9, 1, time.Hour),
+			mk("balanced", 10, 40, 30, 10*time.Minute),
+		}
+		c[1].Children = comments{mk("old", 0, 0, 0, time.Hour), mk("new", 0, 0, 0, time.Minute)}
+		c.sortBy(order)
+		for k, hash := range exp {
+			if c[k].Hash != app.Hash(hash) {
+				t.Errorf("%s: invalid comment %q at position %d, expected %q", order, c[k].Hash, k, hash)
+			}
+		}
+		if order == SortNew && c[2].Children[0].Hash != "new" {
+			t.Errorf("%s: the children were not sorted", order)
+		}
+	}
+}
+
+func TestCommentSortFromRequest(t *testing.T) {
+	acc := app.Account{Metadata: &app.AccountMetadata{CommentSort: SortNew}}
+	tests := []struct {
+		url  string
+		acc  app.Account
+		sort string
+	}{
+		{"/", app.Account{}, DefaultCommentSort},
+		{"/", acc, SortNew},
+		{"/?sort=TOP", acc, SortTop},
+		{"/?sort=random", acc, SortNew},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", tt.url, nil)
+		if s := commentSortFromRequest(r, tt.acc); s != tt.sort {
+			t.Errorf("invalid sort %q for %s, expected %q", s, tt.url, tt.sort)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package frontend
+
+import (
+	"testing"
+
+	"github.com/mariusor/littr.go/app"
+)
+
+func TestLoadTags(t *testing.T) {
+	tests := []struct {
+		data     string
+		tags     app.TagCollection
+		mentions app.TagCollection
+	}{
+		{"no tags here", nil, nil},
+		{
+			"Hello #Go and ~jane, see #news.",
+			app.TagCollection{{Name: "#Go", URL: "/t/Go"}, {Name: "#news", URL: "/t/news"}},
+			app.TagCollection{{Name: "~jane", URL: "/~jane"}},
+		},
+		{"#tag\n#other", app.TagCollection{{Name: "#tag", URL: "/t/tag"}, {Name: "#other", URL: "/t/other"}}, nil},
+		{"a lonely # sign", nil, nil},
+	}
+	for _, tt := range tests {
+		tags, mentions := loadTags(tt.data)
+		if len(tags) != len(tt.tags) || len(mentions) != len(tt.mentions) {
+			t.Errorf("invalid tags %v and mentions %v for %q, expected %v and %v", tags, mentions, tt.data, tt.tags, tt.mentions)
+			continue
+		}
+		for i := range tt.tags {
+			if tags[i] != tt.tags[i] {
+				t.Errorf("invalid tag %v for %q, expected %v", tags[i], tt.data, tt.tags[i])
+			}
+		}
+		for i := range tt.mentions {
+			if mentions[i] != tt.mentions[i] {
+				t.Errorf("invalid mention %v for %q, expected %v", mentions[i], tt.data, tt.mentions[i])
+			}
+		}
+	}
+}
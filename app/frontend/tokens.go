@@ -0,0 +1,153 @@
+package frontend
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/gorilla/securecookie"
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/app/oauth"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+)
+
+// tokenExpirations are the lifetimes, in days, which can be chosen for a personal access token, zero meaning it doesn't expire
+var tokenExpirations = []int{7, 30, 90, 365, 0}
+
+type tokensModel struct {
+	Title       string
+	Account     app.Account
+	Tokens      []oauth.PersonalToken
+	Scopes      []app.Scope
+	Expirations []int
+	// Created is the token which was just created, it is shown only in the response to its creation
+	Created *oauth.PersonalToken
+}
+
+// tokenUserData returns the data saved with the access tokens of the "a" account.
+// Only the fields identifying the account are kept, the API loads it from the database for every request,
+// so the tokens don't keep the role or the flags the account had when they were issued.
+func tokenUserData(a app.Account) json.RawMessage {
+	data, _ := json.Marshal(app.Account{Hash: a.Hash, Handle: a.Handle})
+	return data
+}
+
+// tokenStorage returns the OAuth2 storage which keeps the personal access tokens
+func (h *handler) tokenStorage() (*oauth.Storage, error) {
+	if h.os != nil {
+		if s, ok := h.os.Storage.(*oauth.Storage); ok {
+			return s, nil
+		}
+	}
+	return nil, errors.NotImplementedf("personal access tokens are not available")
+}
+
+// showTokens renders the personal access tokens page, with the "created" token when there is one
+func (h *handler) showTokens(w http.ResponseWriter, r *http.Request, s *oauth.Storage, created *oauth.PersonalToken) {
+	m := tokensModel{
+		Title:       "Personal access tokens",
+		Account:     h.account,
+		Scopes:      app.Scopes,
+		Expirations: tokenExpirations,
+		Created:     created,
+	}
+	var err error
+	if m.Tokens, err = s.LoadPersonalTokens(h.account.Hash.String()); err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	if created != nil {
+		// the token is in the page, it must not be kept by the browser or the proxies
+		w.Header().Set("Cache-Control", "no-store")
+	}
+	h.RenderTemplate(r, w, "tokens", m)
+}
+
+// ShowTokens serves GET /~{handle}/tokens request
+func (h *handler) ShowTokens(w http.ResponseWriter, r *http.Request) {
+	s, err := h.tokenStorage()
+	if err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	h.showTokens(w, r, s, nil)
+}
+
+// HandleTokens serves POST /~{handle}/tokens request
+// It creates a new personal access token, which is shown only once, in the response page.
+func (h *handler) HandleTokens(w http.ResponseWriter, r *http.Request) {
+	acc := h.account
+	back := fmt.Sprintf("%s/tokens", acc.GetLink())
+
+	s, err := h.tokenStorage()
+	if err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	name := strings.TrimSpace(r.PostFormValue("name"))
+	if len(name) == 0 {
+		h.addFlashMessage(Error, r, "the token needs a name")
+		h.Redirect(w, r, back, http.StatusSeeOther)
+		return
+	}
+	r.ParseForm()
+	scopes := app.ParseScopes(strings.Join(r.PostForm["scope"], " "))
+	if len(scopes) == 0 {
+		h.addFlashMessage(Error, r, "the token needs at least one scope")
+		h.Redirect(w, r, back, http.StatusSeeOther)
+		return
+	}
+	days, _ := strconv.Atoi(r.PostFormValue("expires"))
+	valid := false
+	for _, d := range tokenExpirations {
+		valid = valid || d == days
+	}
+	if !valid {
+		h.addFlashMessage(Error, r, "invalid token expiration")
+		h.Redirect(w, r, back, http.StatusSeeOther)
+		return
+	}
+
+	now := time.Now().UTC()
+	t := oauth.PersonalToken{
+		Name:      name,
+		Token:     hex.EncodeToString(securecookie.GenerateRandomKey(32)),
+		Scope:     app.JoinScopes(scopes),
+		CreatedAt: now,
+	}
+	if days > 0 {
+		t.ExpiresAt = now.Add(time.Duration(days) * 24 * time.Hour)
+	}
+	if err := s.CreatePersonalToken(tokenUserData(acc), t); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"handle": acc.Handle,
+		}).Error(err.Error())
+		h.HandleErrors(w, r, errors.NewNotValid(err, "unable to create the token"))
+		return
+	}
+	h.showTokens(w, r, s, &t)
+}
+
+// HandleTokenRevoke serves POST /~{handle}/tokens/{id}/revoke request
+func (h *handler) HandleTokenRevoke(w http.ResponseWriter, r *http.Request) {
+	acc := h.account
+	back := fmt.Sprintf("%s/tokens", acc.GetLink())
+
+	s, err := h.tokenStorage()
+	if err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	if err := s.RemovePersonalToken(acc.Hash.String(), chi.URLParam(r, "id")); err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	h.addFlashMessage(Success, r, "Token revoked")
+	h.Redirect(w, r, back, http.StatusSeeOther)
+}
@@ -0,0 +1,30 @@
+package frontend
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mariusor/littr.go/app"
+)
+
+func TestTokenUserData(t *testing.T) {
+	a := app.Account{
+		Hash:   app.Hash("dc6f5f5bf55bc1073715c98c69fa7ca8"),
+		Handle: "jane",
+		Email:  "jane@example.com",
+		Role:   app.RoleModerator,
+		Flags:  app.FlagsDeleted,
+	}
+	data := make(map[string]interface{})
+	if err := json.Unmarshal(tokenUserData(a), &data); err != nil {
+		t.Fatalf("invalid token data: %s", err)
+	}
+	if data["hash"] != a.Hash.String() || data["handle"] != a.Handle {
+		t.Errorf("the token data %v should identify the account", data)
+	}
+	for _, k := range []string{"email", "role", "flags"} {
+		if _, ok := data[k]; ok {
+			t.Errorf("the token data %v should not contain the %s of the account", data, k)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package frontend
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/app/db"
+	"github.com/mariusor/littr.go/app/ratelimit"
+	"github.com/mariusor/littr.go/app/totp"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// SessionTwoFactorKey holds the account which passed the password check and still needs the TOTP code
+const SessionTwoFactorKey = "__2fa_pending"
+
+// SessionTwoFactorSecretKey holds the secret of a TOTP enrollment until it's confirmed with a code
+const SessionTwoFactorSecretKey = "__2fa_secret"
+
+// TwoFactorLoginTTL is the duration for which the second login step is valid after the password check
+const TwoFactorLoginTTL = 5 * time.Minute
+
+// MaxTwoFactorAttempts is the number of codes which can be tried for an account in a TwoFactorLockPeriod
+const MaxTwoFactorAttempts = 5
+
+// TwoFactorLockPeriod is the duration for which the second login step is locked after MaxTwoFactorAttempts codes
+const TwoFactorLockPeriod = 15 * time.Minute
+
+// RecoveryCodesCount is the number of recovery codes generated for an account
+const RecoveryCodesCount = 10
+
+// pendingLogin is the account waiting for the second login step
+type pendingLogin struct {
+	Handle string
+	Hash   []byte
+	Since  time.Time
+}
+
+// twoFactorAttempts counts the codes tried when the rate limiter doesn't have a store
+var twoFactorAttempts ratelimit.Store = ratelimit.NewMemoryStore()
+
+type twoFactorLoginModel struct {
+	Title string
+}
+
+type twoFactorModel struct {
+	Title         string
+	Account       app.Account
+	Secret        string
+	QRCode        template.URL
+	RecoveryCodes []string
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// normalizeRecoveryCode removes the formatting of a recovery code, so it can be typed in any case, with or without dashes
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// newRecoveryCodes returns the recovery codes to show to the user and their hashes to store on the account
+func newRecoveryCodes() ([]string, [][]byte, error) {
+	codes := make([]string, RecoveryCodesCount)
+	hashes := make([][]byte, RecoveryCodesCount)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, errors.Annotatef(err, "unable to generate recovery codes")
+		}
+		c := strings.ToLower(recoveryEncoding.EncodeToString(b))
+		codes[i] = fmt.Sprintf("%s-%s", c[:4], c[4:])
+		h, err := bcrypt.GenerateFromPassword([]byte(c), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, nil, errors.Annotatef(err, "unable to generate recovery codes")
+		}
+		hashes[i] = h
+	}
+	return codes, hashes, nil
+}
+
+// validateSecondFactor checks "code" against the TOTP secret, decrypted with "key", and the recovery codes of the "a" account.
+// An accepted code can't be used again, so the account needs to be saved after a valid code.
+func validateSecondFactor(a *app.Account, key []byte, code string, now time.Time) (bool, bool) {
+	if !a.HasTwoFactor() {
+		return false, false
+	}
+	tf := a.Metadata.TwoFactor
+	if validateTOTP(tf, key, code, now) {
+		return true, false
+	}
+	rc := normalizeRecoveryCode(code)
+	if len(rc) == 0 {
+		return false, false
+	}
+	for i, h := range tf.RecoveryCodes {
+		if bcrypt.CompareHashAndPassword(h, []byte(rc)) == nil {
+			tf.RecoveryCodes = append(tf.RecoveryCodes[:i:i], tf.RecoveryCodes[i+1:]...)
+			return true, true
+		}
+	}
+	return false, false
+}
+
+// validateTOTP checks "code" against the secret of "tf" and saves its time step as the last accepted one
+func validateTOTP(tf *app.TwoFactor, key []byte, code string, now time.Time) bool {
+	secret, err := totp.Open(key, tf.Secret)
+	if err != nil {
+		return false
+	}
+	step, ok := totp.ValidateAfter(secret, code, now, tf.LastStep)
+	if ok {
+		tf.LastStep = step
+	}
+	return ok
+}
+
+// twoFactorQRCode returns the enrollment QR code of "secret" as a data URI which can be used as an image source
+func (h *handler) twoFactorQRCode(a app.Account, secret string) (template.URL, error) {
+	png, err := totp.QRCode(totp.URI(h.conf.HostName, a.Handle, secret), 256)
+	if err != nil {
+		return "", err
+	}
+	return template.URL(fmt.Sprintf("data:image/png;base64,%s", base64.StdEncoding.EncodeToString(png))), nil
+}
+
+// loadPendingLogin returns the account waiting for the second login step, if it didn't expire
+func (h *handler) loadPendingLogin(r *http.Request) (pendingLogin, bool) {
+	s, err := h.sstor.Get(r, sessionName)
+	if err != nil {
+		return pendingLogin{}, false
+	}
+	p, ok := s.Values[SessionTwoFactorKey].(pendingLogin)
+	if !ok || time.Now().UTC().Sub(p.Since) > TwoFactorLoginTTL {
+		return pendingLogin{}, false
+	}
+	return p, true
+}
+
+// twoFactorAttempt counts a code tried for the "a" account, and returns an error when there were too many of them.
+// The count is kept server side, in the rate limit store, so replaying an older session cookie doesn't reset it.
+func (h *handler) twoFactorAttempt(a app.Account, now time.Time) error {
+	st := h.conf.RateLimiter.Store
+	if st == nil {
+		st = twoFactorAttempts
+	}
+	hits, resetAt, err := st.Hit(fmt.Sprintf("2fa:account:%s", a.Hash), TwoFactorLockPeriod, now)
+	if err != nil {
+		return errors.Annotatef(err, "unable to count the second factor attempts")
+	}
+	if hits > MaxTwoFactorAttempts {
+		retry := time.Duration(math.Ceil(resetAt.Sub(now).Minutes())) * time.Minute
+		return errors.TooManyRequestsf(retry, "too many wrong codes, please try again in %s", retry)
+	}
+	return nil
+}
+
+// ShowLoginTwoFactor serves GET /login/2fa request
+func (h *handler) ShowLoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.loadPendingLogin(r); !ok {
+		h.addFlashMessage(Error, r, "Your login has expired, please try again")
+		h.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+	h.RenderTemplate(r, w, "login-2fa", twoFactorLoginModel{Title: "Two-factor authentication"})
+}
+
+// HandleLoginTwoFactor serves POST /login/2fa request
+// The session is created, and the OAuth2 code issued, only after the second factor is validated.
+func (h *handler) HandleLoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	p, ok := h.loadPendingLogin(r)
+	if !ok {
+		h.addFlashMessage(Error, r, "Your login has expired, please try again")
+		h.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+	a, err := db.Config.LoadAccount(app.Filters{LoadAccountsFilter: app.LoadAccountsFilter{Handle: []string{p.Handle}}})
+	if err != nil || a.Hash.String() != string(p.Hash) {
+		h.addFlashMessage(Error, r, "Login failed")
+		h.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+	if err := h.twoFactorAttempt(a, time.Now().UTC()); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"handle": a.Handle,
+		}).Warn(err.Error())
+		if s, err := h.sstor.Get(r, sessionName); err == nil {
+			delete(s.Values, SessionTwoFactorKey)
+		}
+		msg := "Login failed"
+		if errors.IsTooManyRequests(err) {
+			msg = fmt.Sprintf("Login failed: %s", err)
+		}
+		h.addFlashMessage(Error, r, msg)
+		h.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+	valid, recovery := validateSecondFactor(&a, h.conf.TwoFactorKey, r.PostFormValue("code"), time.Now().UTC())
+	if !valid {
+		h.logger.WithContext(log.Ctx{
+			"handle": a.Handle,
+		}).Warn("invalid second factor")
+		h.addFlashMessage(Error, r, "Login failed: wrong code")
+		h.Redirect(w, r, "/login/2fa", http.StatusSeeOther)
+		return
+	}
+	a.UpdatedAt = time.Now().UTC()
+	if _, err := db.Config.SaveAccount(a); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"handle": a.Handle,
+		}).Error(err.Error())
+		h.HandleErrors(w, r, errors.NewNotValid(err, "unable to use the code"))
+		return
+	}
+	if recovery {
+		h.addFlashMessage(Info, r, fmt.Sprintf("You used a recovery code, %d are left", len(a.Metadata.TwoFactor.RecoveryCodes)))
+	}
+	h.loginAccount(w, r, a)
+}
+
+// ShowTwoFactor serves GET /~{handle}/2fa request
+// When the second factor is not enabled it starts a new enrollment, with the secret kept in the session until confirmed.
+func (h *handler) ShowTwoFactor(w http.ResponseWriter, r *http.Request) {
+	m := twoFactorModel{Title: "Two-factor authentication", Account: h.account}
+	if !h.account.HasTwoFactor() {
+		if len(h.conf.TwoFactorKey) == 0 {
+			h.HandleErrors(w, r, errors.NotImplementedf("two-factor authentication is not available"))
+			return
+		}
+		secret, err := totp.NewSecret()
+		if err != nil {
+			h.HandleErrors(w, r, err)
+			return
+		}
+		if m.QRCode, err = h.twoFactorQRCode(h.account, secret); err != nil {
+			h.logger.Error(err.Error())
+		}
+		m.Secret = secret
+		s, err := h.sstor.Get(r, sessionName)
+		if err != nil {
+			h.HandleErrors(w, r, err)
+			return
+		}
+		s.Values[SessionTwoFactorSecretKey] = secret
+		if err := s.Save(r, w); err != nil {
+			h.HandleErrors(w, r, err)
+			return
+		}
+	}
+	h.RenderTemplate(r, w, "two-factor", m)
+}
+
+// HandleTwoFactor serves POST /~{handle}/2fa request
+// It enables the second factor, generates new recovery codes or disables it.
+func (h *handler) HandleTwoFactor(w http.ResponseWriter, r *http.Request) {
+	acc := h.account
+	meta := app.AccountMetadata{}
+	if acc.Metadata != nil {
+		meta = *acc.Metadata
+	}
+	acc.Metadata = &meta
+	back := fmt.Sprintf("%s/2fa", acc.GetLink())
+
+	if err := checkPassword(acc, r.PostFormValue("current-pw")); err != nil {
+		h.addFlashMessage(Error, r, "the current password is wrong")
+		h.Redirect(w, r, back, http.StatusSeeOther)
+		return
+	}
+	code := r.PostFormValue("code")
+	m := twoFactorModel{Title: "Two-factor authentication", Account: acc}
+	action := r.PostFormValue("action")
+	switch action {
+	case "enable":
+		s, err := h.sstor.Get(r, sessionName)
+		if err != nil {
+			h.HandleErrors(w, r, err)
+			return
+		}
+		secret, _ := s.Values[SessionTwoFactorSecretKey].(string)
+		step, ok := totp.ValidateAfter(secret, code, time.Now().UTC(), -1)
+		if len(secret) == 0 || !ok {
+			h.addFlashMessage(Error, r, "the code is wrong, check the time of your device and scan the new QR code")
+			h.Redirect(w, r, back, http.StatusSeeOther)
+			return
+		}
+		sealed, err := totp.Seal(h.conf.TwoFactorKey, secret)
+		if err != nil {
+			h.HandleErrors(w, r, errors.NewNotImplemented(err, "two-factor authentication is not available"))
+			return
+		}
+		delete(s.Values, SessionTwoFactorSecretKey)
+		s.Save(r, w)
+		meta.TwoFactor = &app.TwoFactor{Secret: sealed, LastStep: step}
+	case "recovery", "disable":
+		if !acc.HasTwoFactor() {
+			h.Redirect(w, r, back, http.StatusSeeOther)
+			return
+		}
+		tf := *meta.TwoFactor
+		meta.TwoFactor = &tf
+		if !validateTOTP(&tf, h.conf.TwoFactorKey, code, time.Now().UTC()) {
+			h.addFlashMessage(Error, r, "the code is wrong")
+			h.Redirect(w, r, back, http.StatusSeeOther)
+			return
+		}
+		if action == "disable" {
+			meta.TwoFactor = nil
+		}
+	default:
+		h.HandleErrors(w, r, errors.BadRequestf("invalid action %q", action))
+		return
+	}
+	if meta.TwoFactor != nil {
+		codes, hashes, err := newRecoveryCodes()
+		if err != nil {
+			h.HandleErrors(w, r, err)
+			return
+		}
+		meta.TwoFactor.RecoveryCodes = hashes
+		m.RecoveryCodes = codes
+	}
+	acc.UpdatedAt = time.Now().UTC()
+	if _, err := db.Config.SaveAccount(acc); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"handle": acc.Handle,
+		}).Error(err.Error())
+		h.HandleErrors(w, r, errors.NewNotValid(err, "unable to save the account"))
+		return
+	}
+	if action == "disable" {
+		h.addFlashMessage(Success, r, "Two-factor authentication was disabled")
+		h.Redirect(w, r, fmt.Sprintf("%s/settings", acc.GetLink()), http.StatusSeeOther)
+		return
+	}
+	// the recovery codes are shown only once
+	m.Account = acc
+	h.RenderTemplate(r, w, "two-factor", m)
+}
@@ -0,0 +1,72 @@
+package frontend
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/app/ratelimit"
+	"github.com/mariusor/littr.go/app/totp"
+	"github.com/mariusor/littr.go/internal/errors"
+)
+
+func TestTwoFactorAttempt(t *testing.T) {
+	h := handler{conf: Config{RateLimiter: ratelimit.Limiter{Store: ratelimit.NewMemoryStore()}}}
+	jane := app.Account{Hash: app.Hash("dc6f5f5bf55bc1073715c98c69fa7ca8"), Handle: "jane"}
+	john := app.Account{Hash: app.Hash("162edb32c80d0e6dd3114fbb59d6273b"), Handle: "john"}
+	now := time.Now().UTC()
+
+	for i := 0; i < MaxTwoFactorAttempts; i++ {
+		if err := h.twoFactorAttempt(jane, now); err != nil {
+			t.Fatalf("attempt %d should be allowed: %s", i+1, err)
+		}
+	}
+	if err := h.twoFactorAttempt(jane, now); !errors.IsTooManyRequests(err) {
+		t.Errorf("the login of %s should be locked after %d attempts, got %v", jane.Handle, MaxTwoFactorAttempts, err)
+	}
+	if err := h.twoFactorAttempt(john, now); err != nil {
+		t.Errorf("the login of %s should not be locked: %s", john.Handle, err)
+	}
+	if err := h.twoFactorAttempt(jane, now.Add(TwoFactorLockPeriod)); err != nil {
+		t.Errorf("the login of %s should be unlocked after %s: %s", jane.Handle, TwoFactorLockPeriod, err)
+	}
+}
+
+func TestValidateSecondFactor(t *testing.T) {
+	key := []byte("totp-server-key")
+	secret, _ := totp.NewSecret()
+	sealed, err := totp.Seal(key, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := app.Account{Metadata: &app.AccountMetadata{TwoFactor: &app.TwoFactor{Secret: sealed, RecoveryCodes: hashes}}}
+	now := time.Now().UTC()
+	code, _ := totp.Code(secret, now)
+
+	if valid, _ := validateSecondFactor(&a, []byte("another-key"), code, now); valid {
+		t.Errorf("the code was accepted with a different server key")
+	}
+	if valid, recovery := validateSecondFactor(&a, key, code, now); !valid || recovery {
+		t.Fatalf("the current code was not accepted")
+	}
+	if a.Metadata.TwoFactor.LastStep == 0 {
+		t.Errorf("the time step of the accepted code was not saved")
+	}
+	if valid, _ := validateSecondFactor(&a, key, code, now.Add(totp.Period)); valid {
+		t.Errorf("the code was accepted again")
+	}
+	if valid, recovery := validateSecondFactor(&a, key, strings.ToUpper(codes[0]), now); !valid || !recovery {
+		t.Errorf("the recovery code was not accepted")
+	}
+	if len(a.Metadata.TwoFactor.RecoveryCodes) != RecoveryCodesCount-1 {
+		t.Errorf("the used recovery code was not removed")
+	}
+	if valid, _ := validateSecondFactor(&a, key, codes[0], now); valid {
+		t.Errorf("the recovery code was accepted again")
+	}
+}
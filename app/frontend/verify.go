@@ -0,0 +1,169 @@
+package frontend
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/app/db"
+	"github.com/mariusor/littr.go/app/mail"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+)
+
+// VerifyTokenTTL is the duration for which an email verification link is valid
+const VerifyTokenTTL = 48 * time.Hour
+
+// verifySignature signs the account hash, its email and the expiration time of a verification token.
+// The email is part of the signature, so the token can't be used to confirm a different address.
+func verifySignature(key []byte, a app.Account, expires int64) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("verify"))
+	mac.Write([]byte(a.Hash))
+	mac.Write([]byte(a.Email))
+	mac.Write([]byte(strconv.FormatInt(expires, 10)))
+	return mac.Sum(nil)
+}
+
+// newVerifyToken returns a token with the format: hash.expires.signature
+func newVerifyToken(key []byte, a app.Account, now time.Time) string {
+	expires := now.Add(VerifyTokenTTL).Unix()
+	sig := verifySignature(key, a, expires)
+	return fmt.Sprintf("%s.%d.%s", a.Hash, expires, base64.RawURLEncoding.EncodeToString(sig))
+}
+
+// loadVerifyAccount returns the account the "tok" verification token was generated for, if the token is still valid
+func (h *handler) loadVerifyAccount(tok string) (app.Account, error) {
+	key, err := h.signingKey()
+	if err != nil {
+		return app.Account{}, err
+	}
+	invalid := errors.Forbiddenf("the verification link is invalid or it has expired")
+	hash, expires, sig, err := parseSignedToken(tok)
+	if err != nil || len(hash) == 0 {
+		return app.Account{}, invalid
+	}
+	if time.Now().UTC().Unix() > expires {
+		return app.Account{}, invalid
+	}
+	a, err := db.Config.LoadAccount(app.Filters{LoadAccountsFilter: app.LoadAccountsFilter{Key: app.Hashes{hash}}})
+	if err != nil || len(a.Email) == 0 {
+		return app.Account{}, invalid
+	}
+	if !hmac.Equal(sig, verifySignature(key, a, expires)) {
+		return app.Account{}, invalid
+	}
+	return a, nil
+}
+
+// sendVerification mails the link for confirming the email address of the "a" account
+func (h *handler) sendVerification(a app.Account) error {
+	if len(a.Email) == 0 {
+		return errors.BadRequestf("the account doesn't have an email address")
+	}
+	if h.conf.Mailer == nil {
+		return errors.NotImplementedf("email verification is not available")
+	}
+	key, err := h.signingKey()
+	if err != nil {
+		return err
+	}
+	link := fmt.Sprintf("%s/verify/%s", h.conf.BaseURL, newVerifyToken(key, a, time.Now().UTC()))
+	body := bytes.Buffer{}
+	fmt.Fprintf(&body, "Hello %s,\n\n", a.Handle)
+	fmt.Fprintf(&body, "Please confirm the email address of your new account on %s by following the link below in the next %s:\n\n", h.conf.HostName, VerifyTokenTTL)
+	fmt.Fprintf(&body, "%s\n\n", link)
+	fmt.Fprintf(&body, "Until then you won't be able to submit content or to vote.\n")
+	fmt.Fprintf(&body, "If you didn't create this account, you can ignore this message.\n")
+
+	m := mail.Message{
+		To:      a.Email,
+		Subject: fmt.Sprintf("Confirm your email address on %s", h.conf.HostName),
+		Body:    body.String(),
+	}
+	return h.conf.Mailer.Send(m)
+}
+
+// ValidateVerified lets through only the requests of the accounts which confirmed their email address
+func (h *handler) ValidateVerified(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if err := h.account.ValidateInteraction(); err != nil {
+			h.HandleErrors(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// HandleVerify serves GET /verify/{token} request
+func (h *handler) HandleVerify(w http.ResponseWriter, r *http.Request) {
+	tok := chi.URLParam(r, "token")
+	a, err := h.loadVerifyAccount(tok)
+	if err != nil {
+		h.HandleErrors(w, r, err)
+		return
+	}
+	if !a.IsVerified() {
+		a.Verify()
+		a.UpdatedAt = time.Now().UTC()
+		if _, err := db.Config.SaveAccount(a); err != nil {
+			h.logger.WithContext(log.Ctx{
+				"handle": a.Handle,
+			}).Error(err.Error())
+			h.HandleErrors(w, r, errors.NewNotValid(err, "unable to verify the account"))
+			return
+		}
+	}
+	h.addFlashMessage(Success, r, "Your email address was confirmed")
+	h.Redirect(w, r, a.GetLink(), http.StatusSeeOther)
+}
+
+// HandleResendVerification serves GET /verify request
+func (h *handler) HandleResendVerification(w http.ResponseWriter, r *http.Request) {
+	a := h.account
+	if a.IsVerified() {
+		h.addFlashMessage(Info, r, "Your email address is already confirmed")
+	} else if err := h.sendVerification(a); err != nil {
+		h.logger.WithContext(log.Ctx{
+			"handle": a.Handle,
+		}).Error(err.Error())
+		h.addFlashMessage(Error, r, "Unable to send the verification email")
+	} else {
+		h.addFlashMessage(Info, r, fmt.Sprintf("We sent a new verification link to %s", a.Email))
+	}
+	h.Redirect(w, r, a.GetLink(), http.StatusSeeOther)
+}
+
+// HandleAccountVerify serves POST /~{handle}/verify request
+// It allows moderators to confirm an account without the emailed link.
+func (h *handler) HandleAccountVerify(w http.ResponseWriter, r *http.Request) {
+	handle := chi.URLParam(r, "handle")
+	a, err := db.Config.LoadAccount(app.Filters{LoadAccountsFilter: app.LoadAccountsFilter{Handle: []string{handle}}})
+	if err != nil {
+		h.HandleErrors(w, r, errors.NewNotFound(err, "not found"))
+		return
+	}
+	if !a.IsVerified() {
+		a.Verify()
+		a.UpdatedAt = time.Now().UTC()
+		if _, err := db.Config.SaveAccount(a); err != nil {
+			h.logger.WithContext(log.Ctx{
+				"handle":    a.Handle,
+				"moderator": h.account.Handle,
+			}).Error(err.Error())
+			h.addFlashMessage(Error, r, "unable to verify account")
+			h.Redirect(w, r, a.GetLink(), http.StatusSeeOther)
+			return
+		}
+	}
+	h.addFlashMessage(Success, r, "account verified")
+	h.Redirect(w, r, a.GetLink(), http.StatusSeeOther)
+}
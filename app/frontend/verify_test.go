@@ -0,0 +1,41 @@
+package frontend
+
+import (
+	"crypto/hmac"
+	"testing"
+	"time"
+
+	"github.com/mariusor/littr.go/app"
+)
+
+func TestNewVerifyToken(t *testing.T) {
+	key := []byte("dsa3Thahquoh9chaeGhoo8ohshieSoo1")
+	a := app.Account{
+		Hash:   app.Hash("dc6f5f5bf55bc1073715c98c69fa7ca8"),
+		Handle: "jane",
+		Email:  "jane@example.com",
+	}
+	now := time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	hash, expires, sig, err := parseSignedToken(newVerifyToken(key, a, now))
+	if err != nil {
+		t.Fatalf("unable to parse the verification token: %s", err)
+	}
+	if hash != a.Hash {
+		t.Errorf("invalid hash %s, expected %s", hash, a.Hash)
+	}
+	if exp := now.Add(VerifyTokenTTL).Unix(); expires != exp {
+		t.Errorf("invalid expiration time %d, expected %d", expires, exp)
+	}
+	if !hmac.Equal(sig, verifySignature(key, a, expires)) {
+		t.Errorf("the verification token signature should be valid")
+	}
+	changed := a
+	changed.Email = "eve@example.com"
+	if hmac.Equal(sig, verifySignature(key, changed, expires)) {
+		t.Errorf("the verification token signature should not be valid for a different email")
+	}
+	if hmac.Equal(sig, resetSignature(key, a, expires)) {
+		t.Errorf("the verification token should not be usable as a password reset token")
+	}
+}
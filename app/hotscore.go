@@ -22,7 +22,7 @@ func Wilson(ups, downs int64) float64 {
 
 	n1 := float64(n)
 	z := StatisticalConfidence
-	p := float64(ups / n)
+	p := float64(ups) / n1
 	zzfn := z * z / (4 * n1)
 	w := (p + 2.0*zzfn - z*math.Sqrt((zzfn/n1+p*(1.0-p))/n1)) / (1 + 4*zzfn)
 
@@ -0,0 +1,24 @@
+package app
+
+import "testing"
+
+// TestWilson checks the proportion of up votes is computed on floats:
+// with an integer division every item without unanimous votes had the score of an item with no up votes.
+func TestWilson(t *testing.T) {
+	if w := Wilson(0, 0); w != 0 {
+		t.Errorf("invalid score %f for no votes, expected 0", w)
+	}
+	if Wilson(99, 1) <= Wilson(0, 1) {
+		t.Errorf("invalid score %f for 99 ups and 1 down, expected more than %f", Wilson(99, 1), Wilson(0, 1))
+	}
+	if w := Wilson(99, 1); w < 0.9 || w > 1 {
+		t.Errorf("invalid score %f for 99 ups and 1 down, expected between 0.9 and 1", w)
+	}
+	ordered := [][2]int64{{100, 1}, {10, 1}, {5, 5}, {1, 10}}
+	for k := 1; k < len(ordered); k++ {
+		prev, cur := ordered[k-1], ordered[k]
+		if Wilson(prev[0], prev[1]) <= Wilson(cur[0], cur[1]) {
+			t.Errorf("score for %v should be greater than the one for %v", prev, cur)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package app
+
+import "time"
+
+// Identity links an account to its account at an external OAuth2 provider, which it can log in with
+type Identity struct {
+	Provider string `json:"provider"`
+	// Subject is the identifier of the account at the provider
+	Subject   string    `json:"subject"`
+	Handle    string    `json:"handle,omitempty"`
+	Email     string    `json:"email,omitempty"`
+	Account   *Account  `json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+	LastLogin time.Time `json:"lastLogin,omitempty"`
+}
+
+type IdentityCollection []Identity
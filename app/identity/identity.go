@@ -0,0 +1,295 @@
+// Package identity loads the profiles of the accounts of external OAuth2 providers,
+// so they can be used for logging in and for creating local accounts.
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/mariusor/littr.go/internal/errors"
+	"golang.org/x/oauth2"
+)
+
+// Profile is the account of a user at an external provider
+type Profile struct {
+	Provider string
+	// ID is the identifier of the account at the provider, it doesn't change when the user renames it
+	ID     string
+	Handle string
+	Name   string
+	Email  string
+	// EmailVerified is set when the provider confirmed the user owns the email address
+	EmailVerified bool
+}
+
+// Provider is an external OAuth2 provider the users can log in with
+type Provider struct {
+	Name   string
+	Label  string
+	Config oauth2.Config
+	// ProfileURL returns the profile of the user the access token was issued for
+	ProfileURL string
+	// EmailsURL returns the email addresses of the user, for the providers which don't include the verified one in the profile
+	EmailsURL string
+	parse     func([]byte) (Profile, error)
+	// oidc checks the ID tokens of the OpenID Connect providers, which are loaded from their claims
+	oidc *oidcVerifier
+}
+
+// known are the providers which can be configured through the {NAME}_KEY and {NAME}_SECRET environment variables
+var known = map[string]Provider{
+	"github": {
+		Name:  "github",
+		Label: "Github",
+		Config: oauth2.Config{
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  "https://github.com/login/oauth/authorize",
+				TokenURL: "https://github.com/login/oauth/access_token",
+			},
+			Scopes: []string{"read:user", "user:email"},
+		},
+		ProfileURL: "https://api.github.com/user",
+		EmailsURL:  "https://api.github.com/user/emails",
+		parse:      parseGithub,
+	},
+	"gitlab": {
+		Name:  "gitlab",
+		Label: "Gitlab",
+		Config: oauth2.Config{
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  "https://gitlab.com/oauth/authorize",
+				TokenURL: "https://gitlab.com/oauth/token",
+			},
+			Scopes: []string{"read_user"},
+		},
+		ProfileURL: "https://gitlab.com/api/v4/user",
+		parse:      parseGitlab,
+	},
+	"google": {
+		Name:  "google",
+		Label: "Google",
+		Config: oauth2.Config{
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  "https://accounts.google.com/o/oauth2/auth",
+				TokenURL: "https://accounts.google.com/o/oauth2/token",
+			},
+			Scopes: []string{"openid", "email", "profile"},
+		},
+		ProfileURL: "https://openidconnect.googleapis.com/v1/userinfo",
+		parse:      parseGoogle,
+	},
+	"facebook": {
+		Name:  "facebook",
+		Label: "Facebook",
+		Config: oauth2.Config{
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  "https://graph.facebook.com/oauth/authorize",
+				TokenURL: "https://graph.facebook.com/oauth/access_token",
+			},
+			Scopes: []string{"email"},
+		},
+		ProfileURL: "https://graph.facebook.com/me?fields=id,name,email",
+		parse:      parseFacebook,
+	},
+}
+
+// Load returns the "name" provider with the credentials from the environment.
+// It returns a not found error for the unknown providers and for the ones which are not configured.
+// The OpenID Connect providers load their end-points from the discovery document of the issuer.
+func Load(name string, redirectURL string) (Provider, error) {
+	p, ok := known[strings.ToLower(name)]
+	if !ok {
+		p, err := loadOIDC(context.Background(), strings.ToLower(name))
+		p.Config.RedirectURL = redirectURL
+		return p, err
+	}
+	env := strings.ToUpper(p.Name)
+	p.Config.ClientID = os.Getenv(fmt.Sprintf("%s_KEY", env))
+	p.Config.ClientSecret = os.Getenv(fmt.Sprintf("%s_SECRET", env))
+	if len(p.Config.ClientID) == 0 {
+		return p, errors.NotFoundf("provider %q is not configured", name)
+	}
+	p.Config.RedirectURL = redirectURL
+	return p, nil
+}
+
+// Available returns the labels of the configured providers, by their names
+func Available() map[string]string {
+	res := make(map[string]string)
+	for name, p := range known {
+		if len(os.Getenv(fmt.Sprintf("%s_KEY", strings.ToUpper(name)))) > 0 {
+			res[name] = p.Label
+		}
+	}
+	for _, name := range oidcNames() {
+		if oidcConfigured(name) {
+			res[name] = oidcLabel(name)
+		}
+	}
+	return res
+}
+
+// AuthCodeURL returns the URL of the provider's consent page, the "state" is also sent as the nonce of the ID token
+func (p Provider) AuthCodeURL(state string, opts ...oauth2.AuthCodeOption) string {
+	if p.oidc != nil {
+		opts = append(opts, oauth2.SetAuthURLParam("nonce", state))
+	}
+	return p.Config.AuthCodeURL(state, opts...)
+}
+
+// Exchange returns the access token for the authorization code the provider redirected back with
+func (p Provider) Exchange(ctx context.Context, code string) (*oauth2.Token, error) {
+	tok, err := p.Config.Exchange(ctx, code)
+	if err != nil {
+		return nil, errors.Annotatef(err, "unable to exchange the %s authorization code", p.Label)
+	}
+	return tok, nil
+}
+
+// FetchProfile loads the profile of the user the "tok" access token was issued for.
+// The "nonce" is checked against the ID token of the OpenID Connect providers.
+func (p Provider) FetchProfile(ctx context.Context, tok *oauth2.Token, nonce string) (Profile, error) {
+	if p.oidc != nil {
+		return p.oidcProfile(ctx, tok, nonce)
+	}
+	c := p.Config.Client(ctx, tok)
+	body, err := get(c, p.ProfileURL)
+	if err != nil {
+		return Profile{}, errors.Annotatef(err, "unable to load the %s profile", p.Label)
+	}
+	prof, err := p.parse(body)
+	if err != nil {
+		return prof, errors.Annotatef(err, "invalid %s profile", p.Label)
+	}
+	if len(p.EmailsURL) > 0 && !prof.EmailVerified {
+		if body, err := get(c, p.EmailsURL); err == nil {
+			if email, ok := primaryEmail(body); ok {
+				prof.Email = email
+				prof.EmailVerified = true
+			}
+		}
+	}
+	if len(prof.ID) == 0 {
+		return prof, errors.NotValidf("the %s profile is missing the account identifier", p.Label)
+	}
+	prof.Provider = p.Name
+	return prof, nil
+}
+
+func get(c *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected response status %s", res.Status)
+	}
+	return ioutil.ReadAll(res.Body)
+}
+
+func parseGithub(data []byte) (Profile, error) {
+	var u struct {
+		ID    json.Number `json:"id"`
+		Login string      `json:"login"`
+		Name  string      `json:"name"`
+		Email string      `json:"email"`
+	}
+	if err := json.Unmarshal(data, &u); err != nil {
+		return Profile{}, err
+	}
+	// the public email of the profile is not necessarily verified, it gets replaced by the primary verified one
+	return Profile{ID: u.ID.String(), Handle: u.Login, Name: u.Name, Email: u.Email}, nil
+}
+
+func parseGitlab(data []byte) (Profile, error) {
+	var u struct {
+		ID          json.Number `json:"id"`
+		Username    string      `json:"username"`
+		Name        string      `json:"name"`
+		Email       string      `json:"email"`
+		ConfirmedAt string      `json:"confirmed_at"`
+	}
+	if err := json.Unmarshal(data, &u); err != nil {
+		return Profile{}, err
+	}
+	return Profile{ID: u.ID.String(), Handle: u.Username, Name: u.Name, Email: u.Email, EmailVerified: len(u.ConfirmedAt) > 0}, nil
+}
+
+func parseGoogle(data []byte) (Profile, error) {
+	var u struct {
+		Sub           string `json:"sub"`
+		Name          string `json:"name"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+	}
+	if err := json.Unmarshal(data, &u); err != nil {
+		return Profile{}, err
+	}
+	return Profile{ID: u.Sub, Name: u.Name, Email: u.Email, EmailVerified: u.EmailVerified}, nil
+}
+
+// parseFacebook loads the profile with an unverified email, the Graph API doesn't tell if the address was confirmed,
+// so the accounts created from it need to verify the address like the ones registered with a password.
+func parseFacebook(data []byte) (Profile, error) {
+	var u struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(data, &u); err != nil {
+		return Profile{}, err
+	}
+	return Profile{ID: u.ID, Name: u.Name, Email: u.Email, EmailVerified: false}, nil
+}
+
+// primaryEmail returns the primary verified address from a Github list of emails
+func primaryEmail(data []byte) (string, bool) {
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := json.Unmarshal(data, &emails); err != nil {
+		return "", false
+	}
+	for _, e := range emails {
+		if e.Primary && e.Verified {
+			return e.Email, true
+		}
+	}
+	return "", false
+}
+
+var invalidHandleChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// SuggestHandle returns a handle for the local account created from the "p" profile.
+// It uses the handle at the provider, the email user or the name, whichever is usable first.
+func SuggestHandle(p Profile) string {
+	candidates := []string{p.Handle}
+	if i := strings.Index(p.Email, "@"); i > 0 {
+		candidates = append(candidates, p.Email[:i])
+	}
+	candidates = append(candidates, p.Name)
+	for _, c := range candidates {
+		h := strings.Trim(invalidHandleChars.ReplaceAllString(c, "_"), "_.-")
+		if len(h) > 32 {
+			h = h[:32]
+		}
+		if len(h) > 0 {
+			return h
+		}
+	}
+	return "user"
+}
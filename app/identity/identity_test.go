@@ -0,0 +1,175 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+// fakeProvider serves the token, profile and emails end-points of a Github like provider
+func fakeProvider(t *testing.T, profile string, emails string) (*httptest.Server, Provider) {
+	const token = "fake-access-token"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("code") != "fake-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"invalid_grant"}`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":%q,"token_type":"bearer"}`, token)
+	})
+	authorized := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer "+token {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next(w, r)
+		}
+	}
+	mux.HandleFunc("/user", authorized(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, profile)
+	}))
+	mux.HandleFunc("/user/emails", authorized(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, emails)
+	}))
+	srv := httptest.NewServer(mux)
+
+	p := known["github"]
+	p.Config.ClientID = "fake-key"
+	p.Config.ClientSecret = "fake-secret"
+	p.Config.Endpoint = oauth2.Endpoint{
+		AuthURL:  srv.URL + "/authorize",
+		TokenURL: srv.URL + "/token",
+	}
+	p.ProfileURL = srv.URL + "/user"
+	p.EmailsURL = srv.URL + "/user/emails"
+	return srv, p
+}
+
+func TestProvider_FetchProfile(t *testing.T) {
+	srv, p := fakeProvider(t,
+		`{"id":1234,"login":"jane-doe","name":"Jane Doe","email":"public@example.com"}`,
+		`[{"email":"other@example.com","primary":false,"verified":true},{"email":"jane@example.com","primary":true,"verified":true}]`,
+	)
+	defer srv.Close()
+
+	ctx := context.Background()
+	tok, err := p.Exchange(ctx, "fake-code")
+	if err != nil {
+		t.Fatalf("unable to exchange the code: %s", err)
+	}
+	prof, err := p.FetchProfile(ctx, tok, "")
+	if err != nil {
+		t.Fatalf("unable to load the profile: %s", err)
+	}
+	exp := Profile{
+		Provider:      "github",
+		ID:            "1234",
+		Handle:        "jane-doe",
+		Name:          "Jane Doe",
+		Email:         "jane@example.com",
+		EmailVerified: true,
+	}
+	if prof != exp {
+		t.Errorf("invalid profile %#v, expected %#v", prof, exp)
+	}
+
+	if _, err := p.Exchange(ctx, "wrong-code"); err == nil {
+		t.Errorf("the exchange of an invalid code should fail")
+	}
+}
+
+func TestProvider_FetchProfileUnverifiedEmail(t *testing.T) {
+	srv, p := fakeProvider(t,
+		`{"id":1234,"login":"jane-doe","email":"public@example.com"}`,
+		`[{"email":"public@example.com","primary":true,"verified":false}]`,
+	)
+	defer srv.Close()
+
+	prof, err := p.FetchProfile(context.Background(), &oauth2.Token{AccessToken: "fake-access-token"}, "")
+	if err != nil {
+		t.Fatalf("unable to load the profile: %s", err)
+	}
+	if prof.EmailVerified || prof.Email != "public@example.com" {
+		t.Errorf("the email %q should not be verified", prof.Email)
+	}
+}
+
+func TestProvider_FetchProfileMissingID(t *testing.T) {
+	srv, p := fakeProvider(t, `{"login":"jane-doe"}`, `[]`)
+	defer srv.Close()
+
+	if _, err := p.FetchProfile(context.Background(), &oauth2.Token{AccessToken: "fake-access-token"}, ""); err == nil {
+		t.Errorf("a profile without identifier should not be accepted")
+	}
+	if _, err := p.FetchProfile(context.Background(), &oauth2.Token{AccessToken: "wrong"}, ""); err == nil {
+		t.Errorf("an unauthorized profile request should fail")
+	}
+}
+
+func TestParseFacebook(t *testing.T) {
+	prof, err := parseFacebook([]byte(`{"id":"10101","name":"Jane Doe","email":"jane@example.com"}`))
+	if err != nil {
+		t.Fatalf("unable to parse the profile: %s", err)
+	}
+	// the email of a facebook profile is never considered confirmed
+	if exp := (Profile{ID: "10101", Name: "Jane Doe", Email: "jane@example.com"}); prof != exp {
+		t.Errorf("invalid profile %#v, expected %#v", prof, exp)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	os.Setenv("GITLAB_KEY", "key")
+	os.Setenv("GITLAB_SECRET", "secret")
+	os.Unsetenv("GOOGLE_KEY")
+	os.Setenv("FACEBOOK_KEY", "key")
+	defer os.Unsetenv("GITLAB_KEY")
+	defer os.Unsetenv("GITLAB_SECRET")
+	defer os.Unsetenv("FACEBOOK_KEY")
+
+	p, err := Load("GitLab", "http://127.0.0.1/auth/gitlab/callback")
+	if err != nil {
+		t.Fatalf("unable to load the gitlab provider: %s", err)
+	}
+	if p.Config.ClientID != "key" || p.Config.ClientSecret != "secret" || p.Config.RedirectURL != "http://127.0.0.1/auth/gitlab/callback" {
+		t.Errorf("invalid gitlab configuration %#v", p.Config)
+	}
+	if _, err := Load("google", ""); err == nil {
+		t.Errorf("the google provider is not configured")
+	}
+	if _, err := Load("unknown", ""); err == nil {
+		t.Errorf("the unknown provider should not be loaded")
+	}
+	if _, err := Load("facebook", ""); err != nil {
+		t.Errorf("unable to load the facebook provider: %s", err)
+	}
+	if av := Available(); av["gitlab"] != "Gitlab" || len(av["google"]) > 0 || av["facebook"] != "Facebook" {
+		t.Errorf("invalid available providers %v", av)
+	}
+}
+
+func TestSuggestHandle(t *testing.T) {
+	tests := []struct {
+		p   Profile
+		exp string
+	}{
+		{Profile{Handle: "jane-doe"}, "jane-doe"},
+		{Profile{Email: "jane.doe@example.com", Name: "Jane Doe"}, "jane.doe"},
+		{Profile{Name: "Jane Doe"}, "Jane_Doe"},
+		{Profile{Handle: "@@", Name: "!!"}, "user"},
+	}
+	for _, tt := range tests {
+		if h := SuggestHandle(tt.p); h != tt.exp {
+			t.Errorf("invalid handle %q for %#v, expected %q", h, tt.p, tt.exp)
+		}
+	}
+}
@@ -0,0 +1,443 @@
+package identity
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mariusor/littr.go/internal/errors"
+	"golang.org/x/oauth2"
+)
+
+// oidcCacheTTL is the duration for which the discovery documents and the keys of the issuers are reused
+const oidcCacheTTL = time.Hour
+
+// oidcLeeway is the clock difference allowed when checking the validity of the ID tokens
+const oidcLeeway = time.Minute
+
+// httpClient loads the discovery documents and the keys of the issuers
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// OIDC is the configuration of an OpenID Connect provider, from the OIDC_{NAME}_* environment variables
+type OIDC struct {
+	Issuer string
+	// the claims the handle, the email and the name of the local accounts are loaded from
+	HandleClaim string
+	EmailClaim  string
+	NameClaim   string
+}
+
+// discovery is the part of the OpenID Connect discovery document which is used
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type cached struct {
+	discovery discovery
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+var oidcCache = struct {
+	sync.Mutex
+	discovery map[string]cached
+	keys      map[string]cached
+}{
+	discovery: make(map[string]cached),
+	keys:      make(map[string]cached),
+}
+
+// oidcVerifier checks the ID tokens of an OpenID Connect provider
+type oidcVerifier struct {
+	OIDC
+	clientID string
+	jwksURI  string
+}
+
+// oidcNames returns the names of the OpenID Connect providers from the OIDC_PROVIDERS environment variable
+func oidcNames() []string {
+	names := make([]string, 0)
+	for _, n := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		n = strings.ToLower(strings.TrimSpace(n))
+		if _, ok := known[n]; ok || len(n) == 0 {
+			continue
+		}
+		names = append(names, n)
+	}
+	return names
+}
+
+func oidcEnv(name string, key string) string {
+	return os.Getenv(fmt.Sprintf("OIDC_%s_%s", strings.ToUpper(name), key))
+}
+
+func envOr(val string, def string) string {
+	if len(val) == 0 {
+		return def
+	}
+	return val
+}
+
+// oidcLabel returns the label of the "name" OpenID Connect provider, which is shown on its login button
+func oidcLabel(name string) string {
+	return envOr(oidcEnv(name, "LABEL"), name)
+}
+
+// oidcConfigured returns if the "name" OpenID Connect provider has both its issuer and its client key
+func oidcConfigured(name string) bool {
+	return len(oidcEnv(name, "ISSUER")) > 0 && len(oidcEnv(name, "KEY")) > 0
+}
+
+// loadOIDC returns the "name" OpenID Connect provider, with the endpoints from the discovery document of its issuer
+func loadOIDC(ctx context.Context, name string) (Provider, error) {
+	found := false
+	for _, n := range oidcNames() {
+		found = found || n == name
+	}
+	if !found {
+		return Provider{}, errors.NotFoundf("unknown provider %q", name)
+	}
+	conf := OIDC{
+		Issuer:      strings.TrimSuffix(oidcEnv(name, "ISSUER"), "/"),
+		HandleClaim: envOr(oidcEnv(name, "HANDLE_CLAIM"), "preferred_username"),
+		EmailClaim:  envOr(oidcEnv(name, "EMAIL_CLAIM"), "email"),
+		NameClaim:   envOr(oidcEnv(name, "NAME_CLAIM"), "name"),
+	}
+	if !oidcConfigured(name) {
+		return Provider{}, errors.NotFoundf("provider %q is not configured", name)
+	}
+	clientID := oidcEnv(name, "KEY")
+	d, err := discover(ctx, conf.Issuer)
+	if err != nil {
+		return Provider{}, err
+	}
+	return Provider{
+		Name:  name,
+		Label: oidcLabel(name),
+		Config: oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: oidcEnv(name, "SECRET"),
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  d.AuthorizationEndpoint,
+				TokenURL: d.TokenEndpoint,
+			},
+			Scopes: []string{"openid", "email", "profile"},
+		},
+		ProfileURL: d.UserinfoEndpoint,
+		oidc: &oidcVerifier{
+			OIDC:     conf,
+			clientID: clientID,
+			jwksURI:  d.JWKSURI,
+		},
+	}, nil
+}
+
+func getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	res, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected response status %s", res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// discover loads the discovery document of the "issuer"
+func discover(ctx context.Context, issuer string) (discovery, error) {
+	oidcCache.Lock()
+	c, ok := oidcCache.discovery[issuer]
+	oidcCache.Unlock()
+	if ok && time.Since(c.fetchedAt) < oidcCacheTTL {
+		return c.discovery, nil
+	}
+
+	d := discovery{}
+	if err := getJSON(ctx, issuer+"/.well-known/openid-configuration", &d); err != nil {
+		return d, errors.Annotatef(err, "unable to load the discovery document of %s", issuer)
+	}
+	if strings.TrimSuffix(d.Issuer, "/") != issuer {
+		return d, errors.NotValidf("the discovery document is for the %q issuer instead of %q", d.Issuer, issuer)
+	}
+	if len(d.AuthorizationEndpoint) == 0 || len(d.TokenEndpoint) == 0 || len(d.JWKSURI) == 0 {
+		return d, errors.NotValidf("the discovery document of %s is missing endpoints", issuer)
+	}
+	oidcCache.Lock()
+	oidcCache.discovery[issuer] = cached{discovery: d, fetchedAt: time.Now()}
+	oidcCache.Unlock()
+	return d, nil
+}
+
+func b64(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// publicKey returns the key from the "k" JSON web key, only the RSA and the P-256 keys are supported
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := b64(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := b64(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, errors.NotValidf("unsupported curve %s", k.Crv)
+		}
+		x, err := b64(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := b64(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, errors.NotValidf("unsupported key type %s", k.Kty)
+}
+
+// key returns the signing key with the "kid" identifier of the issuer.
+// The keys are loaded again when the identifier is not known, as the issuers rotate them.
+func (v oidcVerifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	oidcCache.Lock()
+	c, ok := oidcCache.keys[v.jwksURI]
+	oidcCache.Unlock()
+	if ok && time.Since(c.fetchedAt) < oidcCacheTTL {
+		if k, ok := c.keys[kid]; ok {
+			return k, nil
+		}
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := getJSON(ctx, v.jwksURI, &set); err != nil {
+		return nil, errors.Annotatef(err, "unable to load the keys of %s", v.Issuer)
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for _, k := range set.Keys {
+		if len(k.Use) > 0 && k.Use != "sig" {
+			continue
+		}
+		if pub, err := k.publicKey(); err == nil {
+			keys[k.Kid] = pub
+		}
+	}
+	oidcCache.Lock()
+	oidcCache.keys[v.jwksURI] = cached{keys: keys, fetchedAt: time.Now()}
+	oidcCache.Unlock()
+
+	if k, ok := keys[kid]; ok {
+		return k, nil
+	}
+	return nil, errors.NotFoundf("unknown signing key %q", kid)
+}
+
+// verify checks the signature and the claims of the "raw" ID token and returns its claims.
+// The "nonce" is the one sent with the authorization request.
+func (v oidcVerifier) verify(ctx context.Context, raw string, nonce string, now time.Time) (map[string]interface{}, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, errors.NotValidf("malformed ID token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	data, err := b64(parts[0])
+	if err != nil {
+		return nil, errors.NewNotValid(err, "malformed ID token header")
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, errors.NewNotValid(err, "malformed ID token header")
+	}
+	sig, err := b64(parts[2])
+	if err != nil {
+		return nil, errors.NewNotValid(err, "malformed ID token signature")
+	}
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if header.Alg != "RS256" {
+			return nil, errors.NotValidf("unsupported ID token algorithm %q", header.Alg)
+		}
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, hash[:], sig); err != nil {
+			return nil, errors.NewNotValid(err, "invalid ID token signature")
+		}
+	case *ecdsa.PublicKey:
+		if header.Alg != "ES256" {
+			return nil, errors.NotValidf("unsupported ID token algorithm %q", header.Alg)
+		}
+		if len(sig) != 64 {
+			return nil, errors.NotValidf("invalid ID token signature")
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(pub, hash[:], r, s) {
+			return nil, errors.NotValidf("invalid ID token signature")
+		}
+	default:
+		return nil, errors.NotValidf("unsupported ID token algorithm %q", header.Alg)
+	}
+
+	if data, err = b64(parts[1]); err != nil {
+		return nil, errors.NewNotValid(err, "malformed ID token claims")
+	}
+	claims := make(map[string]interface{})
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	if err := dec.Decode(&claims); err != nil {
+		return nil, errors.NewNotValid(err, "malformed ID token claims")
+	}
+	if iss, _ := claims["iss"].(string); strings.TrimSuffix(iss, "/") != v.Issuer {
+		return nil, errors.NotValidf("the ID token was issued by %q instead of %q", iss, v.Issuer)
+	}
+	if !hasAudience(claims["aud"], v.clientID) {
+		return nil, errors.NotValidf("the ID token was not issued for this client")
+	}
+	if azp, ok := claims["azp"].(string); ok && azp != v.clientID {
+		return nil, errors.NotValidf("the ID token was not issued for this client")
+	}
+	exp, ok := unixClaim(claims["exp"])
+	if !ok || now.After(exp.Add(oidcLeeway)) {
+		return nil, errors.NotValidf("the ID token expired")
+	}
+	if iat, ok := unixClaim(claims["iat"]); ok && iat.After(now.Add(oidcLeeway)) {
+		return nil, errors.NotValidf("the ID token was issued in the future")
+	}
+	if n, _ := claims["nonce"].(string); len(nonce) > 0 && n != nonce {
+		return nil, errors.NotValidf("invalid ID token nonce")
+	}
+	if sub, _ := claims["sub"].(string); len(sub) == 0 {
+		return nil, errors.NotValidf("the ID token is missing the subject")
+	}
+	return claims, nil
+}
+
+func hasAudience(aud interface{}, clientID string) bool {
+	switch a := aud.(type) {
+	case string:
+		return a == clientID
+	case []interface{}:
+		for _, v := range a {
+			if s, ok := v.(string); ok && s == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func unixClaim(v interface{}) (time.Time, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	sec, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(sec), 0), true
+}
+
+func stringClaim(claims map[string]interface{}, name string) string {
+	switch v := claims[name].(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+	return ""
+}
+
+func boolClaim(claims map[string]interface{}, name string) bool {
+	switch v := claims[name].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// oidcProfile loads the profile from the claims of the ID token which came with "tok".
+// The claims missing from the ID token are loaded from the userinfo endpoint, when the provider has one.
+func (p Provider) oidcProfile(ctx context.Context, tok *oauth2.Token, nonce string) (Profile, error) {
+	raw, _ := tok.Extra("id_token").(string)
+	if len(raw) == 0 {
+		return Profile{}, errors.NotValidf("the %s response is missing the ID token", p.Label)
+	}
+	v := p.oidc
+	claims, err := v.verify(ctx, raw, nonce, time.Now())
+	if err != nil {
+		return Profile{}, errors.Annotatef(err, "invalid %s ID token", p.Label)
+	}
+	sub := stringClaim(claims, "sub")
+	if len(p.ProfileURL) > 0 && (len(stringClaim(claims, v.HandleClaim)) == 0 || len(stringClaim(claims, v.EmailClaim)) == 0 ||
+		len(stringClaim(claims, v.NameClaim)) == 0) {
+		if body, err := get(p.Config.Client(ctx, tok), p.ProfileURL); err == nil {
+			info := make(map[string]interface{})
+			dec := json.NewDecoder(strings.NewReader(string(body)))
+			dec.UseNumber()
+			// the userinfo claims are used only when they are about the same subject as the ID token
+			if dec.Decode(&info) == nil && stringClaim(info, "sub") == sub {
+				for k, val := range info {
+					if _, ok := claims[k]; !ok {
+						claims[k] = val
+					}
+				}
+			}
+		}
+	}
+	return Profile{
+		Provider:      p.Name,
+		ID:            sub,
+		Handle:        stringClaim(claims, v.HandleClaim),
+		Name:          stringClaim(claims, v.NameClaim),
+		Email:         stringClaim(claims, v.EmailClaim),
+		EmailVerified: boolClaim(claims, "email_verified"),
+	}, nil
+}
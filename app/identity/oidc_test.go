@@ -0,0 +1,337 @@
+package identity
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeIssuer is an in-process OpenID Connect provider, it serves the discovery document, the keys,
+// the token and the userinfo end-points
+type fakeIssuer struct {
+	*httptest.Server
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+	// claims are added to the ID tokens issued by the token end-point
+	claims map[string]interface{}
+	// userinfo is served by the userinfo end-point
+	userinfo map[string]interface{}
+}
+
+func b64enc(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	rk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate the RSA key: %s", err)
+	}
+	ek, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate the EC key: %s", err)
+	}
+	f := &fakeIssuer{rsa: rk, ec: ek, claims: map[string]interface{}{}, userinfo: map[string]interface{}{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 f.URL,
+			"authorization_endpoint": f.URL + "/authorize",
+			"token_endpoint":         f.URL + "/token",
+			"userinfo_endpoint":      f.URL + "/userinfo",
+			"jwks_uri":               f.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{
+				{
+					"kty": "RSA", "kid": "rsa", "use": "sig",
+					"n": b64enc(rk.N.Bytes()),
+					"e": b64enc(big.NewInt(int64(rk.E)).Bytes()),
+				},
+				{
+					"kty": "EC", "kid": "ec", "crv": "P-256",
+					"x": b64enc(ek.X.Bytes()),
+					"y": b64enc(ek.Y.Bytes()),
+				},
+			},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("code") != "fake-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"invalid_grant"}`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "fake-access-token",
+			"token_type":   "bearer",
+			"id_token":     f.sign(t, "RS256", "rsa", f.idClaims()),
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fake-access-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(f.userinfo)
+	})
+	f.Server = httptest.NewServer(mux)
+	return f
+}
+
+// idClaims returns valid ID token claims, overwritten by the ones set on the issuer
+func (f *fakeIssuer) idClaims() map[string]interface{} {
+	now := time.Now()
+	c := map[string]interface{}{
+		"iss":   f.URL,
+		"aud":   "fake-key",
+		"sub":   "user-1234",
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"nonce": "fake-nonce",
+	}
+	for k, v := range f.claims {
+		c[k] = v
+	}
+	return c
+}
+
+func (f *fakeIssuer) sign(t *testing.T, alg string, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64enc(header) + "." + b64enc(payload)
+	hash := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	switch alg {
+	case "RS256":
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, f.rsa, crypto.SHA256, hash[:]); err != nil {
+			t.Fatalf("unable to sign the ID token: %s", err)
+		}
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, f.ec, hash[:])
+		if err != nil {
+			t.Fatalf("unable to sign the ID token: %s", err)
+		}
+		sig = make([]byte, 64)
+		rb, sb := r.Bytes(), s.Bytes()
+		copy(sig[32-len(rb):32], rb)
+		copy(sig[64-len(sb):], sb)
+	}
+	return signed + "." + b64enc(sig)
+}
+
+func (f *fakeIssuer) configure(name string) func() {
+	env := map[string]string{
+		"OIDC_PROVIDERS": name,
+		"OIDC_" + strings.ToUpper(name) + "_ISSUER": f.URL,
+		"OIDC_" + strings.ToUpper(name) + "_KEY":    "fake-key",
+		"OIDC_" + strings.ToUpper(name) + "_SECRET": "fake-secret",
+		"OIDC_" + strings.ToUpper(name) + "_LABEL":  "Corp ID",
+	}
+	for k, v := range env {
+		os.Setenv(k, v)
+	}
+	return func() {
+		for k := range env {
+			os.Unsetenv(k)
+		}
+	}
+}
+
+func TestOIDC_FetchProfile(t *testing.T) {
+	f := newFakeIssuer(t)
+	defer f.Close()
+	defer f.configure("corp")()
+	f.claims = map[string]interface{}{
+		"preferred_username": "jane-doe",
+		"email":              "jane@example.com",
+		"email_verified":     true,
+	}
+	f.userinfo = map[string]interface{}{
+		"sub":   "user-1234",
+		"name":  "Jane Doe",
+		"email": "other@example.com",
+	}
+
+	if av := Available(); av["corp"] != "Corp ID" {
+		t.Errorf("invalid available providers %v", av)
+	}
+	p, err := Load("corp", "http://127.0.0.1/auth/corp/callback")
+	if err != nil {
+		t.Fatalf("unable to load the provider: %s", err)
+	}
+	u, err := url.Parse(p.AuthCodeURL("fake-nonce"))
+	if err != nil {
+		t.Fatalf("invalid authorization URL: %s", err)
+	}
+	if u.Query().Get("nonce") != "fake-nonce" || !strings.HasPrefix(u.String(), f.URL+"/authorize") {
+		t.Errorf("invalid authorization URL %s", u)
+	}
+	if !strings.Contains(u.Query().Get("scope"), "openid") {
+		t.Errorf("the openid scope is missing from the authorization URL %s", u)
+	}
+
+	ctx := context.Background()
+	tok, err := p.Exchange(ctx, "fake-code")
+	if err != nil {
+		t.Fatalf("unable to exchange the code: %s", err)
+	}
+	prof, err := p.FetchProfile(ctx, tok, "fake-nonce")
+	if err != nil {
+		t.Fatalf("unable to load the profile: %s", err)
+	}
+	exp := Profile{
+		Provider:      "corp",
+		ID:            "user-1234",
+		Handle:        "jane-doe",
+		Name:          "Jane Doe",
+		Email:         "jane@example.com",
+		EmailVerified: true,
+	}
+	if prof != exp {
+		t.Errorf("invalid profile %#v, expected %#v", prof, exp)
+	}
+
+	if _, err := p.FetchProfile(ctx, tok, "other-nonce"); err == nil {
+		t.Errorf("an ID token with a different nonce should not be accepted")
+	}
+}
+
+func TestOIDC_ClaimMapping(t *testing.T) {
+	f := newFakeIssuer(t)
+	defer f.Close()
+	defer f.configure("mapped")()
+	os.Setenv("OIDC_MAPPED_HANDLE_CLAIM", "nickname")
+	os.Setenv("OIDC_MAPPED_EMAIL_CLAIM", "mail")
+	defer os.Unsetenv("OIDC_MAPPED_HANDLE_CLAIM")
+	defer os.Unsetenv("OIDC_MAPPED_EMAIL_CLAIM")
+	f.claims = map[string]interface{}{"nickname": "jd", "mail": "jd@example.com", "name": "J. D."}
+	// the userinfo of a different subject is ignored
+	f.userinfo = map[string]interface{}{"sub": "someone-else", "email_verified": true}
+
+	p, err := Load("mapped", "")
+	if err != nil {
+		t.Fatalf("unable to load the provider: %s", err)
+	}
+	ctx := context.Background()
+	tok, err := p.Exchange(ctx, "fake-code")
+	if err != nil {
+		t.Fatalf("unable to exchange the code: %s", err)
+	}
+	prof, err := p.FetchProfile(ctx, tok, "fake-nonce")
+	if err != nil {
+		t.Fatalf("unable to load the profile: %s", err)
+	}
+	exp := Profile{Provider: "mapped", ID: "user-1234", Handle: "jd", Name: "J. D.", Email: "jd@example.com"}
+	if prof != exp {
+		t.Errorf("invalid profile %#v, expected %#v", prof, exp)
+	}
+}
+
+func TestOIDC_Verify(t *testing.T) {
+	f := newFakeIssuer(t)
+	defer f.Close()
+	defer f.configure("corp")()
+
+	p, err := Load("corp", "")
+	if err != nil {
+		t.Fatalf("unable to load the provider: %s", err)
+	}
+	other := newFakeIssuer(t)
+	defer other.Close()
+
+	with := func(k string, v interface{}) map[string]interface{} {
+		c := f.idClaims()
+		if v == nil {
+			delete(c, k)
+		} else {
+			c[k] = v
+		}
+		return c
+	}
+	unsigned := func() string {
+		header, _ := json.Marshal(map[string]string{"alg": "none", "kid": "rsa"})
+		payload, _ := json.Marshal(f.idClaims())
+		return b64enc(header) + "." + b64enc(payload) + "."
+	}
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"RS256", f.sign(t, "RS256", "rsa", f.idClaims()), true},
+		{"ES256", f.sign(t, "ES256", "ec", f.idClaims()), true},
+		{"audience list", f.sign(t, "RS256", "rsa", with("aud", []string{"other", "fake-key"})), true},
+		{"other issuer", f.sign(t, "RS256", "rsa", with("iss", other.URL)), false},
+		{"other audience", f.sign(t, "RS256", "rsa", with("aud", "other")), false},
+		{"other authorized party", f.sign(t, "RS256", "rsa", with("azp", "other")), false},
+		{"expired", f.sign(t, "RS256", "rsa", with("exp", time.Now().Add(-time.Hour).Unix())), false},
+		{"missing expiry", f.sign(t, "RS256", "rsa", with("exp", nil)), false},
+		{"issued in the future", f.sign(t, "RS256", "rsa", with("iat", time.Now().Add(time.Hour).Unix())), false},
+		{"missing subject", f.sign(t, "RS256", "rsa", with("sub", nil)), false},
+		{"other nonce", f.sign(t, "RS256", "rsa", with("nonce", "other")), false},
+		{"other key", other.sign(t, "RS256", "rsa", f.idClaims()), false},
+		{"unknown key", f.sign(t, "RS256", "unknown", f.idClaims()), false},
+		{"algorithm mismatch", f.sign(t, "ES256", "rsa", f.idClaims()), false},
+		{"unsigned", unsigned(), false},
+		{"malformed", "not.a-token", false},
+	}
+	for _, tt := range tests {
+		_, err := p.oidc.verify(context.Background(), tt.token, "fake-nonce", time.Now())
+		if tt.valid && err != nil {
+			t.Errorf("%s: the ID token should be valid: %s", tt.name, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%s: the ID token should not be valid", tt.name)
+		}
+	}
+}
+
+func TestOIDC_Load(t *testing.T) {
+	os.Setenv("OIDC_PROVIDERS", "github, missing")
+	defer os.Unsetenv("OIDC_PROVIDERS")
+
+	if _, err := Load("missing", ""); err == nil {
+		t.Errorf("a provider without issuer should not be loaded")
+	}
+	if _, err := Load("unlisted", ""); err == nil {
+		t.Errorf("a provider missing from OIDC_PROVIDERS should not be loaded")
+	}
+	if names := oidcNames(); len(names) != 1 || names[0] != "missing" {
+		t.Errorf("the known providers can't be overridden, invalid names %v", names)
+	}
+	if av := Available(); len(av["missing"]) > 0 {
+		t.Errorf("a provider without issuer should not be available %v", av)
+	}
+	os.Setenv("OIDC_MISSING_ISSUER", "https://id.example.com")
+	defer os.Unsetenv("OIDC_MISSING_ISSUER")
+	if av := Available(); len(av["missing"]) > 0 {
+		t.Errorf("a provider without key should not be available %v", av)
+	}
+	os.Setenv("OIDC_MISSING_KEY", "key")
+	defer os.Unsetenv("OIDC_MISSING_KEY")
+	if av := Available(); av["missing"] != "missing" {
+		t.Errorf("a configured provider should be available %v", av)
+	}
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"time"
+
+	"github.com/mariusor/littr.go/internal/errors"
+)
+
+// InviteTTL is the duration for which a new invite can be used
+const InviteTTL = 14 * 24 * time.Hour
+
+// InviteQuotaPeriod is the period for which Config.InviteQuota limits the number of invites of an account
+const InviteQuotaPeriod = 30 * 24 * time.Hour
+
+// Invite is a code which allows registering new accounts when the instance is invite only
+type Invite struct {
+	Code      string    `json:"code"`
+	CreatedBy *Account  `json:"-"`
+	MaxUses   int       `json:"maxUses"`
+	Uses      int       `json:"uses"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt,omitempty"`
+}
+
+type InviteCollection []Invite
+
+// Invitation records the account which invited a registered one, together they make the invite tree
+type Invitation struct {
+	Account   Account  `json:"account"`
+	InvitedBy *Account `json:"invitedBy,omitempty"`
+	Code      string   `json:"code,omitempty"`
+	// Depth is the distance from the account the invite tree was loaded for
+	Depth     int       `json:"depth"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type InvitationCollection []Invitation
+
+// Expired returns if the invite can't be used anymore because it's too old
+func (i Invite) Expired() bool {
+	return !i.ExpiresAt.IsZero() && time.Now().UTC().After(i.ExpiresAt)
+}
+
+// IsUsable returns if new accounts can still register with the invite
+func (i Invite) IsUsable() bool {
+	return len(i.Code) > 0 && i.Uses < i.MaxUses && !i.Expired()
+}
+
+// Remaining returns how many accounts can still register with the invite
+func (i Invite) Remaining() int {
+	if !i.IsUsable() {
+		return 0
+	}
+	return i.MaxUses - i.Uses
+}
+
+// ValidateCreateInvite returns an error if the account is not allowed to create a new invite,
+// "recent" being the number of invites it created in the current quota period
+func (a Account) ValidateCreateInvite(recent int) error {
+	if !a.IsLogged() || !a.IsLocal() {
+		return errors.Forbiddenf("only local accounts can invite new users")
+	}
+	if err := a.ValidateInteraction(); err != nil {
+		return err
+	}
+	if a.IsModerator() {
+		return nil
+	}
+	if a.Score < Instance.Config.InviteMinScore {
+		return errors.Forbiddenf("you need a score of at least %d to invite new users", Instance.Config.InviteMinScore)
+	}
+	if recent >= Instance.Config.InviteQuota {
+		return errors.Forbiddenf("you can create at most %d invites in %d days", Instance.Config.InviteQuota, int(InviteQuotaPeriod.Hours()/24))
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mariusor/littr.go/internal/errors"
+)
+
+func TestAccount_ValidateCreateInvite(t *testing.T) {
+	defer func(minScore int64, quota int, moderators []string) {
+		Instance.Config.InviteMinScore = minScore
+		Instance.Config.InviteQuota = quota
+		Instance.Config.Moderators = moderators
+	}(Instance.Config.InviteMinScore, Instance.Config.InviteQuota, Instance.Config.Moderators)
+	Instance.Config.InviteMinScore = 10
+	Instance.Config.InviteQuota = 2
+	Instance.Config.Moderators = []string{"mod"}
+	Instance.HostName = "example.com"
+	Instance.APIURL = "https://example.com/api"
+
+	now := time.Now()
+	tests := []struct {
+		name   string
+		acc    Account
+		recent int
+		valid  bool
+	}{
+		{"anonymous", AnonymousAccount, 0, false},
+		{"remote", Account{Handle: "jane", Hash: Hash("dc6f5f5bf55bc1073715c98c69fa7ca8"), CreatedAt: now, Score: 20,
+			Metadata: &AccountMetadata{ID: "https://remote.org/users/jane"}}, 0, false},
+		{"blocked", Account{Handle: "jane", Hash: Hash("dc6f5f5bf55bc1073715c98c69fa7ca8"), CreatedAt: now, Score: 20, Flags: FlagsBlocked}, 0, false},
+		{"unverified", Account{Handle: "jane", Hash: Hash("dc6f5f5bf55bc1073715c98c69fa7ca8"), CreatedAt: now, Score: 20, Flags: FlagsUnverified}, 0, false},
+		{"low score", Account{Handle: "jane", Hash: Hash("dc6f5f5bf55bc1073715c98c69fa7ca8"), CreatedAt: now, Score: 9}, 0, false},
+		{"quota exhausted", Account{Handle: "jane", Hash: Hash("dc6f5f5bf55bc1073715c98c69fa7ca8"), CreatedAt: now, Score: 20}, 2, false},
+		{"valid", Account{Handle: "jane", Hash: Hash("dc6f5f5bf55bc1073715c98c69fa7ca8"), CreatedAt: now, Score: 20}, 1, true},
+		{"moderator", Account{Handle: "mod", Hash: Hash("64e55785250f59637e7f578167e2c112"), CreatedAt: now}, 10, true},
+	}
+	for _, tt := range tests {
+		err := tt.acc.ValidateCreateInvite(tt.recent)
+		if tt.valid && err != nil {
+			t.Errorf("%s: the invite should be allowed: %s", tt.name, err)
+		}
+		if !tt.valid && !errors.IsForbidden(err) {
+			t.Errorf("%s: the invite should be forbidden, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestInvite_IsUsable(t *testing.T) {
+	tests := []struct {
+		name      string
+		invite    Invite
+		usable    bool
+		remaining int
+	}{
+		{"new", Invite{Code: "abc", MaxUses: 3}, true, 3},
+		{"used", Invite{Code: "abc", MaxUses: 3, Uses: 1}, true, 2},
+		{"exhausted", Invite{Code: "abc", MaxUses: 3, Uses: 3}, false, 0},
+		{"expired", Invite{Code: "abc", MaxUses: 3, ExpiresAt: time.Now().UTC().Add(-time.Minute)}, false, 0},
+		{"not expired", Invite{Code: "abc", MaxUses: 1, ExpiresAt: time.Now().UTC().Add(time.Hour)}, true, 1},
+		{"no code", Invite{MaxUses: 1}, false, 0},
+	}
+	for _, tt := range tests {
+		if u := tt.invite.IsUsable(); u != tt.usable {
+			t.Errorf("%s: usable %t, expected %t", tt.name, u, tt.usable)
+		}
+		if r := tt.invite.Remaining(); r != tt.remaining {
+			t.Errorf("%s: remaining %d, expected %d", tt.name, r, tt.remaining)
+		}
+	}
+}
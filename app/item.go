@@ -14,14 +14,31 @@ type Tag struct {
 
 type TagCollection []Tag
 
+// TagCount holds a tag together with the number of items it has been applied to
+type TagCount struct {
+	Tag
+	Count int64 `json:"count"`
+}
+
+type TagCountCollection []TagCount
+
+// NormalizeTagName returns the form under which a tag name is stored: lowercase and without the leading '#'
+func NormalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimLeft(strings.TrimSpace(name), "#"))
+}
+
 type ItemMetadata struct {
-	Tags       TagCollection `json:"tags,omitempty"`
-	Mentions   TagCollection `json:"mentions,omitempty"`
-	ID         string        `json:"id,omitempty"`
-	URL        string        `json:"url,omitempty"`
-	RepliesURI string        `json:"replies,omitempty"`
-	AuthorURI  string        `json:"author,omitempty"`
-	Icon       ImageMetadata `json:"icon,omitempty"`
+	Tags        TagCollection        `json:"tags,omitempty"`
+	Mentions    TagCollection        `json:"mentions,omitempty"`
+	ID          string               `json:"id,omitempty"`
+	URL         string               `json:"url,omitempty"`
+	RepliesURI  string               `json:"replies,omitempty"`
+	AuthorURI   string               `json:"author,omitempty"`
+	Icon        ImageMetadata        `json:"icon,omitempty"`
+	Poll        *Poll                `json:"poll,omitempty"`
+	Attachments AttachmentCollection `json:"attachments,omitempty"`
+	// Language is the ISO 639-1 code of the language the item is written in
+	Language string `json:"language,omitempty"`
 }
 
 type Identifiable interface {
@@ -53,6 +70,11 @@ type Item struct {
 	IsTop       bool          `json:"-"`
 	Parent      *Item         `json:"-"`
 	OP          *Item         `json:"-"`
+	// ReplyCount is the number of direct replies the item has
+	ReplyCount int64 `json:"-"`
+	// Ups and Downs are the number of positive and negative votes the item has
+	Ups   int64 `json:"-"`
+	Downs int64 `json:"-"`
 }
 
 func (i Item) Deleted() bool {
@@ -69,6 +91,64 @@ func (i *Item) Delete() {
 	i.Flags |= FlagsDeleted
 }
 
+// Locked returns if a moderator locked the thread the item belongs to
+func (i Item) Locked() bool {
+	return (i.Flags & FlagsLocked) == FlagsLocked
+}
+
+// Lock adds the locked flag on an item
+func (i *Item) Lock() {
+	i.Flags |= FlagsLocked
+}
+
+// Unlock removes the locked flag from an item
+func (i *Item) Unlock() {
+	i.Flags &^= FlagsLocked
+}
+
+// Archived returns if the item has the archived flag, or if it's older than the configured archiving age
+func (i Item) Archived() bool {
+	if (i.Flags & FlagsArchived) == FlagsArchived {
+		return true
+	}
+	age := Instance.Config.ArchiveAfter
+	return age > 0 && !i.SubmittedAt.IsZero() && time.Since(i.SubmittedAt) > age
+}
+
+// Draft returns if the item was saved by its author without being published
+func (i Item) Draft() bool {
+	return (i.Flags & FlagsDraft) == FlagsDraft
+}
+
+// Scheduled returns if the item waits to be published at its submission time
+func (i Item) Scheduled() bool {
+	return (i.Flags & FlagsScheduled) == FlagsScheduled
+}
+
+// IsPublished returns if the item is visible to everybody, not only to its author
+func (i Item) IsPublished() bool {
+	return (i.Flags & FlagsUnpublished) == FlagsNone
+}
+
+// Language returns the language code of the item, or an empty string if we don't know it
+func (i Item) Language() string {
+	if i.Metadata == nil {
+		return ""
+	}
+	return i.Metadata.Language
+}
+
+// ValidateInteraction returns an error if the item doesn't accept new replies or votes
+func (i Item) ValidateInteraction() error {
+	if i.Locked() {
+		return errors.Forbiddenf("the thread is locked, it doesn't accept replies or votes")
+	}
+	if i.Archived() {
+		return errors.Forbiddenf("the item is archived, it doesn't accept replies or votes")
+	}
+	return nil
+}
+
 func (i Item) IsLink() bool {
 	return i.MimeType == MimeTypeURL
 }
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizeTagName(t *testing.T) {
+	tests := map[string]string{
+		"#Golang":  "golang",
+		"golang":   "golang",
+		" #News ":  "news",
+		"##double": "double",
+		"#":        "",
+		"":         "",
+		"#Ünïcödé": "ünïcödé",
+	}
+	for name, exp := range tests {
+		if n := NormalizeTagName(name); n != exp {
+			t.Errorf("invalid normalized name %q for %q, expected %q", n, name, exp)
+		}
+	}
+}
+
+func TestItem_ValidateInteraction(t *testing.T) {
+	defer func(age time.Duration) { Instance.Config.ArchiveAfter = age }(Instance.Config.ArchiveAfter)
+	Instance.Config.ArchiveAfter = 30 * 24 * time.Hour
+
+	i := Item{SubmittedAt: time.Now().UTC().Add(-time.Hour)}
+	if err := i.ValidateInteraction(); err != nil {
+		t.Errorf("a new item should accept interactions: %s", err)
+	}
+	i.Lock()
+	if !i.Locked() || i.ValidateInteraction() == nil {
+		t.Errorf("a locked item should not accept interactions")
+	}
+	i.Unlock()
+	if i.Locked() || i.ValidateInteraction() != nil {
+		t.Errorf("an unlocked item should accept interactions")
+	}
+
+	old := Item{SubmittedAt: time.Now().UTC().Add(-31 * 24 * time.Hour)}
+	if !old.Archived() || old.ValidateInteraction() == nil {
+		t.Errorf("an item older than the archiving age should not accept interactions")
+	}
+	if flagged := (Item{Flags: FlagsArchived}); !flagged.Archived() {
+		t.Errorf("an item with the archived flag should be archived")
+	}
+	Instance.Config.ArchiveAfter = 0
+	if old.Archived() {
+		t.Errorf("items should not be archived when the archiving age is not set")
+	}
+}
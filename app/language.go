@@ -0,0 +1,145 @@
+package app
+
+import (
+	"strings"
+	"unicode"
+)
+
+// LanguageUndetermined is the ISO 639-2 code we use when filtering for items for which we don't know the language
+const LanguageUndetermined = "und"
+
+// scriptLanguages maps the writing systems which are used mostly by a single language to that language
+var scriptLanguages = []struct {
+	lang  string
+	table *unicode.RangeTable
+}{
+	{"ja", unicode.Hiragana},
+	{"ja", unicode.Katakana},
+	{"ko", unicode.Hangul},
+	{"zh", unicode.Han},
+	{"ru", unicode.Cyrillic},
+	{"el", unicode.Greek},
+	{"ar", unicode.Arabic},
+	{"he", unicode.Hebrew},
+	{"hi", unicode.Devanagari},
+	{"th", unicode.Thai},
+}
+
+// stopWords holds the most common words of the languages written with the latin alphabet
+var stopWords = map[string][]string{
+	"en": {"the", "and", "is", "are", "was", "of", "to", "in", "that", "it", "with", "for", "this", "you", "not", "have", "be", "on", "what", "which"},
+	"fr": {"le", "la", "les", "et", "est", "des", "une", "un", "du", "que", "qui", "dans", "pour", "pas", "sur", "avec", "ce", "il", "sont", "nous"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ein", "eine", "zu", "den", "mit", "sich", "auf", "für", "ich", "von", "dem", "sie", "es", "auch"},
+	"es": {"el", "la", "los", "las", "y", "es", "que", "de", "en", "un", "una", "por", "con", "para", "no", "se", "del", "lo", "como", "pero"},
+	"it": {"il", "la", "che", "di", "e", "è", "un", "una", "per", "non", "sono", "del", "della", "gli", "con", "si", "anche", "come", "questo", "ma"},
+	"pt": {"o", "a", "os", "as", "e", "é", "que", "de", "não", "um", "uma", "para", "com", "do", "da", "em", "no", "na", "se", "mas"},
+	"nl": {"de", "het", "een", "en", "is", "van", "dat", "niet", "op", "te", "zijn", "met", "voor", "ik", "je", "die", "er", "maar", "ook", "wat"},
+	"ro": {"și", "este", "în", "nu", "care", "cu", "pe", "un", "o", "la", "din", "sunt", "mai", "ce", "dar", "să", "pentru", "fost", "acest", "lui"},
+	"sv": {"och", "är", "att", "det", "som", "en", "ett", "på", "av", "för", "med", "inte", "jag", "till", "har", "den", "om", "var", "men", "så"},
+	"pl": {"i", "w", "nie", "na", "się", "jest", "że", "to", "z", "do", "jak", "ale", "co", "tak", "jego", "od", "po", "czy", "już", "są"},
+}
+
+// DetectLanguage tries to guess the language of the text and returns its ISO 639-1 code
+// It returns an empty string when the text doesn't contain enough information for a guess
+func DetectLanguage(text string) string {
+	if len(text) == 0 {
+		return ""
+	}
+	text = stripTags(text)
+	letters := 0
+	scripts := make(map[string]int)
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for _, s := range scriptLanguages {
+			if unicode.Is(s.table, r) {
+				scripts[s.lang]++
+				break
+			}
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+	nonLatin := 0
+	lang := ""
+	for l, cnt := range scripts {
+		nonLatin += cnt
+		if lang == "" || cnt > scripts[lang] {
+			lang = l
+		}
+	}
+	if nonLatin*2 > letters {
+		if scripts["ja"] > 0 {
+			// japanese texts mix kana with han characters
+			return "ja"
+		}
+		return lang
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	counts := make(map[string]int)
+	for _, w := range words {
+		for l, stop := range stopWords {
+			for _, s := range stop {
+				if w == s {
+					counts[l]++
+					break
+				}
+			}
+		}
+	}
+	best, second := "", 0
+	for l, cnt := range counts {
+		if best == "" || cnt > counts[best] {
+			if best != "" {
+				second = counts[best]
+			}
+			best = l
+		} else if cnt > second {
+			second = cnt
+		}
+	}
+	if counts[best] < 2 || counts[best] == second {
+		return ""
+	}
+	return best
+}
+
+// stripTags removes the HTML markup from the text so the tag names don't count as words
+func stripTags(text string) string {
+	b := strings.Builder{}
+	inTag := false
+	for _, r := range text {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>' && inTag:
+			inTag = false
+			b.WriteRune(' ')
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// ValidLanguage checks if the lang is an ISO 639-1 language code or LanguageUndetermined
+func ValidLanguage(lang string) bool {
+	if lang == LanguageUndetermined {
+		return true
+	}
+	if len(lang) != 2 {
+		return false
+	}
+	for _, r := range lang {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package app
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := map[string]string{
+		"":        "",
+		"1234 !!": "",
+		"hello":   "",
+		"This is the best thing that happened to the web in years.":  "en",
+		"<p>Ceci est le début de la fin, et il est déjà tard.</p>":   "fr",
+		"Das ist nicht die Lösung, die ich mir auch gewünscht habe.": "de",
+		"Это просто тест":                                            "ru",
+		"これはテストです":                                                   "ja",
+		"東京は日本の首都です":                                                 "ja",
+		"这是一个测试":                                                     "zh",
+		"<strong>the</strong> <em>and</em> le et":                    "",
+	}
+	for text, exp := range tests {
+		if l := DetectLanguage(text); l != exp {
+			t.Errorf("invalid language %q for %q, expected %q", l, text, exp)
+		}
+	}
+}
+
+func TestValidLanguage(t *testing.T) {
+	tests := map[string]bool{
+		"en":                 true,
+		LanguageUndetermined: true,
+		"EN":                 false,
+		"eng":                false,
+		"e1":                 false,
+		"":                   false,
+	}
+	for lang, exp := range tests {
+		if v := ValidLanguage(lang); v != exp {
+			t.Errorf("invalid validity %t for %q, expected %t", v, lang, exp)
+		}
+	}
+}
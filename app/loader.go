@@ -6,6 +6,7 @@ import (
 	as "github.com/go-ap/activitystreams"
 	"github.com/mariusor/littr.go/app/activitypub"
 	"github.com/mariusor/qstring"
+	"io"
 	"net/url"
 	"strings"
 	"time"
@@ -109,10 +110,18 @@ type LoadItemsFilter struct {
 	Deleted              []bool     `qstring:"deleted,omitempty"`
 	IRI                  string     `qstring:"id,omitempty"`
 	Depth                int        `qstring:"depth,omitempty"`
+	// Tag is the list of tag names the items should be tagged with, without the leading '#'
+	Tag []string `qstring:"tag,omitempty"`
 	// Federated shows if the item was generated locally or is coming from an external peer
 	Federated []bool `qstring:"federated,omitempty"`
 	// FollowedBy is the hash or handle of the user of which we should show the list of items that were commented on or liked
-	FollowedBy   []string `qstring:"followedBy,omitempty"`
+	FollowedBy []string `qstring:"followedBy,omitempty"`
+	// SavedBy is the hash or handle of the user of which we should show the list of saved items
+	SavedBy []string `qstring:"savedBy,omitempty"`
+	// Draft shows if the items are drafts or scheduled ones, the database loads only published items when it's empty
+	Draft []bool `qstring:"draft,omitempty"`
+	// Language is the list of language codes of the items, LanguageUndetermined matches the items with no known language
+	Language     []string `qstring:"lang,omitempty"`
 	contentAlias string
 	authorAlias  string
 }
@@ -331,6 +340,32 @@ FROM "items" WHERE "key" ~* ?%d) AND "%s"."path" IS NOT NULL)`, it, counter, it)
 		}
 		wheres = append(wheres, fmt.Sprintf("(%s)", strings.Join(delWhere, " OR ")))
 	}
+	if len(f.Draft) > 0 {
+		draftWhere := make([]string, 0)
+		for _, draft := range f.Draft {
+			var eqOp string
+			if draft {
+				eqOp = "!="
+			} else {
+				eqOp = "="
+			}
+			draftWhere = append(draftWhere, fmt.Sprintf(`"%s"."flags" & %d::bit(8) %s 0::bit(8)`, it, FlagsUnpublished, eqOp))
+		}
+		wheres = append(wheres, fmt.Sprintf("(%s)", strings.Join(draftWhere, " OR ")))
+	}
+	if len(f.Language) > 0 {
+		langWhere := make([]string, 0)
+		for _, lang := range f.Language {
+			if lang == LanguageUndetermined {
+				langWhere = append(langWhere, fmt.Sprintf(`"%s"."metadata"->>'language' IS NULL`, it))
+				continue
+			}
+			langWhere = append(langWhere, fmt.Sprintf(`"%s"."metadata"->>'language' = ?%d`, it, counter))
+			whereValues = append(whereValues, interface{}(lang))
+			counter++
+		}
+		wheres = append(wheres, fmt.Sprintf("(%s)", strings.Join(langWhere, " OR ")))
+	}
 	if len(f.FollowedBy) > 0 {
 		keyWhere := make([]string, 0)
 		for _, hash := range f.FollowedBy {
@@ -342,6 +377,15 @@ FROM "items" WHERE "key" ~* ?%d) AND "%s"."path" IS NOT NULL)`, it, counter, it)
 		}
 		wheres = append(wheres, fmt.Sprintf("(%s)", strings.Join(keyWhere, " OR ")))
 	}
+	if len(f.SavedBy) > 0 {
+		keyWhere := make([]string, 0)
+		for _, hash := range f.SavedBy {
+			keyWhere = append(keyWhere, fmt.Sprintf(`"%s"."id" IN (SELECT "saved_items"."item_id" FROM "saved_items" WHERE "saved_items"."account_id" = (SELECT "id" FROM "accounts" where "key" ~* ?%d OR "handle" = ?%d))`, it, counter, counter))
+			whereValues = append(whereValues, interface{}(hash))
+			counter++
+		}
+		wheres = append(wheres, fmt.Sprintf("(%s)", strings.Join(keyWhere, " OR ")))
+	}
 	if len(f.Federated) > 0 && len(f.Federated) < 2 {
 		for _, fed := range f.Federated {
 			fWheres := make([]string, 0)
@@ -355,6 +399,16 @@ FROM "items" WHERE "key" ~* ?%d) AND "%s"."path" IS NOT NULL)`, it, counter, it)
 			wheres = append(wheres, fmt.Sprintf("(%s)", strings.Join(fWheres, " OR ")))
 		}
 	}
+	if len(f.Tag) > 0 {
+		tagWhere := make([]string, 0)
+		for _, tag := range f.Tag {
+			tagWhere = append(tagWhere, fmt.Sprintf(`"tags"."name" = ?%d`, counter))
+			whereValues = append(whereValues, interface{}(NormalizeTagName(tag)))
+			counter++
+		}
+		wheres = append(wheres, fmt.Sprintf(`"%s"."id" IN (SELECT "item_tags"."item_id" FROM "item_tags" 
+INNER JOIN "tags" ON "tags"."id" = "item_tags"."tag_id" WHERE %s)`, it, strings.Join(tagWhere, " OR ")))
+	}
 	if len(f.IRI) > 0 {
 		wheres = append(wheres, fmt.Sprintf(`"%s"."metadata"->>'id' ~* ?%d`, it, counter))
 		whereValues = append(whereValues, interface{}(f.IRI))
@@ -379,7 +433,12 @@ func copyItemsFilters(a *LoadItemsFilter, b LoadItemsFilter) {
 	a.Deleted = b.Deleted
 	a.IRI = b.IRI
 	a.Deleted = b.Deleted
+	a.Depth = b.Depth
 	a.FollowedBy = b.FollowedBy
+	a.SavedBy = b.SavedBy
+	a.Draft = b.Draft
+	a.Language = b.Language
+	a.Tag = b.Tag
 	a.contentAlias = b.contentAlias
 	a.authorAlias = b.authorAlias
 }
@@ -473,9 +532,13 @@ func (f LoadAccountsFilter) GetWhereClauses() ([]string, []interface{}) {
 		wheres = append(wheres, fmt.Sprintf("(%s)", strings.Join(whereColumns, " OR ")))
 	}
 	if len(f.Email) > 0 {
-		wheres = append(wheres, fmt.Sprintf(`"accounts"."email"  ~* ?%d`, counter))
-		whereValues = append(whereValues, interface{}(f.Email))
-		counter++
+		whereColumns := make([]string, 0)
+		for _, email := range f.Email {
+			whereColumns = append(whereColumns, fmt.Sprintf(`lower("accounts"."email") = lower(?%d)`, counter))
+			whereValues = append(whereValues, interface{}(email))
+			counter++
+		}
+		wheres = append(wheres, fmt.Sprintf("(%s)", strings.Join(whereColumns, " OR ")))
 	}
 	if len(f.InboxIRI) > 0 {
 		wheres = append(wheres, fmt.Sprintf(`"accounts"."metadata"->>'inbox' ~* ?%d`, counter))
@@ -546,6 +609,41 @@ type CanLoadVotes interface {
 	LoadVote(f Filters) (Vote, error)
 }
 
+type CanLoadTags interface {
+	// LoadTags returns the tags applied to the items matching the filter, ordered by their usage count
+	LoadTags(f Filters) (TagCountCollection, error)
+}
+
+type CanLoadNotifications interface {
+	// LoadNotifications returns the notifications addressed to the "a" account, newest first
+	LoadNotifications(a Account, f Filters) (NotificationCollection, uint, error)
+	// CountUnreadNotifications returns the number of notifications the "a" account didn't see yet
+	CountUnreadNotifications(a Account) (uint, error)
+}
+
+type CanSaveNotifications interface {
+	SaveNotification(n Notification) (Notification, error)
+	// MarkNotificationsRead marks as read all the notifications addressed to the "a" account
+	MarkNotificationsRead(a Account) error
+}
+
+type CanSaveBookmarks interface {
+	// SaveBookmark adds the "it" item to the saved items of the "a" account
+	SaveBookmark(a Account, it Item) error
+	// RemoveBookmark removes the "it" item from the saved items of the "a" account
+	RemoveBookmark(a Account, it Item) error
+}
+
+type CanSavePollVotes interface {
+	// SavePollVote records the choices an account made on a poll item
+	SavePollVote(v PollVote) error
+}
+
+type CanLoadPollVotes interface {
+	// LoadPollChoices returns the options the "a" account chose in the poll items found in "items", by their keys
+	LoadPollChoices(a Account, items ItemCollection) (map[Hash][]string, error)
+}
+
 type CanLoadInfo interface {
 	LoadInfo() (Info, error)
 }
@@ -577,6 +675,21 @@ type CanSaveAccounts interface {
 	SaveAccount(a Account) (Account, error)
 }
 
+type CanDeleteAccounts interface {
+	// DeleteAccount tombstones the items of the "a" account and removes its personal data
+	DeleteAccount(a Account) error
+}
+
+type CanExportAccounts interface {
+	// ExportAccount writes an archive with the ActivityStreams representation of the "a" account's data to "w"
+	ExportAccount(a Account, w io.Writer) error
+}
+
+type CanMoveAccounts interface {
+	// MoveAccount re-points the followers and the karma of the "from" account to the "to" account
+	MoveAccount(from, to Account) error
+}
+
 type CanSaveActivity interface {
 	SaveActivity(as.Item, as.IRI) (as.Item, error)
 }
@@ -641,12 +754,60 @@ func ContextAccountSaver(ctx context.Context) (CanSaveAccounts, bool) {
 	return s, ok
 }
 
+func ContextAccountDeleter(ctx context.Context) (CanDeleteAccounts, bool) {
+	ctxVal := ctx.Value(RepositoryCtxtKey)
+	s, ok := ctxVal.(CanDeleteAccounts)
+	return s, ok
+}
+
+func ContextAccountExporter(ctx context.Context) (CanExportAccounts, bool) {
+	ctxVal := ctx.Value(RepositoryCtxtKey)
+	s, ok := ctxVal.(CanExportAccounts)
+	return s, ok
+}
+
+func ContextAccountMover(ctx context.Context) (CanMoveAccounts, bool) {
+	ctxVal := ctx.Value(RepositoryCtxtKey)
+	s, ok := ctxVal.(CanMoveAccounts)
+	return s, ok
+}
+
 func ContextVoteSaver(ctx context.Context) (CanSaveVotes, bool) {
 	ctxVal := ctx.Value(RepositoryCtxtKey)
 	s, ok := ctxVal.(CanSaveVotes)
 	return s, ok
 }
 
+func ContextTagLoader(ctx context.Context) (CanLoadTags, bool) {
+	ctxVal := ctx.Value(RepositoryCtxtKey)
+	l, ok := ctxVal.(CanLoadTags)
+	return l, ok
+}
+
+func ContextNotificationLoader(ctx context.Context) (CanLoadNotifications, bool) {
+	ctxVal := ctx.Value(RepositoryCtxtKey)
+	l, ok := ctxVal.(CanLoadNotifications)
+	return l, ok
+}
+
+func ContextNotificationSaver(ctx context.Context) (CanSaveNotifications, bool) {
+	ctxVal := ctx.Value(RepositoryCtxtKey)
+	s, ok := ctxVal.(CanSaveNotifications)
+	return s, ok
+}
+
+func ContextBookmarkSaver(ctx context.Context) (CanSaveBookmarks, bool) {
+	ctxVal := ctx.Value(RepositoryCtxtKey)
+	s, ok := ctxVal.(CanSaveBookmarks)
+	return s, ok
+}
+
+func ContextPollVoteSaver(ctx context.Context) (CanSavePollVotes, bool) {
+	ctxVal := ctx.Value(RepositoryCtxtKey)
+	s, ok := ctxVal.(CanSavePollVotes)
+	return s, ok
+}
+
 func ContextNodeInfoLoader(ctx context.Context) (CanLoadInfo, bool) {
 	ctxVal := ctx.Value(RepositoryCtxtKey)
 	a, ok := ctxVal.(CanLoadInfo)
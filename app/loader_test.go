@@ -0,0 +1,56 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadItemsFilter_Depth(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter LoadItemsFilter
+		depth  bool
+		values int
+	}{
+		{"replies", LoadItemsFilter{InReplyTo: []string{"a"}}, false, 1},
+		{"replies with depth", LoadItemsFilter{InReplyTo: []string{"a"}, Depth: 3}, true, 1},
+		{"thread", LoadItemsFilter{Context: []string{"a"}}, false, 1},
+		{"thread with depth", LoadItemsFilter{Context: []string{"a", "b"}, Depth: 3}, true, 2},
+		{"top level", LoadItemsFilter{Context: []string{ContextNil}, Depth: 3}, false, 0},
+	}
+	for _, tt := range tests {
+		wheres, values := tt.filter.WithContentAlias("item").GetWhereClauses()
+		depth := false
+		for _, w := range wheres {
+			if strings.Contains(w, `nlevel("item"."path") <=`) {
+				depth = true
+				if !strings.HasSuffix(w, "+ 3") {
+					t.Errorf("%s: invalid depth clause %q", tt.name, w)
+				}
+			}
+		}
+		if depth != tt.depth {
+			t.Errorf("%s: depth clause %t, expected %t", tt.name, depth, tt.depth)
+		}
+		if len(values) != tt.values {
+			t.Errorf("%s: invalid number of values %d, expected %d", tt.name, len(values), tt.values)
+		}
+	}
+}
+
+func TestFilters_GetLimit(t *testing.T) {
+	tests := []struct {
+		filter Filters
+		limit  string
+	}{
+		{Filters{}, ""},
+		{Filters{MaxItems: 50}, "  LIMIT 50"},
+		{Filters{MaxItems: 50, Page: 1}, "  LIMIT 50"},
+		{Filters{MaxItems: 50, Page: 3}, "  LIMIT 50 OFFSET 100"},
+	}
+	for _, tt := range tests {
+		if l := tt.filter.GetLimit(); l != tt.limit {
+			t.Errorf("invalid limit %q for %v, expected %q", l, tt.filter, tt.limit)
+		}
+	}
+}
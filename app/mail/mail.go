@@ -0,0 +1,128 @@
+// Package mail sends the emails of the application, like the password reset ones.
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer is the interface for the backends that deliver the emails
+type Mailer interface {
+	Send(m Message) error
+}
+
+// headerValue removes the line breaks from a header value, so it can't be used to inject other headers
+func headerValue(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// Bytes returns the message formatted as an RFC 5322 email sent from the "from" address
+func (m Message) Bytes(from string, date time.Time) []byte {
+	b := bytes.Buffer{}
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue(from))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue(m.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerValue(m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.Replace(m.Body, "\n", "\r\n", -1))
+	return b.Bytes()
+}
+
+// SMTPMailer delivers the emails through an SMTP server
+type SMTPMailer struct {
+	Host string
+	Port string
+	User string
+	Pw   string
+	From string
+}
+
+// NewSMTPMailer returns a mailer which connects to the "host":"port" SMTP server,
+// authenticating with "user" and "pw" when they're not empty.
+func NewSMTPMailer(host, port, user, pw, from string) *SMTPMailer {
+	if len(port) == 0 {
+		port = "25"
+	}
+	return &SMTPMailer{Host: host, Port: port, User: user, Pw: pw, From: from}
+}
+
+// Send delivers the "m" message
+func (s SMTPMailer) Send(m Message) error {
+	var auth smtp.Auth
+	if len(s.User) > 0 {
+		auth = smtp.PlainAuth("", s.User, s.Pw, s.Host)
+	}
+	addr := fmt.Sprintf("%s:%s", s.Host, s.Port)
+	if err := smtp.SendMail(addr, auth, s.From, []string{m.To}, m.Bytes(s.From, time.Now())); err != nil {
+		return errors.Annotatef(err, "unable to send email to %s", m.To)
+	}
+	return nil
+}
+
+// FileMailer writes the emails as .eml files in a directory, it's meant for development and testing
+type FileMailer struct {
+	Path string
+	From string
+}
+
+// NewFileMailer returns a mailer which writes the messages in the "path" directory, creating it if needed
+func NewFileMailer(path, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, errors.Annotatef(err, "unable to create mail path %s", path)
+	}
+	return &FileMailer{Path: path, From: from}, nil
+}
+
+// Send writes the "m" message to a new file named after the time it was sent and the recipient
+func (f FileMailer) Send(m Message) error {
+	now := time.Now().UTC()
+	to := strings.Map(func(r rune) rune {
+		if r == '/' || r == filepath.Separator || r < ' ' {
+			return '_'
+		}
+		return r
+	}, m.To)
+	name := filepath.Join(f.Path, fmt.Sprintf("%d-%s.eml", now.UnixNano(), to))
+	if err := ioutil.WriteFile(name, m.Bytes(f.From, now), 0600); err != nil {
+		return errors.Annotatef(err, "unable to write email to %s", name)
+	}
+	return nil
+}
+
+// LogMailer only logs the emails, it's used when no other mailer is configured
+// The bodies contain the password reset and verification links, so they don't get logged:
+// use a FileMailer to read them during development.
+type LogMailer struct {
+	Logger log.Logger
+}
+
+// Send logs the recipient and the subject of the "m" message
+func (l LogMailer) Send(m Message) error {
+	if l.Logger == nil {
+		return errors.NotImplementedf("no mailer configured")
+	}
+	l.Logger.WithContext(log.Ctx{
+		"to":      m.To,
+		"subject": m.Subject,
+		"size":    len(m.Body),
+	}).Info("email not sent, no mailer configured")
+	return nil
+}
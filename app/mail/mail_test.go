@@ -0,0 +1,107 @@
+package mail
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mariusor/littr.go/internal/log"
+)
+
+func TestMessage_Bytes(t *testing.T) {
+	m := Message{
+		To:      "jane@example.com\r\nBcc: eve@example.com",
+		Subject: "Reset your password",
+		Body:    "first line\nsecond line",
+	}
+	date := time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)
+	data := string(m.Bytes("system@example.com", date))
+
+	for _, exp := range []string{
+		"From: system@example.com\r\n",
+		"To: jane@example.comBcc: eve@example.com\r\n",
+		"Subject: Reset your password\r\n",
+		"Date: Wed, 01 May 2019 10:00:00 +0000\r\n",
+		"\r\n\r\nfirst line\r\nsecond line",
+	} {
+		if !strings.Contains(data, exp) {
+			t.Errorf("message %q doesn't contain %q", data, exp)
+		}
+	}
+	if strings.Contains(data, "\r\nBcc:") {
+		t.Errorf("message %q allows header injection", data)
+	}
+}
+
+func TestFileMailer_Send(t *testing.T) {
+	dir, err := ioutil.TempDir("", "littr-mail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := NewFileMailer(filepath.Join(dir, "outbox"), "system@example.com")
+	if err != nil {
+		t.Fatalf("unable to create mailer: %s", err)
+	}
+	m := Message{To: "jane@example.com", Subject: "Hello", Body: "Hello Jane"}
+	if err := f.Send(m); err != nil {
+		t.Fatalf("unable to send message: %s", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(f.Path, "*.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("expected one message file, found %d", len(files))
+	}
+	if !strings.HasSuffix(files[0], "-jane@example.com.eml") {
+		t.Errorf("invalid message file name %s", files[0])
+	}
+	data, err := ioutil.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "To: jane@example.com\r\n") || !strings.HasSuffix(string(data), "Hello Jane") {
+		t.Errorf("invalid message contents %q", data)
+	}
+}
+
+type ctxLogger struct {
+	log.Logger
+	ctx []log.Ctx
+}
+
+func (l *ctxLogger) WithContext(c ...interface{}) log.Logger {
+	for _, cc := range c {
+		if ctx, ok := cc.(log.Ctx); ok {
+			l.ctx = append(l.ctx, ctx)
+		}
+	}
+	return l
+}
+
+func (l *ctxLogger) Info(string) {}
+
+func TestLogMailer_Send(t *testing.T) {
+	l := ctxLogger{}
+	m := Message{To: "jane@example.com", Subject: "Reset your password", Body: "https://example.com/reset/secret-token"}
+	if err := (LogMailer{Logger: &l}).Send(m); err != nil {
+		t.Fatalf("unable to log message: %s", err)
+	}
+	if len(l.ctx) != 1 || l.ctx[0]["to"] != m.To || l.ctx[0]["subject"] != m.Subject {
+		t.Fatalf("invalid logged message %v", l.ctx)
+	}
+	for k, v := range l.ctx[0] {
+		if s, ok := v.(string); ok && strings.Contains(s, "secret-token") {
+			t.Errorf("the message body was logged in %q", k)
+		}
+	}
+	if err := (LogMailer{}).Send(m); err == nil {
+		t.Errorf("expected an error without a logger")
+	}
+}
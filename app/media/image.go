@@ -0,0 +1,303 @@
+package media
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/internal/errors"
+)
+
+// DefaultMaxUploadSize is the size limit of an uploaded file, when MAX_UPLOAD_SIZE is not set
+const DefaultMaxUploadSize = 5 << 20
+
+// MaxPixels limits the dimensions of the images we accept, so we don't decode huge images in memory
+const MaxPixels = 40 * 1000 * 1000
+
+// ThumbnailSize is the maximum width or height of a generated thumbnail
+const ThumbnailSize = 320
+
+const (
+	MimeTypeJPEG = "image/jpeg"
+	MimeTypePNG  = "image/png"
+	MimeTypeGIF  = "image/gif"
+)
+
+// ValidMimeTypes are the types of files we accept as attachments
+var ValidMimeTypes = []string{
+	MimeTypeJPEG,
+	MimeTypePNG,
+	MimeTypeGIF,
+}
+
+var extensions = map[string]string{
+	MimeTypeJPEG: ".jpg",
+	MimeTypePNG:  ".png",
+	MimeTypeGIF:  ".gif",
+}
+
+// IsValidMimeType returns if files of type "typ" can be uploaded
+func IsValidMimeType(typ string) bool {
+	for _, t := range ValidMimeTypes {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// MimeTypeForExtension returns the mime-type we use when serving a stored file with the "ext" extension
+func MimeTypeForExtension(ext string) string {
+	for typ, e := range extensions {
+		if e == ext {
+			return typ
+		}
+	}
+	return "application/octet-stream"
+}
+
+// Upload validates the image in "r", re-encodes it to drop any metadata it contained (EXIF included, after the
+// JPEG images are rotated according to their EXIF orientation), generates its thumbnail and saves both of them to the "s" storage.
+// Files larger than "maxSize" bytes, or with a type not in ValidMimeTypes are rejected.
+func Upload(s Storage, r io.Reader, name string, maxSize int64) (app.Attachment, error) {
+	att := app.Attachment{}
+	if maxSize <= 0 {
+		maxSize = DefaultMaxUploadSize
+	}
+	raw, err := ioutil.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return att, errors.Annotatef(err, "unable to read %s", name)
+	}
+	if int64(len(raw)) > maxSize {
+		return att, errors.NotValidf("%s is larger than the %d bytes limit", name, maxSize)
+	}
+	typ := http.DetectContentType(raw)
+	if !IsValidMimeType(typ) {
+		return att, errors.NotValidf("%s has an unsupported file type %s", name, typ)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return att, errors.NewNotValid(err, "unable to decode %s", name)
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return att, errors.NotValidf("%s is too large: %dx%d", name, cfg.Width, cfg.Height)
+	}
+
+	img, clean, err := reEncode(raw, typ)
+	if err != nil {
+		return att, errors.NewNotValid(err, "unable to process %s", name)
+	}
+	thumb := bytes.Buffer{}
+	thumbTyp := MimeTypePNG
+	if typ == MimeTypeJPEG {
+		thumbTyp = MimeTypeJPEG
+		err = jpeg.Encode(&thumb, thumbnail(img, ThumbnailSize), &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&thumb, thumbnail(img, ThumbnailSize))
+	}
+	if err != nil {
+		return att, errors.Annotatef(err, "unable to generate thumbnail for %s", name)
+	}
+
+	key := fmt.Sprintf("%x", sha256.Sum256(clean))
+	fileName := key + extensions[typ]
+	thumbName := key + "-thumb" + extensions[thumbTyp]
+	if err := s.Save(fileName, bytes.NewReader(clean)); err != nil {
+		return att, err
+	}
+	if err := s.Save(thumbName, &thumb); err != nil {
+		s.Remove(fileName)
+		return att, err
+	}
+
+	att.URL = s.URL(fileName)
+	att.MimeType = typ
+	att.Name = name
+	att.Thumbnail = app.ImageMetadata{URI: s.URL(thumbName), MimeType: thumbTyp}
+	return att, nil
+}
+
+// reEncode decodes the image and encodes it back in the same format.
+// The standard library encoders don't write any of the metadata blocks of the original file.
+// It returns the decoded image, which for animated GIFs is the first frame, and the new contents of the file.
+// The JPEG images are rotated according to their EXIF orientation first, as it gets lost with the rest of the metadata.
+func reEncode(raw []byte, typ string) (image.Image, []byte, error) {
+	var img image.Image
+	var err error
+	buf := bytes.Buffer{}
+	switch typ {
+	case MimeTypeJPEG:
+		if img, err = jpeg.Decode(bytes.NewReader(raw)); err == nil {
+			img = orient(img, jpegOrientation(raw))
+			err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90})
+		}
+	case MimeTypePNG:
+		if img, err = png.Decode(bytes.NewReader(raw)); err == nil {
+			err = png.Encode(&buf, img)
+		}
+	case MimeTypeGIF:
+		var g *gif.GIF
+		if g, err = gif.DecodeAll(bytes.NewReader(raw)); err == nil {
+			if len(g.Image) == 0 {
+				return nil, nil, errors.NotValidf("no frames in image")
+			}
+			img = g.Image[0]
+			err = gif.EncodeAll(&buf, g)
+		}
+	default:
+		err = errors.NotValidf("unsupported type %s", typ)
+	}
+	return img, buf.Bytes(), err
+}
+
+// jpegOrientation returns the EXIF orientation of the "raw" JPEG image, or 1, the default, when it has none
+func jpegOrientation(raw []byte) int {
+	if len(raw) < 4 || raw[0] != 0xFF || raw[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(raw); {
+		if raw[i] != 0xFF {
+			return 1
+		}
+		marker := raw[i+1]
+		if marker == 0xDA || marker == 0xD9 {
+			// the metadata segments are all before the start of the image data
+			return 1
+		}
+		size := int(binary.BigEndian.Uint16(raw[i+2:]))
+		if size < 2 || i+2+size > len(raw) {
+			return 1
+		}
+		seg := raw[i+4 : i+2+size]
+		if marker == 0xE1 && len(seg) > 6 && string(seg[:6]) == "Exif\x00\x00" {
+			return exifOrientation(seg[6:])
+		}
+		i += 2 + size
+	}
+	return 1
+}
+
+// exifOrientation returns the value of the orientation tag from the first IFD of the "tiff" EXIF data
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var bo binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		bo = binary.LittleEndian
+	case "MM":
+		bo = binary.BigEndian
+	default:
+		return 1
+	}
+	off := int(bo.Uint32(tiff[4:]))
+	if off < 8 || off+2 > len(tiff) {
+		return 1
+	}
+	count := int(bo.Uint16(tiff[off:]))
+	for k := 0; k < count; k++ {
+		e := off + 2 + k*12
+		if e+12 > len(tiff) {
+			return 1
+		}
+		if bo.Uint16(tiff[e:]) != 0x0112 {
+			continue
+		}
+		if o := int(bo.Uint16(tiff[e+8:])); o >= 1 && o <= 8 {
+			return o
+		}
+		return 1
+	}
+	return 1
+}
+
+// orient flips and rotates "src" so it shows the same as the original image with the "o" EXIF orientation
+func orient(src image.Image, o int) image.Image {
+	if o < 2 || o > 8 {
+		return src
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if o >= 5 {
+		// the orientations from 5 to 8 swap the width and the height
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA64(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch o {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, src.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// thumbnail scales down the "src" image so it fits in a "max" sized square, by averaging the pixels of the source
+// which correspond to each pixel of the destination.
+func thumbnail(src image.Image, max int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= max && h <= max {
+		return src
+	}
+	tw, th := max, max
+	if w > h {
+		th = h * max / w
+	} else {
+		tw = w * max / h
+	}
+	if tw < 1 {
+		tw = 1
+	}
+	if th < 1 {
+		th = 1
+	}
+	dst := image.NewRGBA64(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := b.Min.Y+y*h/th, b.Min.Y+(y+1)*h/th
+		for x := 0; x < tw; x++ {
+			x0, x1 := b.Min.X+x*w/tw, b.Min.X+(x+1)*w/tw
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			if n == 0 {
+				continue
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
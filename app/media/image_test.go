@@ -0,0 +1,176 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func pngImage(t *testing.T, w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		img.Set(x, h/2, color.RGBA{R: 255, A: 255})
+	}
+	buf := bytes.Buffer{}
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("unable to encode image: %s", err)
+	}
+	return buf.Bytes()
+}
+
+func TestUpload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "littr-media")
+	if err != nil {
+		t.Fatalf("unable to create temporary dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	s, err := NewLocalStorage(dir, "https://example.com/media/")
+	if err != nil {
+		t.Fatalf("unable to create storage: %s", err)
+	}
+
+	att, err := Upload(s, bytes.NewReader(pngImage(t, 800, 400)), "wide.png", 0)
+	if err != nil {
+		t.Fatalf("unable to upload image: %s", err)
+	}
+	if att.MimeType != MimeTypePNG || att.Name != "wide.png" {
+		t.Errorf("invalid attachment %v", att)
+	}
+	if !strings.HasPrefix(att.URL, "https://example.com/media/") || !strings.HasSuffix(att.URL, ".png") {
+		t.Errorf("invalid attachment URL %s", att.URL)
+	}
+	f, err := s.Open(filepath.Base(att.Thumbnail.URI))
+	if err != nil {
+		t.Fatalf("unable to open thumbnail: %s", err)
+	}
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		t.Fatalf("unable to decode thumbnail: %s", err)
+	}
+	if cfg.Width != ThumbnailSize || cfg.Height != ThumbnailSize/2 {
+		t.Errorf("invalid thumbnail size %dx%d, expected %dx%d", cfg.Width, cfg.Height, ThumbnailSize, ThumbnailSize/2)
+	}
+
+	if _, err := Upload(s, strings.NewReader("#!/bin/sh\necho not an image"), "script.sh", 0); err == nil {
+		t.Errorf("a file which is not an image should not be uploaded")
+	}
+	if _, err := Upload(s, bytes.NewReader(pngImage(t, 800, 400)), "large.png", 100); err == nil {
+		t.Errorf("a file larger than the limit should not be uploaded")
+	}
+}
+
+func TestLocalStorage_fullPath(t *testing.T) {
+	l := LocalStorage{Path: "/var/lib/littr/media"}
+	tests := map[string]string{
+		"image.png":           "/var/lib/littr/media/image.png",
+		"../../../etc/passwd": "/var/lib/littr/media/passwd",
+		"/etc/passwd":         "/var/lib/littr/media/passwd",
+	}
+	for name, exp := range tests {
+		if p, err := l.fullPath(name); err != nil || p != exp {
+			t.Errorf("invalid path %q for %q, expected %q", p, name, exp)
+		}
+	}
+	if _, err := l.fullPath(""); err == nil {
+		t.Errorf("an empty file name should not be valid")
+	}
+}
+
+// exifJPEG returns a JPEG image with an EXIF segment containing the "o" orientation
+func exifJPEG(t *testing.T, w, h int, o uint16) []byte {
+	buf := bytes.Buffer{}
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatalf("unable to encode image: %s", err)
+	}
+	raw := buf.Bytes()
+	exif := []byte("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01")
+	exif = append(exif, byte(o>>8), byte(o), 0, 0, 0, 0, 0, 0)
+	seg := append([]byte{0xFF, 0xE1, byte((len(exif) + 2) >> 8), byte(len(exif) + 2)}, exif...)
+	return append(append(append([]byte{}, raw[:2]...), seg...), raw[2:]...)
+}
+
+func TestJpegOrientation(t *testing.T) {
+	for o := uint16(1); o <= 8; o++ {
+		if v := jpegOrientation(exifJPEG(t, 2, 1, o)); v != int(o) {
+			t.Errorf("invalid orientation %d, expected %d", v, o)
+		}
+	}
+	buf := bytes.Buffer{}
+	jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 1)), nil)
+	if v := jpegOrientation(buf.Bytes()); v != 1 {
+		t.Errorf("invalid orientation %d for an image without EXIF, expected 1", v)
+	}
+	if v := jpegOrientation(pngImage(t, 2, 1)); v != 1 {
+		t.Errorf("invalid orientation %d for a PNG image, expected 1", v)
+	}
+}
+
+func TestOrient(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	red := color.RGBA{R: 255, A: 255}
+	src.Set(0, 0, red)
+	tests := []struct {
+		o    int
+		w, h int
+		x, y int
+	}{
+		{1, 3, 2, 0, 0},
+		{2, 3, 2, 2, 0},
+		{3, 3, 2, 2, 1},
+		{4, 3, 2, 0, 1},
+		{5, 2, 3, 0, 0},
+		{6, 2, 3, 1, 0},
+		{7, 2, 3, 1, 2},
+		{8, 2, 3, 0, 2},
+	}
+	for _, tt := range tests {
+		dst := orient(src, tt.o)
+		if b := dst.Bounds(); b.Dx() != tt.w || b.Dy() != tt.h {
+			t.Errorf("invalid size %dx%d for orientation %d, expected %dx%d", b.Dx(), b.Dy(), tt.o, tt.w, tt.h)
+			continue
+		}
+		if r, _, _, _ := dst.At(tt.x, tt.y).RGBA(); r != 0xffff {
+			t.Errorf("the top left pixel should be at %d,%d for orientation %d", tt.x, tt.y, tt.o)
+		}
+	}
+}
+
+func TestUpload_Orientation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "littr-media")
+	if err != nil {
+		t.Fatalf("unable to create temporary dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	s, err := NewLocalStorage(dir, "https://example.com/media/")
+	if err != nil {
+		t.Fatalf("unable to create storage: %s", err)
+	}
+	att, err := Upload(s, bytes.NewReader(exifJPEG(t, 40, 20, 6)), "rotated.jpg", 0)
+	if err != nil {
+		t.Fatalf("unable to upload image: %s", err)
+	}
+	f, err := s.Open(filepath.Base(att.URL))
+	if err != nil {
+		t.Fatalf("unable to open image: %s", err)
+	}
+	defer f.Close()
+	raw, _ := ioutil.ReadAll(f)
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("unable to decode image: %s", err)
+	}
+	if cfg.Width != 20 || cfg.Height != 40 {
+		t.Errorf("invalid size %dx%d, the image should have been rotated to 20x40", cfg.Width, cfg.Height)
+	}
+	if bytes.Contains(raw, []byte("Exif")) {
+		t.Errorf("the EXIF metadata should have been removed")
+	}
+}
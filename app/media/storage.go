@@ -0,0 +1,87 @@
+// Package media handles the processing and storage of files uploaded together with items.
+package media
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mariusor/littr.go/internal/errors"
+)
+
+// Storage is the interface for the backends that hold uploaded media files
+type Storage interface {
+	Save(name string, r io.Reader) error
+	Open(name string) (io.ReadCloser, error)
+	Remove(name string) error
+	URL(name string) string
+}
+
+// LocalStorage keeps the media files in a directory on the local filesystem
+type LocalStorage struct {
+	Path    string
+	BaseURL string
+}
+
+// NewLocalStorage returns a local filesystem storage rooted at "path", creating the directory if needed.
+// The files are accessible under the "baseURL" prefix.
+func NewLocalStorage(path string, baseURL string) (*LocalStorage, error) {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, errors.Annotatef(err, "unable to create storage path %s", path)
+	}
+	return &LocalStorage{Path: path, BaseURL: strings.TrimRight(baseURL, "/")}, nil
+}
+
+func (l LocalStorage) fullPath(name string) (string, error) {
+	name = filepath.Base(filepath.Clean(name))
+	if name == "." || name == string(filepath.Separator) {
+		return "", errors.NotValidf("invalid file name")
+	}
+	return filepath.Join(l.Path, name), nil
+}
+
+// Save writes the contents of "r" to the "name" file
+func (l LocalStorage) Save(name string, r io.Reader) error {
+	path, err := l.fullPath(name)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return errors.Annotatef(err, "unable to create %s", name)
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, r); err != nil {
+		return errors.Annotatef(err, "unable to write %s", name)
+	}
+	return nil
+}
+
+// Open returns a reader for the "name" file
+func (l LocalStorage) Open(name string) (io.ReadCloser, error) {
+	path, err := l.fullPath(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, errors.NotFoundf("%s not found", name)
+	}
+	return f, err
+}
+
+// Remove deletes the "name" file
+func (l LocalStorage) Remove(name string) error {
+	path, err := l.fullPath(name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// URL returns the absolute URL under which the "name" file is served
+func (l LocalStorage) URL(name string) string {
+	return fmt.Sprintf("%s/%s", l.BaseURL, name)
+}
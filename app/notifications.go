@@ -0,0 +1,41 @@
+package app
+
+import (
+	"time"
+)
+
+type NotificationType string
+
+const (
+	NotificationReply   = NotificationType("reply")
+	NotificationMention = NotificationType("mention")
+	NotificationFollow  = NotificationType("follow")
+)
+
+type NotificationCollection []Notification
+
+// Notification is something that happened which the account it's addressed to should be made aware of
+type Notification struct {
+	Type      NotificationType `json:"type"`
+	For       *Account         `json:"-"`
+	Actor     *Account         `json:"actor,omitempty"`
+	Item      *Item            `json:"item,omitempty"`
+	CreatedAt time.Time        `json:"createdAt"`
+	ReadAt    time.Time        `json:"readAt,omitempty"`
+}
+
+// IsRead shows if the notification was already seen by the account it's addressed to
+func (n Notification) IsRead() bool {
+	return !n.ReadAt.IsZero()
+}
+
+// Unread returns the number of notifications that were not yet seen
+func (n NotificationCollection) Unread() int {
+	cnt := 0
+	for _, no := range n {
+		if !no.IsRead() {
+			cnt++
+		}
+	}
+	return cnt
+}
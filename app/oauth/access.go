@@ -0,0 +1,134 @@
+package oauth
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+)
+
+// AccessToken is an access token an OAuth2 client holds for an account
+type AccessToken struct {
+	// ID identifies the token when revoking it, it is derived from the token, which can't be loaded back from it
+	ID     string
+	Client string
+	ClientMetadata
+	// Prefix identifies the token in the listings, without disclosing it
+	Prefix string
+	Scope  string
+	// IP and UserAgent are of the last request made with the token
+	IP        string
+	UserAgent string
+	CreatedAt time.Time
+	// ExpiresAt is zero for the tokens which don't expire
+	ExpiresAt time.Time
+	// LastSeen is zero for the tokens which were not used yet
+	LastSeen time.Time
+}
+
+type accessToken struct {
+	Client      string
+	AccessToken string
+	ExpiresIn   time.Duration
+	Scope       string
+	IP          string
+	UserAgent   string
+	CreatedAt   time.Time
+	LastSeen    pg.NullTime
+	ClientExtra json.RawMessage
+}
+
+// touchInterval is the duration for which the last use of an access token is not updated again
+const touchInterval = time.Minute
+
+// LoadAccessTokens loads the access tokens of the account with the "hash" key, the most recently used first.
+// The personal access tokens and the "exclude" clients are skipped.
+func (s *Storage) LoadAccessTokens(hash string, exclude ...string) ([]AccessToken, error) {
+	exclude = append(exclude, PersonalTokenClient)
+	var rows []accessToken
+	q := `SELECT "access"."client", "access"."access_token", "access"."expires_in", "access"."scope", "access"."ip",
+		"access"."user_agent", "access"."created_at", "access"."last_seen", "client"."extra" AS "client_extra"
+	FROM "access" INNER JOIN "client" ON "client"."id" = "access"."client"
+	WHERE "access"."extra"->>'hash' = ?0 AND "access"."client" NOT IN (?1)
+	ORDER BY coalesce("access"."last_seen", "access"."created_at") DESC`
+	if _, err := s.db.Query(&rows, q, hash, pg.In(exclude)); err != nil && err != pg.ErrNoRows {
+		s.l.WithContext(log.Ctx{"hash": hash, "table": "access", "operation": "select"}).Error(err.Error())
+		return nil, errors.Annotatef(err, "DB query error")
+	}
+	tokens := make([]AccessToken, 0, len(rows))
+	for _, r := range rows {
+		t := AccessToken{
+			ID:             tokenID(r.AccessToken),
+			Client:         r.Client,
+			ClientMetadata: LoadClientMetadata(r.ClientExtra),
+			Prefix:         r.AccessToken,
+			Scope:          r.Scope,
+			IP:             r.IP,
+			UserAgent:      r.UserAgent,
+			CreatedAt:      r.CreatedAt,
+			LastSeen:       r.LastSeen.Time,
+		}
+		if len(t.Prefix) > PersonalTokenPrefixLen {
+			t.Prefix = t.Prefix[:PersonalTokenPrefixLen]
+		}
+		if r.ExpiresIn > 0 {
+			t.ExpiresAt = r.CreatedAt.Add(r.ExpiresIn * time.Second)
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, nil
+}
+
+// RemoveAccessToken revokes the access token with the "id" identifier of the account with the "hash" key,
+// together with its refresh token
+func (s *Storage) RemoveAccessToken(hash string, id string) error {
+	token, err := s.findToken(hash, id, false)
+	if err != nil {
+		return err
+	}
+	return s.db.RunInTransaction(func(tx *pg.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM "refresh" WHERE "access" = ?0`, token); err != nil {
+			s.l.WithContext(log.Ctx{"hash": hash, "table": "refresh", "operation": "delete"}).Error(err.Error())
+			return errors.Annotate(err, "")
+		}
+		if _, err := tx.Exec(`DELETE FROM "access" WHERE "client" != ?0 AND "access_token" = ?1`, PersonalTokenClient, token); err != nil {
+			s.l.WithContext(log.Ctx{"hash": hash, "table": "access", "operation": "delete"}).Error(err.Error())
+			return errors.Annotate(err, "")
+		}
+		s.l.WithContext(log.Ctx{"hash": hash, "id": id}).Debugf("removed access token")
+		return nil
+	})
+}
+
+// RevokeAccountTokens removes all the refresh tokens of the account with the "hash" key, and the access tokens
+// issued together with them, so no client can keep acting on its behalf.
+// The personal access tokens are kept, they are revoked separately.
+func (s *Storage) RevokeAccountTokens(hash string) error {
+	return s.db.RunInTransaction(func(tx *pg.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM "refresh" WHERE "access" IN (SELECT "access_token" FROM "access" WHERE "extra"->>'hash' = ?0)`, hash); err != nil {
+			s.l.WithContext(log.Ctx{"hash": hash, "table": "refresh", "operation": "delete"}).Error(err.Error())
+			return errors.Annotate(err, "")
+		}
+		if _, err := tx.Exec(`DELETE FROM "access" WHERE "client" != ?0 AND "extra"->>'hash' = ?1`, PersonalTokenClient, hash); err != nil {
+			s.l.WithContext(log.Ctx{"hash": hash, "table": "access", "operation": "delete"}).Error(err.Error())
+			return errors.Annotate(err, "")
+		}
+		s.l.WithContext(log.Ctx{"hash": hash}).Debugf("revoked all access tokens")
+		return nil
+	})
+}
+
+// TouchAccess saves the address and the user agent of a request made with the "token" access token.
+// The last use is updated at most once every touchInterval, so the requests don't all write to the database.
+func (s *Storage) TouchAccess(token string, ip string, userAgent string) error {
+	q := `UPDATE "access" SET "last_seen" = ?1, "ip" = ?2, "user_agent" = ?3
+	WHERE "access_token" = ?0 AND ("last_seen" IS NULL OR "last_seen" < ?4)`
+	now := time.Now().UTC()
+	if _, err := s.db.Exec(q, token, now, ip, userAgent, now.Add(-touchInterval)); err != nil {
+		s.l.WithContext(log.Ctx{"table": "access", "operation": "update"}).Error(err.Error())
+		return errors.Annotate(err, "")
+	}
+	return nil
+}
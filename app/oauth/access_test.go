@@ -0,0 +1,125 @@
+package oauth
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gchaincl/dotsql"
+	"github.com/go-pg/pg"
+	"github.com/mariusor/littr.go/internal/log"
+)
+
+// testStorage connects to the database configured in the DB_* environment variables and creates the OAuth2 tables
+// in a new schema, which is dropped by the returned function.
+// The test is skipped when there's no database to connect to.
+func testStorage(t *testing.T) (*Storage, func()) {
+	t.Helper()
+	host := os.Getenv("DB_HOST")
+	if len(host) == 0 {
+		t.Skip("no test database configured")
+	}
+	port := os.Getenv("DB_PORT")
+	if len(port) == 0 {
+		port = "5432"
+	}
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	db := pg.Connect(&pg.Options{
+		Addr:     fmt.Sprintf("%s:%s", host, port),
+		User:     os.Getenv("DB_USER"),
+		Password: os.Getenv("DB_PASSWORD"),
+		Database: os.Getenv("DB_NAME"),
+		OnConnect: func(c *pg.Conn) error {
+			_, err := c.Exec(fmt.Sprintf(`SET search_path TO "%s", public`, schema))
+			return err
+		},
+	})
+	if _, err := db.Exec(fmt.Sprintf(`CREATE SCHEMA "%s"`, schema)); err != nil {
+		db.Close()
+		t.Skipf("unable to use the test database: %s", err)
+	}
+	cleanup := func() {
+		db.Exec(fmt.Sprintf(`DROP SCHEMA "%s" CASCADE`, schema))
+		db.Close()
+	}
+	dot, err := dotsql.LoadFromFile("../../db/init.sql")
+	if err == nil {
+		var q string
+		if q, err = dot.Raw("create-oauth-storage"); err == nil {
+			_, err = db.Exec(q)
+		}
+	}
+	if err != nil {
+		cleanup()
+		t.Fatalf("unable to create the tables: %s", err)
+	}
+	return New(db, log.Dev(log.PanicLevel)), cleanup
+}
+
+// testToken saves the "token" access token of the "client" for the account with the "hash" key, and its refresh token
+func testToken(t *testing.T, s *Storage, client string, token string, hash string) {
+	t.Helper()
+	ins := `INSERT INTO "access" ("client", "authorize", "previous", "access_token", "refresh_token", "expires_in",
+		"scope", "redirect_uri", "extra", "created_at")
+	VALUES (?0, '', '', ?1, ?2, 3600, '', '', ?3, ?4)`
+	extra := fmt.Sprintf(`{"hash":%q}`, hash)
+	if _, err := s.db.Exec(ins, client, token, "refresh-"+token, extra, time.Now().UTC()); err != nil {
+		t.Fatalf("unable to save access token %s: %s", token, err)
+	}
+	if _, err := s.db.Exec(`INSERT INTO "refresh" ("token", "access") VALUES (?0, ?1)`, "refresh-"+token, token); err != nil {
+		t.Fatalf("unable to save refresh token %s: %s", token, err)
+	}
+}
+
+// testTokens returns the access tokens, and the access tokens which still have a refresh token
+func testTokens(t *testing.T, s *Storage) (map[string]bool, map[string]bool) {
+	t.Helper()
+	var access, refresh []struct {
+		Token string
+	}
+	if _, err := s.db.Query(&access, `SELECT "access_token" AS "token" FROM "access"`); err != nil {
+		t.Fatalf("unable to load the access tokens: %s", err)
+	}
+	if _, err := s.db.Query(&refresh, `SELECT "access" AS "token" FROM "refresh"`); err != nil {
+		t.Fatalf("unable to load the refresh tokens: %s", err)
+	}
+	a, r := make(map[string]bool), make(map[string]bool)
+	for _, row := range access {
+		a[row.Token] = true
+	}
+	for _, row := range refresh {
+		r[row.Token] = true
+	}
+	return a, r
+}
+
+func TestStorage_RevokeAccountTokens(t *testing.T) {
+	s, cleanup := testStorage(t)
+	defer cleanup()
+
+	jane, john := fmt.Sprintf("%032x", "jane"), fmt.Sprintf("%032x", "john")
+	testToken(t, s, "app", "jane-app", jane)
+	testToken(t, s, "other-app", "jane-other-app", jane)
+	testToken(t, s, PersonalTokenClient, "jane-personal", jane)
+	testToken(t, s, "app", "john-app", john)
+
+	if err := s.RevokeAccountTokens(jane); err != nil {
+		t.Fatalf("unable to revoke the tokens: %s", err)
+	}
+	access, refresh := testTokens(t, s)
+	if access["jane-app"] || access["jane-other-app"] || refresh["jane-app"] || refresh["jane-other-app"] {
+		t.Errorf("the tokens of the clients should be revoked, got %v and refresh tokens for %v", access, refresh)
+	}
+	// the personal access tokens are revoked separately
+	if !access["jane-personal"] {
+		t.Errorf("the personal access token should be kept, got %v", access)
+	}
+	if !access["john-app"] || !refresh["john-app"] {
+		t.Errorf("the tokens of the other accounts should be kept, got %v and refresh tokens for %v", access, refresh)
+	}
+
+	if err := s.RevokeAccountTokens(jane); err != nil {
+		t.Errorf("revoking the tokens of an account without any should not fail: %s", err)
+	}
+}
@@ -0,0 +1,111 @@
+package oauth
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+)
+
+// ClientMetadata is what a third party application registered about itself, it is saved in the extra column of the client
+type ClientMetadata struct {
+	Name      string    `json:"name,omitempty"`
+	Website   string    `json:"website,omitempty"`
+	Scope     string    `json:"scope,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+}
+
+// AuthorizedClient is a client which an account authorized to act on its behalf
+type AuthorizedClient struct {
+	ID string
+	ClientMetadata
+	// Granted is the union of the scopes the account granted to the client
+	Granted string
+	// LastAuthorized is when the client last received an access token for the account
+	LastAuthorized time.Time
+}
+
+// LoadClientMetadata returns the metadata a client was registered with
+func LoadClientMetadata(data interface{}) ClientMetadata {
+	m := ClientMetadata{}
+	if raw, err := assertToString(data); err == nil && len(raw) > 0 {
+		json.Unmarshal([]byte(raw), &m)
+	}
+	return m
+}
+
+type authorizedClient struct {
+	Client         string
+	Extra          json.RawMessage
+	Scope          string
+	LastAuthorized time.Time
+}
+
+// LoadAuthorizedClients loads the clients which hold access tokens of the account with the "hash" key.
+// The personal access tokens and the "exclude" clients are skipped.
+func (s *Storage) LoadAuthorizedClients(hash string, exclude ...string) ([]AuthorizedClient, error) {
+	exclude = append(exclude, PersonalTokenClient)
+	var rows []authorizedClient
+	q := `SELECT "access"."client", "client"."extra", string_agg(DISTINCT "access"."scope", ' ') AS "scope",
+		max("access"."created_at") AS "last_authorized"
+	FROM "access" INNER JOIN "client" ON "client"."id" = "access"."client"
+	WHERE "access"."extra"->>'hash' = ?0 AND "access"."client" NOT IN (?1)
+	GROUP BY "access"."client", "client"."extra" ORDER BY "last_authorized" DESC`
+	if _, err := s.db.Query(&rows, q, hash, pg.In(exclude)); err != nil && err != pg.ErrNoRows {
+		s.l.WithContext(log.Ctx{"hash": hash, "table": "access", "operation": "select"}).Error(err.Error())
+		return nil, errors.Annotatef(err, "DB query error")
+	}
+	clients := make([]AuthorizedClient, 0, len(rows))
+	for _, r := range rows {
+		c := AuthorizedClient{
+			ID:             r.Client,
+			ClientMetadata: LoadClientMetadata(r.Extra),
+			LastAuthorized: r.LastAuthorized,
+		}
+		c.Granted = joinUnique(r.Scope)
+		clients = append(clients, c)
+	}
+	return clients, nil
+}
+
+// RevokeClient removes the authorization codes, the access and the refresh tokens the "id" client holds
+// for the account with the "hash" key
+func (s *Storage) RevokeClient(hash string, id string) error {
+	return s.db.RunInTransaction(func(tx *pg.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM "refresh" WHERE "access" IN (SELECT "access_token" FROM "access" WHERE "client" = ?0 AND "extra"->>'hash' = ?1)`, id, hash); err != nil {
+			s.l.WithContext(log.Ctx{"id": id, "hash": hash, "table": "refresh", "operation": "delete"}).Error(err.Error())
+			return errors.Annotate(err, "")
+		}
+		res, err := tx.Exec(`DELETE FROM "access" WHERE "client" = ?0 AND "extra"->>'hash' = ?1`, id, hash)
+		if err != nil {
+			s.l.WithContext(log.Ctx{"id": id, "hash": hash, "table": "access", "operation": "delete"}).Error(err.Error())
+			return errors.Annotate(err, "")
+		}
+		if res.RowsAffected() == 0 {
+			return errors.NotFoundf("application not found")
+		}
+		if _, err := tx.Exec(`DELETE FROM "authorize" WHERE "client" = ?0 AND "extra"->>'hash' = ?1`, id, hash); err != nil {
+			s.l.WithContext(log.Ctx{"id": id, "hash": hash, "table": "authorize", "operation": "delete"}).Error(err.Error())
+			return errors.Annotate(err, "")
+		}
+		s.l.WithContext(log.Ctx{"id": id, "hash": hash}).Debugf("revoked client")
+		return nil
+	})
+}
+
+// joinUnique removes the duplicated values from the space separated list "s"
+func joinUnique(s string) string {
+	seen := make(map[string]bool)
+	res := make([]string, 0)
+	for _, v := range strings.Fields(s) {
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		res = append(res, v)
+	}
+	return strings.Join(res, " ")
+}
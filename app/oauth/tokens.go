@@ -0,0 +1,147 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+	"github.com/openshift/osin"
+)
+
+// PersonalTokenClient is the id of the client the personal access tokens are issued for.
+// It is created together with the first token, so it doesn't need to be seeded.
+const PersonalTokenClient = "personal-access-tokens"
+
+// PersonalToken is an access token created by an account for its own scripts and bots,
+// without going through the authorization flow of an OAuth2 client
+type PersonalToken struct {
+	// ID identifies the token in the revoke requests
+	ID   string
+	Name string
+	// Token is the full bearer token, it is loaded only when the token is created
+	Token string
+	// Prefix identifies the token in the listings, without disclosing it
+	Prefix    string
+	Scope     string
+	CreatedAt time.Time
+	// ExpiresAt is zero for the tokens which don't expire
+	ExpiresAt time.Time
+}
+
+// PersonalTokenPrefixLen is the number of characters of a token which are shown in the listings
+const PersonalTokenPrefixLen = 8
+
+// tokenID returns the identifier of the "token" access token, which is unique like the token without disclosing it.
+// The prefixes of the tokens are shown in the listings, but they can be the same for more tokens.
+func tokenID(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:16])
+}
+
+// findToken returns the access token with the "id" identifier of the account with the "hash" key, from its
+// personal access tokens, when "personal" is set, or from the ones of the OAuth2 clients otherwise.
+func (s *Storage) findToken(hash string, id string, personal bool) (string, error) {
+	op := "="
+	if !personal {
+		op = "!="
+	}
+	var rows []struct {
+		AccessToken string
+	}
+	q := fmt.Sprintf(`SELECT "access_token" FROM "access" WHERE "client" %s ?0 AND "extra"->>'hash' = ?1`, op)
+	if _, err := s.db.Query(&rows, q, PersonalTokenClient, hash); err != nil && err != pg.ErrNoRows {
+		s.l.WithContext(log.Ctx{"hash": hash, "table": "access", "operation": "select"}).Error(err.Error())
+		return "", errors.Annotatef(err, "DB query error")
+	}
+	for _, r := range rows {
+		if tokenID(r.AccessToken) == id {
+			return r.AccessToken, nil
+		}
+	}
+	return "", errors.NotFoundf("token not found")
+}
+
+type personalTokenData struct {
+	Hash      string `json:"hash"`
+	TokenName string `json:"tokenName"`
+}
+
+// CreatePersonalToken saves "t" as an access token of the account serialized in "account".
+// The serialized account is loaded by the API for the requests authorized with the token, the same as for the OAuth2 clients.
+func (s *Storage) CreatePersonalToken(account json.RawMessage, t PersonalToken) error {
+	extra := make(map[string]interface{})
+	if err := json.Unmarshal(account, &extra); err != nil {
+		return errors.Annotatef(err, "invalid account data")
+	}
+	extra["tokenName"] = t.Name
+	data, err := json.Marshal(extra)
+	if err != nil {
+		return errors.Annotatef(err, "invalid account data")
+	}
+	if _, err := s.db.Exec("INSERT INTO client (id, secret, redirect_uri, extra) VALUES (?0, '', '', NULL) ON CONFLICT (id) DO NOTHING", PersonalTokenClient); err != nil {
+		s.l.WithContext(log.Ctx{"id": PersonalTokenClient, "table": "client", "operation": "insert"}).Error(err.Error())
+		return errors.Annotate(err, "")
+	}
+	var expiresIn int32
+	if !t.ExpiresAt.IsZero() {
+		expiresIn = int32(t.ExpiresAt.Sub(t.CreatedAt).Seconds())
+	}
+	return s.SaveAccess(&osin.AccessData{
+		Client:      &osin.DefaultClient{Id: PersonalTokenClient},
+		AccessToken: t.Token,
+		ExpiresIn:   expiresIn,
+		Scope:       t.Scope,
+		CreatedAt:   t.CreatedAt,
+		UserData:    json.RawMessage(data),
+	})
+}
+
+// LoadPersonalTokens loads the personal access tokens of the account with the "hash" key
+func (s *Storage) LoadPersonalTokens(hash string) ([]PersonalToken, error) {
+	var rows []acc
+	q := "SELECT access_token, expires_in, scope, created_at, extra FROM access " +
+		"WHERE client = ?0 AND extra->>'hash' = ?1 ORDER BY created_at DESC"
+	if _, err := s.db.Query(&rows, q, PersonalTokenClient, hash); err != nil && err != pg.ErrNoRows {
+		s.l.WithContext(log.Ctx{"hash": hash, "table": "access", "operation": "select"}).Error(err.Error())
+		return nil, errors.Annotatef(err, "DB query error")
+	}
+	tokens := make([]PersonalToken, 0, len(rows))
+	for _, r := range rows {
+		var data personalTokenData
+		json.Unmarshal(r.Extra, &data)
+		t := PersonalToken{
+			ID:        tokenID(r.AccessToken),
+			Name:      data.TokenName,
+			Prefix:    r.AccessToken,
+			Scope:     r.Scope,
+			CreatedAt: r.CreatedAt,
+		}
+		if len(t.Prefix) > PersonalTokenPrefixLen {
+			t.Prefix = t.Prefix[:PersonalTokenPrefixLen]
+		}
+		if r.ExpiresIn > 0 {
+			t.ExpiresAt = r.CreatedAt.Add(r.ExpiresIn * time.Second)
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, nil
+}
+
+// RemovePersonalToken revokes the personal access token with the "id" identifier of the account with the "hash" key
+func (s *Storage) RemovePersonalToken(hash string, id string) error {
+	token, err := s.findToken(hash, id, true)
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.Exec("DELETE FROM access WHERE client = ?0 AND access_token = ?1", PersonalTokenClient, token); err != nil {
+		s.l.WithContext(log.Ctx{"hash": hash, "table": "access", "operation": "delete"}).Error(err.Error())
+		return errors.Annotate(err, "")
+	}
+	s.l.WithContext(log.Ctx{"hash": hash, "id": id}).Debugf("removed personal access token")
+	return nil
+}
@@ -0,0 +1,20 @@
+package oauth
+
+import "testing"
+
+func TestTokenID(t *testing.T) {
+	a := "3f2a9c1de0b7c5a8e6f4d2b0a9c8e7f6d5c4b3a2e1f0d9c8b7a6f5e4d3c2b1a0"
+	b := "3f2a9c1d00000000000000000000000000000000000000000000000000000000"
+	if a[:PersonalTokenPrefixLen] != b[:PersonalTokenPrefixLen] {
+		t.Fatalf("the tokens should have the same prefix")
+	}
+	if tokenID(a) == tokenID(b) {
+		t.Errorf("tokens with the same prefix should have different identifiers")
+	}
+	if tokenID(a) != tokenID(a) {
+		t.Errorf("the identifier of a token should not change")
+	}
+	if id := tokenID(a); len(id) != 32 || id[:PersonalTokenPrefixLen] == a[:PersonalTokenPrefixLen] {
+		t.Errorf("invalid token identifier %s", id)
+	}
+}
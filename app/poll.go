@@ -0,0 +1,68 @@
+package app
+
+import (
+	"time"
+)
+
+// MaxPollOptions is the maximum number of options a poll can have
+const MaxPollOptions = 10
+
+// PollOption is one of the answers that can be chosen in a poll
+type PollOption struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+type PollOptions []PollOption
+
+// Poll holds the options of an item that is a poll, together with the number of votes each received
+type Poll struct {
+	Options  PollOptions `json:"options"`
+	Multiple bool        `json:"multiple,omitempty"`
+	EndTime  time.Time   `json:"endTime,omitempty"`
+}
+
+// PollVote holds the choices an account made on a poll item
+type PollVote struct {
+	Item        *Item
+	SubmittedBy *Account
+	Choices     []string
+}
+
+// IsPoll returns if the current item is a poll
+func (i Item) IsPoll() bool {
+	return i.HasMetadata() && i.Metadata.Poll != nil
+}
+
+// IsClosed returns if the poll doesn't accept votes anymore
+func (p Poll) IsClosed() bool {
+	return !p.EndTime.IsZero() && p.EndTime.Before(time.Now().UTC())
+}
+
+// HasOption returns if "name" is one of the options of the poll
+func (p Poll) HasOption(name string) bool {
+	for _, o := range p.Options {
+		if o.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Total returns the number of votes the poll received
+func (p Poll) Total() int64 {
+	var total int64
+	for _, o := range p.Options {
+		total += o.Count
+	}
+	return total
+}
+
+// Percent returns the percentage of votes the "o" option received
+func (p Poll) Percent(o PollOption) int64 {
+	total := p.Total()
+	if total == 0 {
+		return 0
+	}
+	return o.Count * 100 / total
+}
@@ -0,0 +1,34 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPoll_IsClosed(t *testing.T) {
+	if (Poll{}).IsClosed() {
+		t.Errorf("a poll without an end time should not be closed")
+	}
+	if !(Poll{EndTime: time.Now().UTC().Add(-time.Minute)}).IsClosed() {
+		t.Errorf("a poll which ended should be closed")
+	}
+	if (Poll{EndTime: time.Now().UTC().Add(time.Hour)}).IsClosed() {
+		t.Errorf("a poll which didn't end should not be closed")
+	}
+}
+
+func TestPoll_Percent(t *testing.T) {
+	p := Poll{Options: PollOptions{{Name: "yes", Count: 3}, {Name: "no", Count: 1}}}
+	if !p.HasOption("yes") || p.HasOption("maybe") {
+		t.Errorf("invalid options %v", p.Options)
+	}
+	if total := p.Total(); total != 4 {
+		t.Errorf("invalid total %d, expected %d", total, 4)
+	}
+	if pc := p.Percent(p.Options[0]); pc != 75 {
+		t.Errorf("invalid percentage %d, expected %d", pc, 75)
+	}
+	if pc := (Poll{}).Percent(PollOption{Name: "yes"}); pc != 0 {
+		t.Errorf("invalid percentage %d for a poll without votes, expected %d", pc, 0)
+	}
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The actions which have a rate limit budget
+const (
+	ActionSubmit   = "submit"
+	ActionComment  = "comment"
+	ActionVote     = "vote"
+	ActionRegister = "register"
+	ActionOutbox   = "outbox"
+	ActionClient   = "client"
+)
+
+// RateLimit is the number of requests allowed for an action in a period
+type RateLimit struct {
+	Count  int
+	Period time.Duration
+}
+
+// DefaultRateLimits are the budgets of the actions which don't have a RATE_LIMIT_{ACTION} environment variable.
+// The outbox budget needs to cover the others, as the frontend submits everything through the API.
+var DefaultRateLimits = map[string]RateLimit{
+	ActionSubmit:   {Count: 10, Period: time.Hour},
+	ActionComment:  {Count: 20, Period: 10 * time.Minute},
+	ActionVote:     {Count: 60, Period: 10 * time.Minute},
+	ActionRegister: {Count: 3, Period: time.Hour},
+	ActionOutbox:   {Count: 200, Period: 10 * time.Minute},
+	ActionClient:   {Count: 10, Period: time.Hour},
+}
+
+// Enabled returns if the rate limit restricts anything
+func (r RateLimit) Enabled() bool {
+	return r.Count > 0 && r.Period > 0
+}
+
+func (r RateLimit) String() string {
+	return fmt.Sprintf("%d/%s", r.Count, r.Period)
+}
+
+// ParseRateLimit loads a rate limit from the "count/period" format, eg: 10/1h
+// A zero count disables the rate limit.
+func ParseRateLimit(s string) (RateLimit, error) {
+	r := RateLimit{}
+	s = strings.TrimSpace(s)
+	if s == "0" {
+		return r, nil
+	}
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) != 2 {
+		return r, fmt.Errorf("invalid rate limit %q, expected count/period", s)
+	}
+	var err error
+	if r.Count, err = strconv.Atoi(parts[0]); err != nil || r.Count < 0 {
+		return r, fmt.Errorf("invalid rate limit count %q", parts[0])
+	}
+	if r.Period, err = time.ParseDuration(parts[1]); err != nil || r.Period < 0 {
+		return r, fmt.Errorf("invalid rate limit period %q", parts[1])
+	}
+	return r, nil
+}
+
+// loadRateLimits returns the budgets of the actions from the RATE_LIMIT_{ACTION} environment variables
+func loadRateLimits(l *Application) map[string]RateLimit {
+	limits := make(map[string]RateLimit, len(DefaultRateLimits))
+	for action, def := range DefaultRateLimits {
+		limits[action] = def
+		val := os.Getenv(fmt.Sprintf("RATE_LIMIT_%s", strings.ToUpper(action)))
+		if len(val) == 0 {
+			continue
+		}
+		r, err := ParseRateLimit(val)
+		if err != nil {
+			l.Logger.Warnf("%s, using the default %s for %s", err, def, action)
+			continue
+		}
+		limits[action] = r
+	}
+	return limits
+}
@@ -0,0 +1,144 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/internal/errors"
+)
+
+// Store keeps the number of requests made for a key in fixed windows of time
+type Store interface {
+	// Hit counts a new request for "key" in the window of length "period" which is current at "now".
+	// It returns the number of requests counted in the window, including this one, and when the window ends.
+	Hit(key string, period time.Duration, now time.Time) (int, time.Time, error)
+}
+
+// cleanupEvery is the number of hits after which the expired windows are removed from the memory store
+const cleanupEvery = 1000
+
+type window struct {
+	hits    int
+	resetAt time.Time
+}
+
+// MemoryStore keeps the counters in the memory of the process, so it works only for single instance setups
+type MemoryStore struct {
+	mu      sync.Mutex
+	windows map[string]*window
+	hits    int
+}
+
+// NewMemoryStore returns a new empty in memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{windows: make(map[string]*window)}
+}
+
+// Hit counts a new request for "key"
+func (m *MemoryStore) Hit(key string, period time.Duration, now time.Time) (int, time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.hits++
+	if m.hits%cleanupEvery == 0 {
+		for k, w := range m.windows {
+			if !w.resetAt.After(now) {
+				delete(m.windows, k)
+			}
+		}
+	}
+	w, ok := m.windows[key]
+	if !ok || !w.resetAt.After(now) {
+		w = &window{resetAt: now.Add(period)}
+		m.windows[key] = w
+	}
+	w.hits++
+	return w.hits, w.resetAt, nil
+}
+
+// Limiter checks the requests against the budgets of their actions
+type Limiter struct {
+	Store  Store
+	Limits map[string]app.RateLimit
+	// NewAccountAge is the age until which the accounts get half of the budgets
+	NewAccountAge time.Duration
+	// MinScore is the score under which the accounts get half of the budgets
+	MinScore int64
+}
+
+// budget returns the rate limit of "action" for the "a" account, which is tighter for the new or low score accounts
+func (l Limiter) budget(action string, a app.Account) app.RateLimit {
+	r := l.Limits[action]
+	if !a.IsLogged() || a.IsModerator() {
+		return r
+	}
+	if a.Score < l.MinScore || (!a.CreatedAt.IsZero() && time.Since(a.CreatedAt) < l.NewAccountAge) {
+		if r.Count = r.Count / 2; r.Count < 1 {
+			r.Count = 1
+		}
+	}
+	return r
+}
+
+// Allow counts a request of the "a" account, from the "ip" address, for "action".
+// The requests are counted both for the account and for the address, the anonymous ones only for the address.
+// It returns an error which can be checked with errors.IsTooManyRequests when one of the budgets is used up,
+// any other error means the store is unavailable, and the callers let the request through.
+func (l Limiter) Allow(action string, a app.Account, ip string) error {
+	if l.Store == nil {
+		return nil
+	}
+	r := l.budget(action, a)
+	if !r.Enabled() {
+		return nil
+	}
+	keys := make([]string, 0, 2)
+	if a.IsLogged() {
+		keys = append(keys, fmt.Sprintf("%s:account:%s", action, a.Hash))
+	}
+	if len(ip) > 0 {
+		keys = append(keys, fmt.Sprintf("%s:ip:%s", action, ip))
+	}
+	now := time.Now().UTC()
+	for _, key := range keys {
+		count := r.Count
+		if strings.Contains(key, ":ip:") {
+			// more accounts can share an address, so it gets the full budget
+			count = l.Limits[action].Count
+		}
+		hits, resetAt, err := l.Store.Hit(key, r.Period, now)
+		if err != nil {
+			return errors.Annotatef(err, "unable to check the rate limit of %s", action)
+		}
+		if hits > count {
+			retry := time.Duration(math.Ceil(resetAt.Sub(now).Seconds())) * time.Second
+			return errors.TooManyRequestsf(retry, "too many %s requests, please try again in %s", action, retry)
+		}
+	}
+	return nil
+}
+
+// ClientIP returns the address of the client making the request.
+// When "trustProxy" is set the last address from the X-Forwarded-For header is used: it's the one added
+// by the reverse proxy in front of the application, the ones before it come from the client and can be forged.
+func ClientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if fwd := r.Header["X-Forwarded-For"]; len(fwd) > 0 {
+			addrs := strings.Split(fwd[len(fwd)-1], ",")
+			if ip := strings.TrimSpace(addrs[len(addrs)-1]); len(ip) > 0 {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mariusor/littr.go/app"
+	"github.com/mariusor/littr.go/internal/errors"
+)
+
+func TestMemoryStore_Hit(t *testing.T) {
+	s := NewMemoryStore()
+	now := time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	for i := 1; i <= 3; i++ {
+		hits, reset, _ := s.Hit("vote:ip:127.0.0.1", time.Minute, now.Add(time.Duration(i)*time.Second))
+		if hits != i {
+			t.Errorf("expected %d hits, got %d", i, hits)
+		}
+		if exp := now.Add(time.Second + time.Minute); !reset.Equal(exp) {
+			t.Errorf("expected the window to end at %s, got %s", exp, reset)
+		}
+	}
+	if hits, _, _ := s.Hit("vote:ip:127.0.0.2", time.Minute, now); hits != 1 {
+		t.Errorf("expected the keys to be counted separately, got %d hits", hits)
+	}
+	if hits, _, _ := s.Hit("vote:ip:127.0.0.1", time.Minute, now.Add(2*time.Minute)); hits != 1 {
+		t.Errorf("expected a new window after the old one ended, got %d hits", hits)
+	}
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	l := Limiter{
+		Store:  NewMemoryStore(),
+		Limits: map[string]app.RateLimit{app.ActionRegister: {Count: 2, Period: time.Hour}},
+	}
+	for i := 0; i < 2; i++ {
+		if err := l.Allow(app.ActionRegister, app.AnonymousAccount, "127.0.0.1"); err != nil {
+			t.Errorf("expected request %d to be allowed, got %s", i, err)
+		}
+	}
+	err := l.Allow(app.ActionRegister, app.AnonymousAccount, "127.0.0.1")
+	if !errors.IsTooManyRequests(err) {
+		t.Fatalf("expected a too many requests error, got %v", err)
+	}
+	if retry, ok := errors.RetryAfter(err); !ok || retry <= 0 || retry > time.Hour {
+		t.Errorf("invalid retry after %s", retry)
+	}
+	if err := l.Allow(app.ActionVote, app.AnonymousAccount, "127.0.0.1"); err != nil {
+		t.Errorf("expected the actions without a budget to be allowed, got %s", err)
+	}
+}
+
+func TestLimiter_budget(t *testing.T) {
+	l := Limiter{
+		Limits:        map[string]app.RateLimit{app.ActionSubmit: {Count: 10, Period: time.Hour}},
+		NewAccountAge: 72 * time.Hour,
+	}
+	old := app.Account{Handle: "jane", Hash: app.Hash("jane"), CreatedAt: time.Now().Add(-100 * time.Hour)}
+	if r := l.budget(app.ActionSubmit, old); r.Count != 10 {
+		t.Errorf("expected the full budget for old accounts, got %d", r.Count)
+	}
+	young := app.Account{Handle: "john", Hash: app.Hash("john"), CreatedAt: time.Now().Add(-time.Hour)}
+	if r := l.budget(app.ActionSubmit, young); r.Count != 5 {
+		t.Errorf("expected half the budget for new accounts, got %d", r.Count)
+	}
+	low := old
+	low.Score = -1
+	if r := l.budget(app.ActionSubmit, low); r.Count != 5 {
+		t.Errorf("expected half the budget for low score accounts, got %d", r.Count)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		fwd        []string
+		trustProxy bool
+		ip         string
+	}{
+		{"no proxy", nil, false, "10.0.0.1"},
+		{"untrusted header", []string{"1.2.3.4"}, false, "10.0.0.1"},
+		{"trusted proxy", []string{"1.2.3.4"}, true, "1.2.3.4"},
+		{"forged by the client", []string{"6.6.6.6, 1.2.3.4"}, true, "1.2.3.4"},
+		{"more headers", []string{"6.6.6.6", "5.5.5.5, 1.2.3.4"}, true, "1.2.3.4"},
+		{"empty header", []string{""}, true, "10.0.0.1"},
+		{"missing header", nil, true, "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = "10.0.0.1:4321"
+		for _, f := range tt.fwd {
+			r.Header.Add("X-Forwarded-For", f)
+		}
+		if ip := ClientIP(r, tt.trustProxy); ip != tt.ip {
+			t.Errorf("%s: invalid client address %q, expected %q", tt.name, ip, tt.ip)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package app
+
+// Role groups the permissions of an account on the instance
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+// Roles are the valid roles, from the least to the most privileged
+var Roles = []Role{
+	RoleUser,
+	RoleModerator,
+	RoleAdmin,
+}
+
+// Permission is an action which is restricted to some roles
+type Permission string
+
+const (
+	// PermDeleteItem allows deleting the items of other accounts
+	PermDeleteItem Permission = "delete-item"
+	// PermLockItem allows locking and unlocking threads
+	PermLockItem Permission = "lock-item"
+	// PermBanAccount allows blocking accounts and pruning invite trees
+	PermBanAccount Permission = "ban-account"
+	// PermVerifyAccount allows confirming accounts without the emailed link
+	PermVerifyAccount Permission = "verify-account"
+	// PermInstanceSettings allows viewing the instance settings and changing the roles of the accounts
+	PermInstanceSettings Permission = "instance-settings"
+)
+
+// RolePermissions are the permissions granted to each role, the regular users don't have any
+var RolePermissions = map[Role][]Permission{
+	RoleModerator: {
+		PermDeleteItem,
+		PermLockItem,
+		PermBanAccount,
+		PermVerifyAccount,
+	},
+	RoleAdmin: {
+		PermDeleteItem,
+		PermLockItem,
+		PermBanAccount,
+		PermVerifyAccount,
+		PermInstanceSettings,
+	},
+}
+
+// ValidRole returns if "r" is one of the known roles
+func ValidRole(r Role) bool {
+	for _, v := range Roles {
+		if v == r {
+			return true
+		}
+	}
+	return false
+}
+
+// Has returns if the role was granted the "p" permission
+func (r Role) Has(p Permission) bool {
+	for _, perm := range RolePermissions[r] {
+		if perm == p {
+			return true
+		}
+	}
+	return false
+}
+
+// GetRole returns the role of the account.
+// The handles listed in the ADMINS and MODERATORS environment variables have those roles regardless of the stored one,
+// and the remote accounts are always regular users.
+func (a Account) GetRole() Role {
+	if !a.IsLogged() || !a.IsLocal() {
+		return RoleUser
+	}
+	for _, handle := range Instance.Config.Admins {
+		if handle == a.Handle {
+			return RoleAdmin
+		}
+	}
+	for _, handle := range Instance.Config.Moderators {
+		if handle == a.Handle && a.Role != RoleAdmin {
+			return RoleModerator
+		}
+	}
+	if !ValidRole(a.Role) {
+		return RoleUser
+	}
+	return a.Role
+}
+
+// Can returns if the account has all the "perms" permissions
+func (a Account) Can(perms ...Permission) bool {
+	role := a.GetRole()
+	for _, p := range perms {
+		if !role.Has(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsAdmin returns if the account can manage the instance
+func (a Account) IsAdmin() bool {
+	return a.GetRole() == RoleAdmin
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAccount_Can(t *testing.T) {
+	defer func(admins, moderators []string) {
+		Instance.Config.Admins = admins
+		Instance.Config.Moderators = moderators
+	}(Instance.Config.Admins, Instance.Config.Moderators)
+	Instance.Config.Admins = []string{"root"}
+	Instance.Config.Moderators = []string{"mod"}
+
+	now := time.Now()
+	tests := []struct {
+		acc  Account
+		role Role
+	}{
+		{Account{Handle: "jane", Hash: Hash("dc6f5f5bf55bc1073715c98c69fa7ca8"), CreatedAt: now}, RoleUser},
+		{Account{Handle: "john", Hash: Hash("162edb32c80d0e6dd3114fbb59d6273b"), CreatedAt: now, Role: RoleModerator}, RoleModerator},
+		{Account{Handle: "mod", Hash: Hash("64e55785250f59637e7f578167e2c112"), CreatedAt: now}, RoleModerator},
+		{Account{Handle: "root", Hash: Hash("eacff9ddf379bd9fc8274c5a9f4cae08"), CreatedAt: now}, RoleAdmin},
+		{Account{Handle: "eve", Hash: Hash("0ae3b3f41b7c3c09b2b5b2a1a5f9f0c4"), CreatedAt: now, Role: Role("superuser")}, RoleUser},
+		{AnonymousAccount, RoleUser},
+	}
+	for _, tt := range tests {
+		if r := tt.acc.GetRole(); r != tt.role {
+			t.Errorf("invalid role %s for %s, expected %s", r, tt.acc.Handle, tt.role)
+		}
+		if can := tt.acc.Can(PermLockItem, PermVerifyAccount); can != (tt.role != RoleUser) {
+			t.Errorf("invalid moderation permissions %t for %s", can, tt.acc.Handle)
+		}
+		if can := tt.acc.Can(PermInstanceSettings); can != (tt.role == RoleAdmin) {
+			t.Errorf("invalid instance settings permission %t for %s", can, tt.acc.Handle)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package app
+
+import "strings"
+
+// Scope limits what a client can do with an access token
+type Scope string
+
+const (
+	// ScopeRead allows loading the collections and the notifications of the account
+	ScopeRead Scope = "read"
+	// ScopeWriteItems allows submitting, editing and deleting items
+	ScopeWriteItems Scope = "write:items"
+	// ScopeWriteVotes allows voting items
+	ScopeWriteVotes Scope = "write:votes"
+	// ScopeFollow allows following and blocking accounts
+	ScopeFollow Scope = "follow"
+	// ScopeAdmin allows the moderation and administration actions the account has permissions for
+	ScopeAdmin Scope = "admin"
+)
+
+// Scopes are the valid scopes
+var Scopes = []Scope{
+	ScopeRead,
+	ScopeWriteItems,
+	ScopeWriteVotes,
+	ScopeFollow,
+	ScopeAdmin,
+}
+
+// ValidScope returns if "s" is one of the known scopes
+func ValidScope(s Scope) bool {
+	for _, v := range Scopes {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseScopes loads the known scopes from the space separated list of the OAuth2 scope parameter
+func ParseScopes(s string) []Scope {
+	scopes := make([]Scope, 0)
+	for _, f := range strings.Fields(s) {
+		sc := Scope(f)
+		if !ValidScope(sc) {
+			continue
+		}
+		dup := false
+		for _, v := range scopes {
+			dup = dup || v == sc
+		}
+		if !dup {
+			scopes = append(scopes, sc)
+		}
+	}
+	return scopes
+}
+
+// JoinScopes returns the OAuth2 scope parameter for "scopes"
+func JoinScopes(scopes []Scope) string {
+	s := make([]string, len(scopes))
+	for i, sc := range scopes {
+		s[i] = string(sc)
+	}
+	return strings.Join(s, " ")
+}
@@ -0,0 +1,17 @@
+package app
+
+import "time"
+
+// Session is a browser session an account is logged in with
+type Session struct {
+	// ID is the random identifier saved in the session cookie
+	ID      string   `json:"-"`
+	Account *Account `json:"-"`
+	// IP and UserAgent are of the last request made with the session
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"userAgent,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+type SessionCollection []Session
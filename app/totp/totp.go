@@ -0,0 +1,171 @@
+// Package totp implements the time based one time passwords from RFC 6238 used as a second login factor.
+package totp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	// Digits is the length of the generated codes
+	Digits = 6
+	// Period is the duration for which a code is valid
+	Period = 30 * time.Second
+	// Skew is the number of periods before and after the current one for which we still accept codes,
+	// to allow for clocks which are not in sync
+	Skew = 1
+	// SecretSize is the number of random bytes of a secret
+	SecretSize = 20
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewSecret returns a random secret, base32 encoded as the authenticator applications expect it
+func NewSecret() (string, error) {
+	b := make([]byte, SecretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Annotatef(err, "unable to generate secret")
+	}
+	return encoding.EncodeToString(b), nil
+}
+
+// decodeSecret accepts the secrets with or without padding, spaces or lowercase letters
+func decodeSecret(secret string) ([]byte, error) {
+	s := strings.ToUpper(strings.Replace(secret, " ", "", -1))
+	return encoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// hotp returns the RFC 4226 code of the "counter" value
+func hotp(key []byte, counter uint64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	off := sum[len(sum)-1] & 0xf
+	bin := binary.BigEndian.Uint32(sum[off:off+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", Digits, bin%mod)
+}
+
+// Code returns the code of the "secret" valid at the "t" time
+func Code(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", errors.Annotatef(err, "invalid secret")
+	}
+	return hotp(key, uint64(t.Unix())/uint64(Period/time.Second)), nil
+}
+
+// Validate returns if "code" is a valid code of the "secret" at the "t" time
+func Validate(secret string, code string, t time.Time) bool {
+	_, ok := ValidateAfter(secret, code, t, -1)
+	return ok
+}
+
+// ValidateAfter returns the time step for which "code" is a valid code of the "secret" at the "t" time.
+// The steps up to, and including, "last" are refused, so a code can't be used again after it was accepted.
+func ValidateAfter(secret string, code string, t time.Time, last int64) (int64, bool) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+	code = strings.Replace(code, " ", "", -1)
+	if len(code) != Digits {
+		return 0, false
+	}
+	counter := int64(t.Unix()) / int64(Period/time.Second)
+	for i := -Skew; i <= Skew; i++ {
+		c := counter + int64(i)
+		if c < 0 || c <= last {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(hotp(key, uint64(c))), []byte(code)) == 1 {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+// sealKey returns the AES-256 key derived from the "key" server key
+func sealKey(key []byte) (cipher.AEAD, error) {
+	if len(key) == 0 {
+		return nil, errors.NotImplementedf("no key for the second factor secrets")
+	}
+	k := sha256.Sum256(key)
+	block, err := aes.NewCipher(k[:])
+	if err != nil {
+		return nil, errors.Annotatef(err, "invalid key")
+	}
+	return cipher.NewGCM(block)
+}
+
+// Seal encrypts the "secret" with the "key" server key, so the secrets stored with the accounts
+// can't be used without it
+func Seal(key []byte, secret string) (string, error) {
+	gcm, err := sealKey(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", errors.Annotatef(err, "unable to generate nonce")
+	}
+	return base64.RawStdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+// Open decrypts a secret encrypted by Seal with the same "key"
+func Open(key []byte, sealed string) (string, error) {
+	gcm, err := sealKey(key)
+	if err != nil {
+		return "", err
+	}
+	raw, err := base64.RawStdEncoding.DecodeString(sealed)
+	if err != nil || len(raw) < gcm.NonceSize() {
+		return "", errors.NotValidf("invalid sealed secret")
+	}
+	secret, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.NotValidf("unable to decrypt the secret")
+	}
+	return string(secret), nil
+}
+
+// URI returns the otpauth:// URI the authenticator applications use for enrolling the "secret"
+func URI(issuer string, account string, secret string) string {
+	label := url.PathEscape(fmt.Sprintf("%s:%s", issuer, account))
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("digits", fmt.Sprintf("%d", Digits))
+	q.Set("period", fmt.Sprintf("%d", int(Period/time.Second)))
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, q.Encode())
+}
+
+// QRCode returns a PNG image of "size" pixels with the QR code of the "uri" enrollment URI
+func QRCode(uri string, size int) ([]byte, error) {
+	png, err := qrcode.Encode(uri, qrcode.Medium, size)
+	if err != nil {
+		return nil, errors.Annotatef(err, "unable to generate QR code")
+	}
+	return png, nil
+}
@@ -0,0 +1,110 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// the SHA1 secret from the RFC 6238 test vectors
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		ts   int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		code, err := Code(rfcSecret, time.Unix(tt.ts, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != tt.code {
+			t.Errorf("code at %d is %s, expected %s", tt.ts, code, tt.code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	if !Validate(rfcSecret, "005924", now) {
+		t.Errorf("current code was not accepted")
+	}
+	if !Validate(strings.ToLower(rfcSecret), "005 924", now.Add(Period)) {
+		t.Errorf("code of the previous period was not accepted")
+	}
+	if Validate(rfcSecret, "005924", now.Add(3*Period)) {
+		t.Errorf("expired code was accepted")
+	}
+	if Validate(rfcSecret, "", now) || Validate("not-base32!", "005924", now) {
+		t.Errorf("invalid input was accepted")
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	s, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Code(s, time.Now()); err != nil {
+		t.Errorf("generated secret %q can't be used: %s", s, err)
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("littr.me", "jane doe", "ABCDEFGH")
+	exp := "otpauth://totp/littr.me:jane%20doe?digits=6&issuer=littr.me&period=30&secret=ABCDEFGH"
+	if uri != exp {
+		t.Errorf("URI is %s, expected %s", uri, exp)
+	}
+}
+
+func TestValidateAfter(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step, ok := ValidateAfter(rfcSecret, "005924", now, -1)
+	if !ok || step != 1234567890/30 {
+		t.Fatalf("current code was not accepted, got step %d", step)
+	}
+	if _, ok := ValidateAfter(rfcSecret, "005924", now, step); ok {
+		t.Errorf("a code was accepted again for the same time step")
+	}
+	if _, ok := ValidateAfter(rfcSecret, "005924", now.Add(Period), step); ok {
+		t.Errorf("a code was accepted again in the next period")
+	}
+	if _, ok := ValidateAfter(rfcSecret, "005924", now, step-1); !ok {
+		t.Errorf("code after the last accepted step was not accepted")
+	}
+}
+
+func TestSeal(t *testing.T) {
+	key := []byte("server-key")
+	sealed, err := Seal(key, rfcSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sealed, rfcSecret) {
+		t.Errorf("the sealed secret %q contains the secret", sealed)
+	}
+	if again, _ := Seal(key, rfcSecret); again == sealed {
+		t.Errorf("sealing the same secret twice should use different nonces")
+	}
+	if s, err := Open(key, sealed); err != nil || s != rfcSecret {
+		t.Errorf("invalid opened secret %q: %v", s, err)
+	}
+	if _, err := Open([]byte("another-key"), sealed); err == nil {
+		t.Errorf("the secret was opened with a different key")
+	}
+	if _, err := Open(key, sealed[:10]); err == nil {
+		t.Errorf("a truncated secret was opened")
+	}
+	if _, err := Seal(nil, rfcSecret); err == nil {
+		t.Errorf("a secret was sealed without a key")
+	}
+}
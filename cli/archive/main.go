@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"github.com/go-pg/pg"
+	"github.com/mariusor/littr.go/app/cmd"
+	"github.com/mariusor/littr.go/app/db"
+	"github.com/mariusor/littr.go/internal/log"
+	"os"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+var defaultAge, _ = time.ParseDuration("4320h")
+
+func main() {
+	var age time.Duration
+	if a, err := time.ParseDuration(os.Getenv("ARCHIVE_AFTER")); err == nil && a > 0 {
+		defaultAge = a
+	}
+	flag.DurationVar(&age, "age", defaultAge, "the age after which threads get archived, default is ARCHIVE_AFTER or 4320h")
+	flag.Parse()
+
+	cmd.Logger = log.Dev(log.TraceLevel)
+	db.Logger = cmd.Logger
+
+	db.Config.DB = pg.Connect(cmd.PGConfigFromENV())
+
+	err := cmd.ArchiveItems(age)
+	cmd.E(err)
+}
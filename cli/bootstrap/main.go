@@ -36,7 +36,7 @@ func getTestAccount(hostname string) []interface{} {
 
 func main() {
 	var dbRootUser, dbHost string
-	var seed, testing, overwrite bool
+	var seed, testing, overwrite, migrate bool
 
 	cmd.Logger = log.Dev(log.TraceLevel)
 
@@ -45,6 +45,7 @@ func main() {
 	flag.BoolVar(&seed, "seed", false, "seed database with data")
 	flag.BoolVar(&testing, "testing", false, "seed database with testing data")
 	flag.BoolVar(&overwrite, "overwrite", false, "destroy database if exists and recreate")
+	flag.BoolVar(&migrate, "migrate", false, "update the existing database to the current schema and data layout")
 	flag.Parse()
 
 	dbRootPw := os.Getenv("POSTGRES_PASSWORD")
@@ -66,6 +67,11 @@ func main() {
 		Addr:     dbHost + ":5432",
 	}
 
+	if migrate {
+		cmd.E(cmd.MigrateDB(o))
+		return
+	}
+
 	checkDb := cmd.CreateDatabase(o, r, overwrite)
 	if checkDb == cmd.ErrDbExists {
 		cmd.Logger.Warnf("WTF: %s", checkDb)
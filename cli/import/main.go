@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"github.com/go-pg/pg"
+	"github.com/mariusor/littr.go/app/cmd"
+	"github.com/mariusor/littr.go/app/db"
+	"github.com/mariusor/littr.go/internal/errors"
+	"github.com/mariusor/littr.go/internal/log"
+	"os"
+
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	var handle string
+	var archive string
+	flag.StringVar(&handle, "handle", "", "the local account the archive is imported for")
+	flag.StringVar(&archive, "archive", "", "the path of the archive exported from the old account")
+	flag.Parse()
+
+	cmd.Logger = log.Dev(log.TraceLevel)
+	db.Logger = cmd.Logger
+
+	if len(handle) == 0 || len(archive) == 0 {
+		cmd.E(errors.NotValidf("both -handle and -archive are needed"))
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	db.Config.DB = pg.Connect(cmd.PGConfigFromENV())
+
+	err := cmd.ImportAccount(handle, archive)
+	cmd.E(err)
+}
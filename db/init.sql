@@ -1,5 +1,15 @@
 -- name: drop-tables
 DROP TABLE IF EXISTS votes CASCADE;
+DROP TABLE IF EXISTS notifications CASCADE;
+DROP TABLE IF EXISTS rate_limits CASCADE;
+DROP TABLE IF EXISTS identities CASCADE;
+DROP TABLE IF EXISTS sessions CASCADE;
+DROP TABLE IF EXISTS invitations CASCADE;
+DROP TABLE IF EXISTS invites CASCADE;
+DROP TABLE IF EXISTS saved_items CASCADE;
+DROP TABLE IF EXISTS poll_votes CASCADE;
+DROP TABLE IF EXISTS item_tags CASCADE;
+DROP TABLE IF EXISTS tags CASCADE;
 DROP TABLE IF EXISTS items CASCADE;
 DROP TABLE IF EXISTS accounts CASCADE;
 DROP TABLE IF EXISTS instances CASCADE;
@@ -14,6 +24,16 @@ DROP TYPE  IF EXISTS "types";
 
 -- name: truncate-tables
 TRUNCATE votes RESTART IDENTITY CASCADE;
+TRUNCATE notifications RESTART IDENTITY CASCADE;
+TRUNCATE rate_limits RESTART IDENTITY CASCADE;
+TRUNCATE identities RESTART IDENTITY CASCADE;
+TRUNCATE sessions RESTART IDENTITY CASCADE;
+TRUNCATE invitations RESTART IDENTITY CASCADE;
+TRUNCATE invites RESTART IDENTITY CASCADE;
+TRUNCATE saved_items RESTART IDENTITY CASCADE;
+TRUNCATE poll_votes RESTART IDENTITY CASCADE;
+TRUNCATE item_tags RESTART IDENTITY CASCADE;
+TRUNCATE tags RESTART IDENTITY CASCADE;
 TRUNCATE accounts RESTART IDENTITY CASCADE;
 TRUNCATE items RESTART IDENTITY CASCADE;
 TRUNCATE instances RESTART IDENTITY CASCADE;
@@ -35,9 +55,13 @@ create table accounts (
   created_at timestamp default current_timestamp,
   updated_at timestamp default current_timestamp,
   metadata jsonb default '{}',
-  flags bit(8) default 0::bit(8)
+  flags bit(8) default 0::bit(8),
+  role varchar not null default 'user' -- user, moderator or admin
 );
 
+-- name: alter-accounts-role
+alter table accounts add column if not exists role varchar not null default 'user';
+
 -- name: create-items
 create table items (
   id serial constraint items_pk primary key,
@@ -66,6 +90,123 @@ create table votes (
   constraint unique_vote_submitted_item unique (submitted_by, item_id)
 );
 
+-- name: create-tags
+create table if not exists tags (
+  id serial constraint tags_pk primary key,
+  name varchar not null constraint tags_name_key unique,
+  created_at timestamp default current_timestamp
+);
+create table if not exists item_tags (
+  item_id int references items(id) on delete cascade,
+  tag_id int references tags(id) on delete cascade,
+  constraint item_tags_pk primary key (item_id, tag_id)
+);
+create index if not exists item_tags_tag_id_idx on item_tags (tag_id);
+
+-- this is used to move the tags stored in the items metadata to the tags tables
+-- name: populate-tags
+INSERT INTO tags (name)
+  SELECT DISTINCT lower(ltrim(t->>'name', '#')) FROM items, jsonb_array_elements(items.metadata->'tags') AS t
+  WHERE coalesce(ltrim(t->>'name', '#'), '') != ''
+ON CONFLICT (name) DO NOTHING;
+INSERT INTO item_tags (item_id, tag_id)
+  SELECT DISTINCT items.id, tags.id FROM items, jsonb_array_elements(items.metadata->'tags') AS t, tags
+  WHERE tags.name = lower(ltrim(t->>'name', '#'))
+ON CONFLICT DO NOTHING;
+
+-- name: create-notifications
+create table if not exists notifications (
+  id serial constraint notifications_pk primary key,
+  account_id int references accounts(id) on delete cascade, -- the account being notified
+  type varchar not null, -- reply, mention, follow
+  actor_id int references accounts(id) on delete cascade default NULL, -- the account that generated the notification
+  actor varchar default NULL, -- the IRI of the actor, for remote actors that we don't have an account for
+  item_id int references items(id) on delete cascade default NULL,
+  created_at timestamp default current_timestamp,
+  read_at timestamp default NULL
+);
+-- the actor and the item are optional, and NULL values never conflict in a unique constraint
+create unique index if not exists unique_notification on notifications (account_id, type, coalesce(actor_id, 0), coalesce(actor, ''), coalesce(item_id, 0));
+create index if not exists notifications_account_id_idx on notifications (account_id, read_at);
+
+-- name: alter-notifications-unique
+alter table notifications drop constraint if exists unique_notification;
+delete from notifications as n using notifications as d
+  where n.id > d.id and n.account_id = d.account_id and n.type = d.type
+  and coalesce(n.actor_id, 0) = coalesce(d.actor_id, 0) and coalesce(n.actor, '') = coalesce(d.actor, '')
+  and coalesce(n.item_id, 0) = coalesce(d.item_id, 0);
+create unique index if not exists unique_notification on notifications (account_id, type, coalesce(actor_id, 0), coalesce(actor, ''), coalesce(item_id, 0));
+
+-- name: create-saved-items
+create table if not exists saved_items (
+  account_id int references accounts(id) on delete cascade,
+  item_id int references items(id) on delete cascade,
+  created_at timestamp default current_timestamp,
+  constraint saved_items_pk primary key (account_id, item_id)
+);
+
+-- name: create-invites
+create table if not exists invites (
+  id serial constraint invites_pk primary key,
+  code varchar not null constraint invites_code_key unique,
+  created_by int references accounts(id) on delete cascade, -- the account that created the invite
+  max_uses int not null default 1,
+  uses int not null default 0,
+  created_at timestamp default current_timestamp,
+  expires_at timestamp default NULL
+);
+create index if not exists invites_created_by_idx on invites (created_by, created_at);
+create table if not exists invitations (
+  account_id int references accounts(id) on delete cascade constraint invitations_pk primary key, -- the invited account
+  invited_by int references accounts(id) on delete set null default NULL, -- the account that created the invite
+  invite_id int references invites(id) on delete set null default NULL,
+  created_at timestamp default current_timestamp
+);
+create index if not exists invitations_invited_by_idx on invitations (invited_by);
+
+-- name: create-rate-limits
+create table if not exists rate_limits (
+  key varchar constraint rate_limits_pk primary key, -- the action and the account or the address it's counted for
+  hits int not null default 0,
+  reset_at timestamp not null -- the end of the current window
+);
+create index if not exists rate_limits_reset_at_idx on rate_limits (reset_at);
+
+-- name: create-identities
+create table if not exists identities (
+  id serial constraint identities_pk primary key,
+  account_id int not null references accounts(id) on delete cascade,
+  provider varchar not null, -- the name of the external OAuth2 provider: github, gitlab, google
+  subject varchar not null, -- the identifier of the account at the provider
+  handle varchar default NULL,
+  email varchar default NULL,
+  created_at timestamp default current_timestamp,
+  last_login timestamp default NULL,
+  constraint identities_provider_subject_key unique (provider, subject)
+);
+create index if not exists identities_account_id_idx on identities (account_id);
+
+-- name: create-sessions
+create table if not exists sessions (
+  id varchar constraint sessions_pk primary key, -- the random identifier saved in the session cookie
+  account_id int not null references accounts(id) on delete cascade,
+  ip varchar default NULL, -- the address of the last request
+  user_agent varchar default NULL,
+  created_at timestamp default current_timestamp,
+  last_seen timestamp default current_timestamp
+);
+create index if not exists sessions_account_id_idx on sessions (account_id);
+
+-- name: create-poll-votes
+create table if not exists poll_votes (
+  item_id int references items(id) on delete cascade, -- the poll item
+  account_id int references accounts(id) on delete cascade default NULL, -- the account that voted
+  actor varchar not null default '', -- the IRI of the voter, for remote actors that we don't have an account for
+  choice varchar not null,
+  created_at timestamp default current_timestamp
+);
+create unique index if not exists poll_votes_unique_idx on poll_votes (item_id, coalesce(account_id, 0), actor, choice);
+
 -- name: create-instances
 create table instances
 (
@@ -229,9 +370,17 @@ CREATE TABLE IF NOT EXISTS access (
   scope varchar NOT NULL,
   redirect_uri varchar NOT NULL,
   extra jsonb DEFAULT NULL,
-  created_at timestamp with time zone NOT NULL
+  created_at timestamp with time zone NOT NULL,
+  ip varchar DEFAULT NULL, -- the address and the user agent of the last request made with the token
+  user_agent varchar DEFAULT NULL,
+  last_seen timestamp with time zone DEFAULT NULL
 );
 CREATE TABLE IF NOT EXISTS refresh (
   token varchar NOT NULL PRIMARY KEY,
   access varchar NOT NULL
 );
+
+-- name: alter-oauth-storage
+ALTER TABLE access ADD COLUMN IF NOT EXISTS ip varchar DEFAULT NULL;
+ALTER TABLE access ADD COLUMN IF NOT EXISTS user_agent varchar DEFAULT NULL;
+ALTER TABLE access ADD COLUMN IF NOT EXISTS last_seen timestamp with time zone DEFAULT NULL;
@@ -25,6 +25,7 @@ require (
 	github.com/openshift/osin v1.0.1
 	github.com/pborman/uuid v1.2.0 // indirect
 	github.com/sirupsen/logrus v1.2.0
+	github.com/skip2/go-qrcode v0.0.0-20190110000554-dc11ecdae0a9
 	github.com/spacemonkeygo/httpsig v0.0.0-20180701154948-c76789e3b41d
 	github.com/unrolled/render v0.0.0-20171102162132-65450fb6b2d3
 	github.com/writeas/go-nodeinfo v0.0.0-20180809171410-91102b12f3e1
@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"runtime"
 	"runtime/debug"
+	"time"
 )
 
 type notFound struct {
@@ -44,6 +45,11 @@ type timeout struct {
 	Err
 }
 
+type tooManyRequests struct {
+	Err
+	retryAfter time.Duration
+}
+
 type Err struct {
 	c error
 	m string
@@ -186,6 +192,12 @@ func NotTimeoutf(s string, args ...interface{}) error {
 func NewTimeout(e error, s string, args ...interface{}) error {
 	return &timeout{wrap(e, s, args...)}
 }
+func TooManyRequestsf(retryAfter time.Duration, s string, args ...interface{}) error {
+	return &tooManyRequests{wrap(nil, s, args...), retryAfter}
+}
+func NewTooManyRequests(e error, retryAfter time.Duration, s string, args ...interface{}) error {
+	return &tooManyRequests{wrap(e, s, args...), retryAfter}
+}
 func IsBadRequest(e error) bool {
 	return xerr.Is(e, badRequest{})
 }
@@ -213,6 +225,18 @@ func IsTimeout(e error) bool {
 func IsNotValid(e error) bool {
 	return xerr.Is(e, notValid{})
 }
+func IsTooManyRequests(e error) bool {
+	return xerr.Is(e, tooManyRequests{})
+}
+
+// RetryAfter returns the duration after which a request which was refused for being over its rate limit can be retried
+func RetryAfter(e error) (time.Duration, bool) {
+	var t *tooManyRequests
+	if !xerr.As(e, &t) {
+		return 0, false
+	}
+	return t.retryAfter, true
+}
 
 func isA(err1, err2 error) bool {
 	return reflect.TypeOf(err1) == reflect.TypeOf(err2)
@@ -244,6 +268,9 @@ func (m methodNotAllowed) Is(e error) bool {
 func (f forbidden) Is(e error) bool {
 	return isA(f, e)
 }
+func (t tooManyRequests) Is(e error) bool {
+	return isA(t, e)
+}
 
 func (n notFound) As(e interface{}) bool {
 	if r, okt := e.(*Err); okt {
@@ -308,3 +335,10 @@ func (f forbidden) As(e interface{}) bool {
 	}
 	return false
 }
+func (t tooManyRequests) As(e interface{}) bool {
+	if _, okt := e.(*Err); okt {
+		e = t.Err
+		return true
+	}
+	return false
+}
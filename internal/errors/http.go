@@ -86,6 +86,9 @@ func httpErrorResponse(e error) int {
 	if IsNotValid(e) {
 		return http.StatusNotAcceptable
 	}
+	if IsTooManyRequests(e) {
+		return http.StatusTooManyRequests
+	}
 	if IsMethodNotAllowed(e) {
 		return http.StatusMethodNotAllowed
 	}
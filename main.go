@@ -3,6 +3,7 @@ package main
 import (
 	"crypto/tls"
 	"flag"
+	"fmt"
 	"github.com/mariusor/littr.go/app/db"
 	"github.com/mariusor/littr.go/app/oauth"
 	"github.com/writeas/go-nodeinfo"
@@ -18,6 +19,9 @@ import (
 	"github.com/mariusor/littr.go/app"
 	"github.com/mariusor/littr.go/app/api"
 	"github.com/mariusor/littr.go/app/frontend"
+	"github.com/mariusor/littr.go/app/mail"
+	"github.com/mariusor/littr.go/app/media"
+	"github.com/mariusor/littr.go/app/ratelimit"
 	"github.com/mariusor/littr.go/internal/log"
 
 	"github.com/eyedeekay/httptunnel"
@@ -102,13 +106,47 @@ func main() {
 		app.Instance.Logger.Warn(err.Error())
 	}
 
+	var stor media.Storage
+	if local, err := media.NewLocalStorage(app.Instance.Config.StoragePath, fmt.Sprintf("%s/media", app.Instance.BaseURL)); err == nil {
+		stor = local
+	} else {
+		app.Instance.Logger.Warn(err.Error())
+	}
+
+	conf := app.Instance.Config
+	var mailer mail.Mailer = mail.LogMailer{Logger: app.Instance.Logger.New(log.Ctx{"package": "mail"})}
+	if len(conf.SMTP.Host) > 0 {
+		mailer = mail.NewSMTPMailer(conf.SMTP.Host, conf.SMTP.Port, conf.SMTP.User, conf.SMTP.Pw, conf.MailFrom)
+	} else if len(conf.MailPath) > 0 {
+		if fm, err := mail.NewFileMailer(conf.MailPath, conf.MailFrom); err == nil {
+			mailer = fm
+		} else {
+			app.Instance.Logger.Warn(err.Error())
+		}
+	}
+
+	limiter := ratelimit.Limiter{
+		Store:         ratelimit.NewMemoryStore(),
+		Limits:        conf.RateLimits,
+		NewAccountAge: conf.RateLimitNewAge,
+		MinScore:      conf.RateLimitMinScore,
+	}
+	if conf.RateLimitStore == "postgres" {
+		limiter.Store = &db.RateLimitStore{DB: db.Config.DB}
+	}
+
 	front, err := frontend.Init(frontend.Config{
-		Env:         e,
-		Logger:      app.Instance.Logger.New(log.Ctx{"package": "frontend"}),
-		Secure:      app.Instance.Secure,
-		BaseURL:     app.Instance.BaseURL,
-		HostName:    app.Instance.HostName,
-		OAuthServer: os,
+		Env:           e,
+		Logger:        app.Instance.Logger.New(log.Ctx{"package": "frontend"}),
+		Secure:        app.Instance.Secure,
+		BaseURL:       app.Instance.BaseURL,
+		HostName:      app.Instance.HostName,
+		OAuthServer:   os,
+		Storage:       stor,
+		MaxUploadSize: app.Instance.Config.MaxUploadSize,
+		Mailer:        mailer,
+		RateLimiter:   limiter,
+		TrustProxy:    conf.TrustProxy,
 	})
 	if err != nil {
 		app.Instance.Logger.Warn(err.Error())
@@ -118,6 +156,7 @@ func main() {
 		Logger:      app.Instance.Logger.New(log.Ctx{"package": "api"}),
 		BaseURL:     app.Instance.APIURL,
 		OAuthServer: os,
+		RateLimiter: limiter,
 	})
 	//processing.InitQueues(&app.Instance)
 	//processing.Logger = app.Instance.Logger.Dev(log.Ctx{"package": "processing"})
@@ -125,6 +164,11 @@ func main() {
 	app.Logger = app.Instance.Logger.New(log.Ctx{"package": "app"})
 	db.Logger = app.Instance.Logger.New(log.Ctx{"package": "db"})
 
+	// publish the scheduled items when their time comes
+	stopScheduler := make(chan struct{})
+	defer close(stopScheduler)
+	go db.RunScheduler(db.ScheduleInterval, stopScheduler)
+
 	// Routes
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
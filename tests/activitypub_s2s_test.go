@@ -67,6 +67,22 @@ var S2STests = testPairs{
 			code: http.StatusForbidden, // Not sure why this fails
 		},
 	}},
+	"Move_Forged": {{
+		req: testReq{
+			met:     http.MethodPost,
+			url:     inboxURL,
+			account: &littrAcct,
+			body: fmt.Sprintf(`{
+"type": "Move",
+"actor": "%s/self/following/dc6f5f5bf55bc1073715c98c69fa7ca8",
+"object": "%s/self/following/dc6f5f5bf55bc1073715c98c69fa7ca8",
+"target": "%s"
+}`, apiURL, apiURL, littrAcct.id),
+		},
+		res: testRes{
+			code: http.StatusForbidden,
+		},
+	}},
 }
 
 func Test_S2SRequests(t *testing.T) {